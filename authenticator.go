@@ -0,0 +1,112 @@
+package rsocket
+
+import (
+	"errors"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+type (
+	// Authenticator authenticates credentials carried by message/x.rsocket.authentication.v0 metadata.
+	// It can be bound by ServerBuilder.Authenticator.
+	Authenticator interface {
+		// Authenticate returns the principal of credentials, or an error if they are invalid.
+		Authenticate(auth extension.Authentication) (principal interface{}, err error)
+	}
+
+	// AuthenticatorFunc is an adapter to allow the use of ordinary functions as Authenticator.
+	AuthenticatorFunc func(auth extension.Authentication) (principal interface{}, err error)
+)
+
+// Authenticate calls fn(auth).
+func (fn AuthenticatorFunc) Authenticate(auth extension.Authentication) (interface{}, error) {
+	return fn(auth)
+}
+
+type authError struct {
+	code common.ErrorCode
+	data []byte
+}
+
+func (e authError) Error() string {
+	return string(e.data)
+}
+
+func (e authError) ErrorCode() common.ErrorCode {
+	return e.code
+}
+
+func (e authError) ErrorData() []byte {
+	return e.data
+}
+
+// connectionAuthenticator authenticates SETUP of a connection and requests received by it.
+type connectionAuthenticator struct {
+	authenticator    Authenticator
+	metadataMimeType string
+	authenticated    bool
+	principal        interface{}
+}
+
+func newConnectionAuthenticator(authenticator Authenticator, setup *framing.FrameSetup) (*connectionAuthenticator, *framing.FrameError) {
+	c := &connectionAuthenticator{
+		authenticator:    authenticator,
+		metadataMimeType: setup.MetadataMimeType(),
+	}
+	metadata, _ := setup.Metadata()
+	auth, ok := extractAuthentication(c.metadataMimeType, metadata)
+	if !ok {
+		// Requests should carry their own credentials.
+		return c, nil
+	}
+	principal, err := authenticator.Authenticate(auth)
+	if err != nil {
+		return nil, framing.NewFrameError(0, common.ErrorCodeRejectedSetup, []byte(err.Error()))
+	}
+	c.authenticated = true
+	c.principal = principal
+	return c, nil
+}
+
+// authenticate returns principal of request, credentials of request take precedence over ones of SETUP.
+func (c *connectionAuthenticator) authenticate(msg payload.Payload) (interface{}, error) {
+	metadata, _ := msg.Metadata()
+	if auth, ok := extractAuthentication(c.metadataMimeType, metadata); ok {
+		principal, err := c.authenticator.Authenticate(auth)
+		if err != nil {
+			return nil, authError{code: common.ErrorCodeRejected, data: []byte(err.Error())}
+		}
+		return principal, nil
+	}
+	if c.authenticated {
+		return c.principal, nil
+	}
+	return nil, authError{code: common.ErrorCodeRejected, data: []byte(errUnauthenticated.Error())}
+}
+
+func extractAuthentication(metadataMimeType string, metadata []byte) (auth extension.Authentication, ok bool) {
+	if len(metadata) < 1 {
+		return
+	}
+	switch metadataMimeType {
+	case extension.MessageAuthentication.String():
+		var err error
+		auth, err = extension.ParseAuthentication(metadata)
+		ok = err == nil
+	case extension.MessageCompositeMetadata.String():
+		rangeComposite(metadata, func(mimeType string, metadata []byte) {
+			if ok || mimeType != extension.MessageAuthentication.String() {
+				return
+			}
+			if found, err := extension.ParseAuthentication(metadata); err == nil {
+				auth, ok = found, true
+			}
+		})
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package rsocket_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Authenticator(t *testing.T) {
+	const addr = "127.0.0.1:7998"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	whoami := func(ctx context.Context) Payload {
+		principal, ok := PrincipalFromContext(ctx)
+		if !ok {
+			return NewString("anonymous", "")
+		}
+		return NewString(fmt.Sprint(principal), "")
+	}
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Authenticator(AuthenticatorFunc(func(auth extension.Authentication) (interface{}, error) {
+				switch auth.Type() {
+				case "simple":
+					username, password, err := extension.ParseSimpleAuth(auth)
+					if err != nil {
+						return nil, err
+					}
+					if password != "pass" {
+						return nil, errors.New("bad credentials")
+					}
+					return username, nil
+				case "bearer":
+					if string(auth.Payload()) != "token" {
+						return nil, errors.New("bad token")
+					}
+					return "bearer", nil
+				default:
+					return nil, errors.New("unsupported authentication")
+				}
+			})).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponseContext(func(ctx context.Context, msg Payload) mono.Mono {
+						return mono.Just(whoami(ctx))
+					}),
+					RequestChannelContext(func(ctx context.Context, msgs rx.Publisher) flux.Flux {
+						return msgs.(flux.Flux).Map(func(Payload) Payload {
+							return whoami(ctx)
+						})
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	connect := func(auth *extension.Authentication) (Client, error) {
+		builder := Connect().MetadataMimeType(extension.MessageCompositeMetadata.String())
+		if auth != nil {
+			metadata, err := extension.NewCompositeMetadataBuilder().
+				PushWellKnown(extension.MessageAuthentication, auth.Bytes()).
+				Build()
+			require.NoError(t, err)
+			builder = builder.SetupPayload(New(nil, metadata))
+		}
+		return builder.Transport("tcp://" + addr).Start(ctx)
+	}
+
+	// authenticated by SETUP
+	auth, err := extension.NewSimpleAuth("alice", "pass")
+	require.NoError(t, err)
+	cli, err := connect(&auth)
+	require.NoError(t, err)
+	res, err := cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", res.DataUTF8())
+	// credentials of request take precedence over ones of SETUP
+	res, err = Route(cli, "whoami").Authentication(extension.NewBearerAuth("token")).RetrieveMono().Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bearer", res.DataUTF8())
+	_ = cli.Close()
+
+	// authenticated by requests
+	cli, err = connect(nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+	_, err = cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	assertErrorCode(t, ErrorCodeRejected, err)
+	_, err = Route(cli, "whoami").Authentication(extension.NewBearerAuth("bad")).RetrieveMono().Block(ctx)
+	assertErrorCode(t, ErrorCodeRejected, err)
+	bob, err := extension.NewSimpleAuth("bob", "pass")
+	require.NoError(t, err)
+	res, err = Route(cli, "whoami").Authentication(bob).RetrieveMono().Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", res.DataUTF8())
+
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageAuthentication, extension.NewBearerAuth("token").Bytes()).
+		Build()
+	require.NoError(t, err)
+	var received []string
+	_, err = cli.RequestChannel(flux.Just(New(nil, metadata), NewString("second", ""))).
+		DoOnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bearer", "bearer"}, received)
+	_, err = cli.RequestChannel(flux.Just(NewString("first", ""))).BlockLast(ctx)
+	assertErrorCode(t, ErrorCodeRejected, err)
+
+	// rejected SETUP
+	auth, err = extension.NewSimpleAuth("alice", "bad")
+	require.NoError(t, err)
+	closed := make(chan error, 1)
+	metadata, err = extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageAuthentication, auth.Bytes()).
+		Build()
+	require.NoError(t, err)
+	rejected, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		SetupPayload(New(nil, metadata)).
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = rejected.Close()
+	}()
+	select {
+	case err := <-closed:
+		assertErrorCode(t, ErrorCodeRejectedSetup, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "rejected connection should be closed")
+	}
+}
+
+func assertErrorCode(t *testing.T, code ErrorCode, err error) {
+	require.Error(t, err)
+	e, ok := err.(Error)
+	require.True(t, ok, "should be rsocket error: %v", err)
+	assert.Equal(t, code, e.ErrorCode())
+}
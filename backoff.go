@@ -0,0 +1,39 @@
+package rsocket
+
+import (
+	"math"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+)
+
+// Backoff returns the delay before n-th attempt of connecting, n starts from 1.
+type Backoff = func(attempt int) time.Duration
+
+// ConstantBackoff returns a Backoff which always delays for the same duration.
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff returns a Backoff which starts with min delay and multiplies it after each attempt until max delay.
+func ExponentialBackoff(min, max time.Duration, multiplier float64) Backoff {
+	return func(attempt int) time.Duration {
+		delay := float64(min) * math.Pow(multiplier, float64(attempt-1))
+		if delay > float64(max) {
+			return max
+		}
+		return time.Duration(delay)
+	}
+}
+
+// JitteredBackoff returns a Backoff which randomizes delays of another Backoff.
+// Jitter is a factor in [0,1], a delay d will be randomized in [d*(1-jitter), d*(1+jitter)].
+func JitteredBackoff(backoff Backoff, jitter float64) Backoff {
+	jitter = math.Max(0, math.Min(jitter, 1))
+	return func(attempt int) time.Duration {
+		delay := float64(backoff(attempt))
+		return time.Duration(delay + delay*jitter*(common.RandFloat64()*2-1))
+	}
+}
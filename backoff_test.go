@@ -0,0 +1,28 @@
+package rsocket_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	constant := ConstantBackoff(time.Second)
+	assert.Equal(t, time.Second, constant(1))
+	assert.Equal(t, time.Second, constant(10))
+
+	exponential := ExponentialBackoff(100*time.Millisecond, time.Second, 2)
+	assert.Equal(t, 100*time.Millisecond, exponential(1))
+	assert.Equal(t, 200*time.Millisecond, exponential(2))
+	assert.Equal(t, 800*time.Millisecond, exponential(4))
+	assert.Equal(t, time.Second, exponential(5))
+	assert.Equal(t, time.Second, exponential(100))
+
+	jittered := JitteredBackoff(constant, 0.5)
+	for i := 1; i <= 100; i++ {
+		delay := jittered(i)
+		assert.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond, "delay %s out of range", delay)
+	}
+}
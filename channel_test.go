@@ -0,0 +1,338 @@
+package rsocket_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// infinite emits payloads until it is cancelled.
+func infinite(prefix string) flux.Flux {
+	done := make(chan struct{})
+	return flux.
+		Create(func(ctx context.Context, s flux.Sink) {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-time.After(10 * time.Millisecond):
+					s.Next(NewString(fmt.Sprintf("%s%d", prefix, i), ""))
+				}
+			}
+		}).
+		DoFinally(func(s rx.SignalType) {
+			close(done)
+		})
+}
+
+func recordSignal(signals chan<- rx.SignalType) rx.FnFinally {
+	return func(s rx.SignalType) {
+		signals <- s
+	}
+}
+
+func waitSignal(t *testing.T, signals <-chan rx.SignalType, expect rx.SignalType, msgAndArgs ...interface{}) {
+	select {
+	case s := <-signals:
+		assert.Equal(t, expect, s, msgAndArgs...)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "wait signal timeout", msgAndArgs...)
+	}
+}
+
+func TestRequestChannel_Cancel(t *testing.T) {
+	const addr = "127.0.0.1:8000"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverInbound := make(chan rx.SignalType, 1)
+	serverOutbound := make(chan rx.SignalType, 1)
+	serverReceived := make(chan string, 16)
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				mode := setup.DataUTF8()
+				return NewAbstractSocket(RequestChannel(func(msgs rx.Publisher) flux.Flux {
+					inputs := msgs.(flux.Flux).DoFinally(recordSignal(serverInbound))
+					switch mode {
+					case "responder-cancel":
+						// only the first payload is wanted
+						return inputs.Take(1)
+					case "responder-complete":
+						inputs.
+							DoOnNext(func(input Payload) {
+								serverReceived <- input.DataUTF8()
+							}).
+							Subscribe(context.Background())
+						return flux.Empty().DoFinally(recordSignal(serverOutbound))
+					case "requester-complete":
+						return flux.Create(func(ctx context.Context, s flux.Sink) {
+							var n int
+							if _, err := inputs.DoOnNext(func(input Payload) {
+								n++
+							}).BlockLast(ctx); err != nil {
+								s.Error(err)
+								return
+							}
+							s.Next(NewString(fmt.Sprintf("received %d", n), ""))
+							s.Next(NewString("after complete", ""))
+							s.Complete()
+						})
+					case "responder-error":
+						inputs.Subscribe(context.Background())
+						return flux.Error(errors.New("boom"))
+					default:
+						inputs.Subscribe(context.Background())
+						return infinite("server").DoFinally(recordSignal(serverOutbound))
+					}
+				})), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	connect := func(mode string) Client {
+		cli, err := Connect().
+			SetupPayload(NewString(mode, "")).
+			Transport("tcp://" + addr).
+			Start(ctx)
+		require.NoError(t, err)
+		return cli
+	}
+
+	t.Run("RequesterCancel", func(t *testing.T) {
+		cli := connect("requester-cancel")
+		defer func() {
+			_ = cli.Close()
+		}()
+		clientOutbound := make(chan rx.SignalType, 1)
+		last, err := cli.RequestChannel(infinite("client").DoFinally(recordSignal(clientOutbound))).
+			Take(2).
+			BlockLast(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "server1", last.DataUTF8())
+		// cancelling receiving cancels both directions, inbound of responder is completed as requester stops sending
+		waitSignal(t, clientOutbound, rx.SignalCancel, "client outbound")
+		waitSignal(t, serverOutbound, rx.SignalCancel, "server outbound")
+		waitSignal(t, serverInbound, rx.SignalComplete, "server inbound")
+	})
+
+	t.Run("ResponderCancel", func(t *testing.T) {
+		cli := connect("responder-cancel")
+		defer func() {
+			_ = cli.Close()
+		}()
+		clientOutbound := make(chan rx.SignalType, 1)
+		var received []string
+		_, err := cli.RequestChannel(infinite("client").DoFinally(recordSignal(clientOutbound))).
+			DoOnNext(func(input Payload) {
+				received = append(received, input.DataUTF8())
+			}).
+			BlockLast(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client0"}, received)
+		waitSignal(t, serverInbound, rx.SignalCancel, "server inbound")
+		// CANCEL from responder stops outbound of requester
+		waitSignal(t, clientOutbound, rx.SignalCancel, "client outbound")
+	})
+
+	t.Run("ResponderError", func(t *testing.T) {
+		cli := connect("responder-error")
+		defer func() {
+			_ = cli.Close()
+		}()
+		clientOutbound := make(chan rx.SignalType, 1)
+		_, err := cli.RequestChannel(infinite("client").DoFinally(recordSignal(clientOutbound))).BlockLast(ctx)
+		assertErrorCode(t, ErrorCodeApplicationError, err)
+		assert.Equal(t, "boom", string(err.(Error).ErrorData()))
+		// ERROR terminates both directions
+		waitSignal(t, clientOutbound, rx.SignalCancel, "client outbound")
+		waitSignal(t, serverInbound, rx.SignalError, "server inbound")
+	})
+
+	t.Run("RequesterError", func(t *testing.T) {
+		cli := connect("requester-error")
+		defer func() {
+			_ = cli.Close()
+		}()
+		sending := flux.Create(func(ctx context.Context, s flux.Sink) {
+			s.Next(NewString("first", ""))
+			s.Error(errors.New("oops"))
+		})
+		_, err := cli.RequestChannel(sending).BlockLast(ctx)
+		assert.Error(t, err)
+		// ERROR terminates both directions
+		waitSignal(t, serverInbound, rx.SignalError, "server inbound")
+		waitSignal(t, serverOutbound, rx.SignalCancel, "server outbound")
+	})
+
+	t.Run("RequesterComplete", func(t *testing.T) {
+		cli := connect("requester-complete")
+		defer func() {
+			_ = cli.Close()
+		}()
+		var received []string
+		_, err := cli.RequestChannel(flux.Just(NewString("a", ""), NewString("b", ""))).
+			DoOnNext(func(input Payload) {
+				received = append(received, input.DataUTF8())
+			}).
+			BlockLast(ctx)
+		require.NoError(t, err)
+		// responder keeps sending after requester completes
+		assert.Equal(t, []string{"received 2", "after complete"}, received)
+		waitSignal(t, serverInbound, rx.SignalComplete, "server inbound")
+	})
+
+	t.Run("ResponderComplete", func(t *testing.T) {
+		cli := connect("responder-complete")
+		defer func() {
+			_ = cli.Close()
+		}()
+		sending := flux.Create(func(ctx context.Context, s flux.Sink) {
+			for i := 0; i < 3; i++ {
+				s.Next(NewString(fmt.Sprintf("client%d", i), ""))
+				time.Sleep(10 * time.Millisecond)
+			}
+			s.Complete()
+		})
+		last, err := cli.RequestChannel(sending).BlockLast(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, last)
+		waitSignal(t, serverOutbound, rx.SignalComplete, "server outbound")
+		// requester keeps sending after responder completes
+		waitSignal(t, serverInbound, rx.SignalComplete, "server inbound")
+		close(serverReceived)
+		var received []string
+		for it := range serverReceived {
+			received = append(received, it)
+		}
+		assert.Equal(t, []string{"client0", "client1", "client2"}, received)
+	})
+}
+
+// rawConn sends and receives frames without any flow control, so it can break the protocol.
+type rawConn struct {
+	net.Conn
+	decoder *transport.LengthBasedFrameDecoder
+}
+
+func (p *rawConn) write(t *testing.T, f framing.Frame) {
+	_, err := common.NewUint24(f.Len()).WriteTo(p.Conn)
+	require.NoError(t, err)
+	_, err = f.WriteTo(p.Conn)
+	require.NoError(t, err)
+}
+
+func (p *rawConn) read(t *testing.T) framing.Frame {
+	require.NoError(t, p.SetReadDeadline(time.Now().Add(3*time.Second)))
+	raw, err := p.decoder.Read()
+	require.NoError(t, err)
+	bf := common.NewByteBuff()
+	_, err = bf.Write(raw[framing.HeaderLen:])
+	require.NoError(t, err)
+	f, err := framing.NewFromBase(framing.NewBaseFrame(framing.ParseFrameHeader(raw), bf))
+	require.NoError(t, err)
+	return f
+}
+
+func TestRequestChannel_ExceedCredits(t *testing.T) {
+	const addr = "127.0.0.1:8001"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan string, 16)
+	inboundErr := make(chan error, 1)
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(RequestChannel(func(msgs rx.Publisher) flux.Flux {
+					msgs.Subscribe(
+						context.Background(),
+						rx.OnSubscribe(func(s rx.Subscription) {
+							// the first payload and one more
+							s.Request(2)
+						}),
+						rx.OnNext(func(input Payload) {
+							received <- input.DataUTF8()
+						}),
+						rx.OnError(func(e error) {
+							inboundErr <- e
+						}),
+					)
+					return flux.Create(func(ctx context.Context, s flux.Sink) {
+					})
+				})), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer func() {
+		_ = c.Close()
+	}()
+	conn := &rawConn{Conn: c, decoder: transport.NewLengthBasedFrameDecoder(c)}
+	conn.write(t, framing.NewFrameSetup(common.DefaultVersion, 10*time.Second, 60*time.Second, nil, []byte("text/plain"), []byte("text/plain"), nil, nil, false))
+	conn.write(t, framing.NewFrameRequestChannel(1, 1, []byte("0"), nil, framing.FlagNext))
+
+	// REQUEST_N is sent as the subscriber requests, the first payload takes one of them.
+	f := conn.read(t)
+	require.IsType(t, &framing.FrameRequestN{}, f)
+	assert.Equal(t, uint32(1), f.(*framing.FrameRequestN).N())
+
+	conn.write(t, framing.NewFramePayload(1, []byte("1"), nil, framing.FlagNext))
+	conn.write(t, framing.NewFramePayload(1, []byte("2"), nil, framing.FlagNext))
+
+	f = conn.read(t)
+	require.IsType(t, &framing.FrameError{}, f)
+	assert.Equal(t, uint32(1), f.Header().StreamID())
+	assert.Equal(t, ErrorCodeInvalid, f.(*framing.FrameError).ErrorCode())
+
+	select {
+	case err := <-inboundErr:
+		assertErrorCode(t, ErrorCodeInvalid, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "inbound should be terminated")
+	}
+	close(received)
+	var all []string
+	for it := range received {
+		all = append(all, it)
+	}
+	assert.Equal(t, []string{"0", "1"}, all)
+}
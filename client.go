@@ -3,19 +3,26 @@ package rsocket
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/rsocket/rsocket-go/internal/instrument"
 	"github.com/rsocket/rsocket-go/internal/socket"
 	"github.com/rsocket/rsocket-go/internal/transport"
+	"github.com/rsocket/rsocket-go/metrics"
 	"github.com/rsocket/rsocket-go/payload"
+	"go.uber.org/atomic"
 )
 
 var (
 	_defaultMimeType = []byte("application/binary")
 	_noopSocket      = NewAbstractSocket()
+	errConnConsumed  = errors.New("rsocket: connection has been consumed")
 )
 
 type (
@@ -40,6 +47,10 @@ type (
 		//	InsecureSkipVerify: true,
 		// }
 		StartTLS(ctx context.Context, tc *tls.Config) (Client, error)
+		// StartWithConn start a client socket over an established connection instead of dialing transport URI.
+		// Frames are length-prefixed as TCP transport, so conn can be any stream, eg: net.Pipe or a SSH tunnel.
+		// The connection can not be dialed again, so reconnect and resume will fail after it is lost.
+		StartWithConn(ctx context.Context, conn net.Conn) (Client, error)
 	}
 
 	// ClientBuilder can be used to build a RSocket client.
@@ -51,6 +62,9 @@ type (
 		KeepAlive(tickPeriod, ackTimeout time.Duration, missedAcks int) ClientBuilder
 		// Resume enable the functionality of resume.
 		Resume(opts ...ClientResumeOptions) ClientBuilder
+		// Reconnect enable reconnecting with a new SETUP after transport is lost.
+		// It will be ignored if resume is enabled.
+		Reconnect(policy ReconnectPolicy) ClientBuilder
 		// Lease enable the functionality of lease.
 		Lease() ClientBuilder
 		// DataMimeType is used to set payload data MIME type.
@@ -63,6 +77,21 @@ type (
 		SetupPayload(setup payload.Payload) ClientBuilder
 		// OnClose register handler when client socket closed.
 		OnClose(fn func(error)) ClientBuilder
+		// Interceptor appends interceptors which wrap requests of both sides, they will be invoked in order.
+		Interceptor(interceptors ...Interceptor) ClientBuilder
+		// Metrics enables recording metrics of connections into registry.
+		Metrics(registry metrics.Registry) ClientBuilder
+		// Prefetch limits credits which are requested from peer at a time by RequestStream and RequestChannel.
+		// Unbounded demand of subscribers will be requested in batches of prefetch,
+		// more is requested when 75% of them are received. Default zero means no limit.
+		Prefetch(prefetch int) ClientBuilder
+		// RouteAuthentication sets the default authentication of requests built by Route,
+		// it is attached if request doesn't set its own authentication.
+		RouteAuthentication(auth extension.Authentication) ClientBuilder
+		// Extension registers a handler for EXT frames of custom extended type from server.
+		// EXT frames of unregistered extended types will be skipped if they can be ignored,
+		// otherwise the connection will be closed.
+		Extension(extendedType uint32, handler ExtensionHandler) ClientBuilder
 		// Acceptor set acceptor for RSocket client.
 		Acceptor(acceptor ClientSocketAcceptor) ClientTransportBuilder
 	}
@@ -75,11 +104,17 @@ type (
 		// "tcp://127.0.0.1:7878" means a TCP RSocket transport.
 		// "ws://127.0.0.1:8080/a/b/c" means a Websocket RSocket transport.
 		// "wss://127.0.0.1:8080/a/b/c" means a  Websocket RSocket transport with HTTPS.
+		// "udp://127.0.0.1:7878" means an experimental UDP RSocket transport, frames are reliable and ordered per stream,
+		// it cannot be used with Resume because frame positions are not kept across streams.
+		// "mem://foo" means an in-process RSocket transport to server "foo",
+		// add "?serialize=true" to pass frames in bytes instead of objects.
+		// Schemes of custom transports can be registered by RegisterTransport.
 		Transport(uri string, opts ...TransportOpts) ClientStarter
 	}
 
 	setupClientSocket interface {
-		Client
+		CloseableRSocket
+		ExtensionSender
 		Setup(ctx context.Context, setup *socket.SetupInfo) error
 	}
 )
@@ -101,8 +136,12 @@ func Connect() ClientBuilder {
 type transportOpts struct {
 	addr    string
 	headers map[string][]string
+	dialer  Dialer
 }
 
+// Dialer dials a connection to the address on the named network, eg: net.Dialer.DialContext.
+type Dialer = transport.Dialer
+
 // WithWebsocketHeaders attach headers for websocket transport.
 func WithWebsocketHeaders(headers map[string][]string) TransportOpts {
 	return func(opts *transportOpts) {
@@ -110,16 +149,30 @@ func WithWebsocketHeaders(headers map[string][]string) TransportOpts {
 	}
 }
 
+// WithDialer dials connections of tcp and unix transport by dialer instead of net.Dial.
+// Connections will be wrapped with TLS if client is started by StartTLS.
+func WithDialer(dialer Dialer) TransportOpts {
+	return func(opts *transportOpts) {
+		opts.dialer = dialer
+	}
+}
+
 // TransportOpts represents options of transport.
 type TransportOpts = func(*transportOpts)
 
 type implClientBuilder struct {
-	resume   *resumeOpts
-	fragment int
-	tpOpts   *transportOpts
-	setup    *socket.SetupInfo
-	acceptor ClientSocketAcceptor
-	onCloses []func(error)
+	resume    *resumeOpts
+	reconnect *ReconnectPolicy
+	fragment  int
+	tpOpts    *transportOpts
+	setup     *socket.SetupInfo
+	acceptor  ClientSocketAcceptor
+	onCloses  []func(error)
+	intercept []Interceptor
+	metrics   *instrument.Recorder
+	prefetch  int
+	ext       *socket.Extensions
+	routeAuth *extension.Authentication
 }
 
 func (p *implClientBuilder) Lease() ClientBuilder {
@@ -137,6 +190,11 @@ func (p *implClientBuilder) Resume(opts ...ClientResumeOptions) ClientBuilder {
 	return p
 }
 
+func (p *implClientBuilder) Reconnect(policy ReconnectPolicy) ClientBuilder {
+	p.reconnect = &policy
+	return p
+}
+
 func (p *implClientBuilder) Fragment(mtu int) ClientBuilder {
 	p.fragment = mtu
 	return p
@@ -147,6 +205,31 @@ func (p *implClientBuilder) OnClose(fn func(error)) ClientBuilder {
 	return p
 }
 
+func (p *implClientBuilder) Interceptor(interceptors ...Interceptor) ClientBuilder {
+	p.intercept = append(p.intercept, interceptors...)
+	return p
+}
+
+func (p *implClientBuilder) Metrics(registry metrics.Registry) ClientBuilder {
+	p.metrics = instrument.NewRecorder(registry, metrics.RoleClient)
+	return p
+}
+
+func (p *implClientBuilder) Prefetch(prefetch int) ClientBuilder {
+	p.prefetch = prefetch
+	return p
+}
+
+func (p *implClientBuilder) RouteAuthentication(auth extension.Authentication) ClientBuilder {
+	p.routeAuth = &auth
+	return p
+}
+
+func (p *implClientBuilder) Extension(extendedType uint32, handler ExtensionHandler) ClientBuilder {
+	registerExtension(&p.ext, extendedType, handler)
+	return p
+}
+
 func (p *implClientBuilder) KeepAlive(tickPeriod, ackTimeout time.Duration, missedAcks int) ClientBuilder {
 	p.setup.KeepaliveInterval = tickPeriod
 	p.setup.KeepaliveLifetime = time.Duration(missedAcks) * ackTimeout
@@ -194,41 +277,99 @@ func (p *implClientBuilder) Transport(transport string, opts ...TransportOpts) C
 }
 
 func (p *implClientBuilder) StartTLS(ctx context.Context, tc *tls.Config) (Client, error) {
-	return p.start(ctx, tc)
+	dial, err := p.dial(tc)
+	if err != nil {
+		return nil, err
+	}
+	return p.start(ctx, dial)
 }
 
 func (p *implClientBuilder) Start(ctx context.Context) (client Client, err error) {
-	return p.start(ctx, nil)
+	dial, err := p.dial(nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.start(ctx, dial)
 }
 
-func (p *implClientBuilder) start(ctx context.Context, tc *tls.Config) (client Client, err error) {
-	var uri *transport.URI
-	uri, err = transport.ParseURI(p.tpOpts.addr)
+func (p *implClientBuilder) StartWithConn(ctx context.Context, conn net.Conn) (Client, error) {
+	consumed := atomic.NewBool(false)
+	return p.start(ctx, func(ctx context.Context) (*transport.Transport, error) {
+		if !consumed.CAS(false, true) {
+			return nil, errConnConsumed
+		}
+		return transport.NewTCPClientTransport(conn), nil
+	})
+}
+
+// dial returns the function which creates a transport for each connection.
+func (p *implClientBuilder) dial(tc *tls.Config) (transport.ClientTransportFunc, error) {
+	uri, err := transport.ParseURI(p.tpOpts.addr)
 	if err != nil {
-		return
+		return nil, err
+	}
+	if dialer := p.tpOpts.dialer; dialer != nil {
+		return func(ctx context.Context) (*transport.Transport, error) {
+			return uri.DialClientTransport(ctx, dialer, tc)
+		}, nil
+	}
+	var headers map[string][]string
+	if uri.IsWebsocket() {
+		headers = p.tpOpts.headers
 	}
+	return func(ctx context.Context) (*transport.Transport, error) {
+		return uri.MakeClientTransport(ctx, tc, headers)
+	}, nil
+}
 
+func (p *implClientBuilder) start(ctx context.Context, dial transport.ClientTransportFunc) (client Client, err error) {
 	// create a blank socket.
 	err = fragmentation.IsValidFragment(p.fragment)
 	if err != nil {
 		return nil, err
 	}
 
+	// create a reconnecting client, a new socket will be created for each connection.
+	if p.resume == nil && p.reconnect != nil {
+		var cs setupClientSocket
+		cs = socket.NewClientReconnect(dial, p.reconnect.toSocketPolicy(), func() *socket.DuplexRSocket {
+			sk := socket.NewClientDuplexRSocket(p.fragment, p.setup.KeepaliveInterval)
+			sk.SetMetrics(p.metrics)
+			sk.SetPrefetch(p.prefetch)
+			sk.SetExtensions(p.ext)
+			sk.Intercept(p.intercept...)
+			return sk
+		}, func() socket.Responder {
+			if p.acceptor != nil {
+				return p.acceptor(cs)
+			}
+			return _noopSocket
+		})
+		for _, closer := range p.onCloses {
+			cs.OnClose(closer)
+		}
+		err = cs.Setup(ctx, p.setup)
+		if err == nil {
+			client = newClient(cs, p.setup, p.routeAuth)
+		}
+		return
+	}
+
 	sk := socket.NewClientDuplexRSocket(
 		p.fragment,
 		p.setup.KeepaliveInterval,
 	)
-	var headers map[string][]string
-	if uri.IsWebsocket() {
-		headers = p.tpOpts.headers
-	}
+	sk.SetMetrics(p.metrics)
+	sk.SetPrefetch(p.prefetch)
+	sk.SetExtensions(p.ext)
+	sk.Intercept(p.intercept...)
 	// create a client.
 	var cs setupClientSocket
 	if p.resume != nil {
 		p.setup.Token = p.resume.tokenGen()
-		cs = socket.NewClientResume(uri, sk, tc, headers)
+		cs = socket.NewClientResume(dial, sk, &p.resume.policy)
 	} else {
-		cs = socket.NewClient(uri, sk, tc, headers)
+		cs = socket.NewClient(dial, sk)
 	}
 	if p.acceptor != nil {
 		sk.SetResponder(p.acceptor(cs))
@@ -246,13 +387,47 @@ func (p *implClientBuilder) start(ctx context.Context, tc *tls.Config) (client C
 	// setup client.
 	err = cs.Setup(ctx, p.setup)
 	if err == nil {
-		client = cs
+		client = newClient(cs, p.setup, p.routeAuth)
 	}
 	return
 }
 
+type implClient struct {
+	CloseableRSocket
+	ExtensionSender
+	setup     socket.SetupInfo
+	routeAuth *extension.Authentication
+}
+
+func newClient(cs setupClientSocket, setup *socket.SetupInfo, routeAuth *extension.Authentication) *implClient {
+	return &implClient{
+		CloseableRSocket: cs,
+		ExtensionSender:  cs,
+		setup:            *setup,
+		routeAuth:        routeAuth,
+	}
+}
+
+// ResumeEventType is type of resume event.
+type ResumeEventType = socket.ResumeEventType
+
+// ResumeEvent represents an attempt or outcome of resuming, see WithClientResumeListener.
+type ResumeEvent = socket.ResumeEvent
+
+const (
+	// ResumeAttempt means a new attempt of resuming starts.
+	ResumeAttempt = socket.ResumeAttempt
+	// ResumeSuccess means an attempt of resuming succeeds.
+	ResumeSuccess = socket.ResumeSuccess
+	// ResumeFailure means an attempt of resuming fails, next attempt may start later.
+	ResumeFailure = socket.ResumeFailure
+	// ResumeGiveUp means no more attempt will start, client will be closed.
+	ResumeGiveUp = socket.ResumeGiveUp
+)
+
 type resumeOpts struct {
 	tokenGen func() []byte
+	policy   socket.ResumePolicy
 }
 
 func newResumeOpts() *resumeOpts {
@@ -272,3 +447,41 @@ func WithClientResumeToken(gen func() []byte) ClientResumeOptions {
 		opts.tokenGen = gen
 	}
 }
+
+// WithClientResumeBackoff sets the backoff strategy of resume attempts, default is a constant delay of 1s.
+// See ConstantBackoff, ExponentialBackoff and JitteredBackoff.
+func WithClientResumeBackoff(backoff Backoff) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.policy.Backoff = backoff
+	}
+}
+
+// WithClientResumeMaxAttempts sets the max number of resume attempts after each disconnection.
+// Client will be closed if all attempts failed, default is 0 which means no limit.
+func WithClientResumeMaxAttempts(n int) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.policy.MaxAttempts = n
+	}
+}
+
+// WithClientResumeWindow sets the max duration of resuming after each disconnection.
+// Client will be closed if it cannot resume in the window, default is 0 which means no limit.
+func WithClientResumeWindow(window time.Duration) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.policy.Window = window
+	}
+}
+
+// WithClientResumeTimeout sets the timeout of waiting for RESUME_OK in each attempt, default is 10s.
+func WithClientResumeTimeout(timeout time.Duration) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.policy.Timeout = timeout
+	}
+}
+
+// WithClientResumeListener registers a handler of resume attempts and outcomes.
+func WithClientResumeListener(fn func(event ResumeEvent)) ClientResumeOptions {
+	return func(opts *resumeOpts) {
+		opts.policy.OnEvent = fn
+	}
+}
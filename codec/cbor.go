@@ -0,0 +1,745 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Major types of CBOR.
+const (
+	cborUint byte = iota << 5
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const (
+	cborFalse      = cborSimple | 20
+	cborTrue       = cborSimple | 21
+	cborNull       = cborSimple | 22
+	cborUndefined  = cborSimple | 23
+	cborFloat16    = cborSimple | 25
+	cborFloat32    = cborSimple | 26
+	cborFloat64    = cborSimple | 27
+	cborBreak      = cborSimple | 31
+	cborIndefinite = 31
+	cborMaxDepth   = 256
+)
+
+var (
+	errCBORTruncated  = errors.New("codec: truncated CBOR data")
+	errCBORTooDeep    = errors.New("codec: CBOR data is nested too deep")
+	errCBORIndefinite = errors.New("codec: invalid indefinite length CBOR item")
+	cborFieldsCache   sync.Map
+)
+
+// cborCodec implements the subset of CBOR which covers the data model of encoding/json, so data can be
+// exchanged with RSocket implementations of other languages without a third-party dependency.
+// Integers, floats, strings, byte strings, arrays, maps, booleans and null are supported.
+// Decoding also accepts indefinite length items and half precision floats that other encoders may produce,
+// tags are skipped and their content is decoded as is, other simple values are rejected.
+type cborCodec struct{}
+
+func (cborCodec) Encode(v interface{}) ([]byte, error) {
+	e := &cborEncoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+func (cborCodec) Decode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("codec: cannot decode CBOR into %T, a non-nil pointer is required", v)
+	}
+	d := &cborDecoder{data: data}
+	if err := d.decode(rv.Elem(), 0); err != nil {
+		return err
+	}
+	if d.off != len(d.data) {
+		return fmt.Errorf("codec: %d extra bytes after CBOR data", len(d.data)-d.off)
+	}
+	return nil
+}
+
+type cborField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// cborFields returns encoded fields of struct type, tag "cbor" takes precedence over tag "json".
+func cborFields(t reflect.Type) []cborField {
+	if cached, ok := cborFieldsCache.Load(t); ok {
+		return cached.([]cborField)
+	}
+	var fields []cborField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("cbor")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		if f.Anonymous && opts[0] == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Fields of embedded struct are promoted, embedded pointers are not supported.
+				if f.Type.Kind() == reflect.Struct {
+					for _, it := range cborFields(ft) {
+						it.index = append([]int{i}, it.index...)
+						fields = append(fields, it)
+					}
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		field := cborField{name: f.Name, index: []int{i}}
+		if opts[0] != "" {
+			field.name = opts[0]
+		}
+		for _, it := range opts[1:] {
+			if it == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	cborFieldsCache.Store(t, fields)
+	return fields
+}
+
+type cborEncoder struct {
+	buf bytes.Buffer
+}
+
+func (p *cborEncoder) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		p.buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		p.buf.WriteByte(major | 24)
+		p.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		p.buf.WriteByte(major | 25)
+		p.buf.Write(b[:])
+	case n <= math.MaxUint32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		p.buf.WriteByte(major | 26)
+		p.buf.Write(b[:])
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		p.buf.WriteByte(major | 27)
+		p.buf.Write(b[:])
+	}
+}
+
+func (p *cborEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		p.buf.WriteByte(cborNull)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			p.buf.WriteByte(cborNull)
+			return nil
+		}
+		return p.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			p.buf.WriteByte(cborTrue)
+		} else {
+			p.buf.WriteByte(cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n < 0 {
+			p.head(cborNegInt, uint64(-1-n))
+		} else {
+			p.head(cborUint, uint64(n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.head(cborUint, v.Uint())
+	case reflect.Float32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], math.Float32bits(float32(v.Float())))
+		p.buf.WriteByte(cborFloat32)
+		p.buf.Write(b[:])
+	case reflect.Float64:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(v.Float()))
+		p.buf.WriteByte(cborFloat64)
+		p.buf.Write(b[:])
+	case reflect.String:
+		p.head(cborText, uint64(v.Len()))
+		p.buf.WriteString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			p.buf.WriteByte(cborNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			p.head(cborBytes, uint64(v.Len()))
+			p.buf.Write(v.Bytes())
+			return nil
+		}
+		return p.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			p.head(cborBytes, uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				p.buf.WriteByte(byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		return p.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			p.buf.WriteByte(cborNull)
+			return nil
+		}
+		return p.encodeMap(v)
+	case reflect.Struct:
+		return p.encodeStruct(v)
+	default:
+		return fmt.Errorf("codec: cannot encode %s as CBOR", v.Type())
+	}
+	return nil
+}
+
+func (p *cborEncoder) encodeArray(v reflect.Value) error {
+	p.head(cborArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := p.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap encodes map with keys sorted by their encoded bytes, so the output is deterministic.
+func (p *cborEncoder) encodeMap(v reflect.Value) error {
+	type kv struct {
+		k, v []byte
+	}
+	entries := make([]kv, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		ke := &cborEncoder{}
+		if err := ke.encode(iter.Key()); err != nil {
+			return err
+		}
+		ve := &cborEncoder{}
+		if err := ve.encode(iter.Value()); err != nil {
+			return err
+		}
+		entries = append(entries, kv{k: ke.buf.Bytes(), v: ve.buf.Bytes()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].k, entries[j].k) < 0
+	})
+	p.head(cborMap, uint64(len(entries)))
+	for _, it := range entries {
+		p.buf.Write(it.k)
+		p.buf.Write(it.v)
+	}
+	return nil
+}
+
+func (p *cborEncoder) encodeStruct(v reflect.Value) error {
+	fields := cborFields(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.name)
+	}
+	p.head(cborMap, uint64(len(values)))
+	for i, it := range values {
+		p.head(cborText, uint64(len(names[i])))
+		p.buf.WriteString(names[i])
+		if err := p.encode(it); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+// head reads the initial byte and argument of next item.
+// The argument is the length of item, or the value of integers and simple values.
+func (p *cborDecoder) head() (major, info byte, arg uint64, err error) {
+	if p.off >= len(p.data) {
+		err = errCBORTruncated
+		return
+	}
+	b := p.data[p.off]
+	p.off++
+	major, info = b&0xE0, b&0x1F
+	var size int
+	switch {
+	case info < 24:
+		arg = uint64(info)
+		return
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == cborIndefinite:
+		return
+	default:
+		err = fmt.Errorf("codec: invalid CBOR additional information %d", info)
+		return
+	}
+	if len(p.data)-p.off < size {
+		err = errCBORTruncated
+		return
+	}
+	for _, it := range p.data[p.off : p.off+size] {
+		arg = arg<<8 | uint64(it)
+	}
+	p.off += size
+	return
+}
+
+func (p *cborDecoder) peekBreak() bool {
+	if p.off < len(p.data) && p.data[p.off] == cborBreak {
+		p.off++
+		return true
+	}
+	return false
+}
+
+// raw reads bytes of a byte string or text string, chunks of indefinite length strings are concatenated.
+func (p *cborDecoder) raw(major, info byte, n uint64) ([]byte, error) {
+	if info != cborIndefinite {
+		if n > uint64(len(p.data)-p.off) {
+			return nil, errCBORTruncated
+		}
+		b := p.data[p.off : p.off+int(n)]
+		p.off += int(n)
+		return b, nil
+	}
+	var b []byte
+	for !p.peekBreak() {
+		m, i, size, err := p.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || i == cborIndefinite {
+			return nil, errCBORIndefinite
+		}
+		chunk, err := p.raw(m, i, size)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+	return b, nil
+}
+
+// length returns number of items of array or map, it returns -1 for indefinite length.
+func (p *cborDecoder) length(info byte, n uint64) (int, error) {
+	if info == cborIndefinite {
+		return -1, nil
+	}
+	// Each item has one byte at least.
+	if n > uint64(len(p.data)-p.off) {
+		return 0, errCBORTruncated
+	}
+	return int(n), nil
+}
+
+func (p *cborDecoder) more(n, i int) bool {
+	if n < 0 {
+		return !p.peekBreak()
+	}
+	return i < n
+}
+
+func (p *cborDecoder) decode(v reflect.Value, depth int) error {
+	if depth > cborMaxDepth {
+		return errCBORTooDeep
+	}
+	if p.off < len(p.data) && (p.data[p.off] == cborNull || p.data[p.off] == cborUndefined) {
+		p.off++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.decode(v.Elem(), depth+1)
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		generic, err := p.decodeGeneric(depth)
+		if err != nil {
+			return err
+		}
+		if generic == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(generic))
+		}
+		return nil
+	}
+	major, info, arg, err := p.head()
+	if err != nil {
+		return err
+	}
+	mismatch := func() error {
+		return fmt.Errorf("codec: cannot decode CBOR major type %d into %s", major>>5, v.Type())
+	}
+	switch major {
+	case cborTag:
+		return p.decode(v, depth+1)
+	case cborUint, cborNegInt:
+		if info == cborIndefinite {
+			return errCBORIndefinite
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if arg > math.MaxInt64 {
+				return fmt.Errorf("codec: CBOR integer overflows %s", v.Type())
+			}
+			n := int64(arg)
+			if major == cborNegInt {
+				n = -1 - n
+			}
+			if v.OverflowInt(n) {
+				return fmt.Errorf("codec: CBOR integer overflows %s", v.Type())
+			}
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if major == cborNegInt || v.OverflowUint(arg) {
+				return fmt.Errorf("codec: CBOR integer overflows %s", v.Type())
+			}
+			v.SetUint(arg)
+		case reflect.Float32, reflect.Float64:
+			f := float64(arg)
+			if major == cborNegInt {
+				f = -1 - f
+			}
+			v.SetFloat(f)
+		default:
+			return mismatch()
+		}
+	case cborBytes, cborText:
+		b, err := p.raw(major, info, arg)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(string(b))
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(append([]byte{}, b...))
+		case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+			if len(b) != v.Len() {
+				return fmt.Errorf("codec: cannot decode %d CBOR bytes into %s", len(b), v.Type())
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+		default:
+			return mismatch()
+		}
+	case cborArray:
+		n, err := p.length(info, arg)
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			s := reflect.MakeSlice(v.Type(), 0, maxInt(n, 0))
+			for i := 0; p.more(n, i); i++ {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := p.decode(elem, depth+1); err != nil {
+					return err
+				}
+				s = reflect.Append(s, elem)
+			}
+			v.Set(s)
+		case reflect.Array:
+			i := 0
+			for ; p.more(n, i); i++ {
+				if i >= v.Len() {
+					return fmt.Errorf("codec: too many CBOR items for %s", v.Type())
+				}
+				if err := p.decode(v.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+			for ; i < v.Len(); i++ {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+		default:
+			return mismatch()
+		}
+	case cborMap:
+		n, err := p.length(info, arg)
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			for i := 0; p.more(n, i); i++ {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := p.decode(key, depth+1); err != nil {
+					return err
+				}
+				if key.Kind() == reflect.Interface && !key.IsNil() && !key.Elem().Type().Comparable() {
+					return fmt.Errorf("codec: invalid CBOR map key type %s", key.Elem().Type())
+				}
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := p.decode(value, depth+1); err != nil {
+					return err
+				}
+				v.SetMapIndex(key, value)
+			}
+		case reflect.Struct:
+			fields := cborFields(v.Type())
+			for i := 0; p.more(n, i); i++ {
+				var name string
+				if err := p.decode(reflect.ValueOf(&name).Elem(), depth+1); err != nil {
+					return err
+				}
+				field, ok := lookupField(fields, name)
+				if !ok {
+					if err := p.skip(depth + 1); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := p.decode(v.FieldByIndex(field.index), depth+1); err != nil {
+					return err
+				}
+			}
+		default:
+			return mismatch()
+		}
+	case cborSimple:
+		switch info {
+		case 20, 21:
+			if v.Kind() != reflect.Bool {
+				return mismatch()
+			}
+			v.SetBool(info == 21)
+		case 25, 26, 27:
+			if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+				return mismatch()
+			}
+			v.SetFloat(cborFloat(info, arg))
+		default:
+			return mismatch()
+		}
+	}
+	return nil
+}
+
+// lookupField finds field by name, it prefers an exact match but accepts a case-insensitive match like encoding/json.
+func lookupField(fields []cborField, name string) (cborField, bool) {
+	for _, it := range fields {
+		if it.name == name {
+			return it, true
+		}
+	}
+	for _, it := range fields {
+		if strings.EqualFold(it.name, name) {
+			return it, true
+		}
+	}
+	return cborField{}, false
+}
+
+// decodeGeneric decodes next item into basic Go types:
+// uint64, int64, float64, bool, nil, string, []byte, []interface{}, and map[string]interface{} if all keys
+// are strings or map[interface{}]interface{} otherwise.
+func (p *cborDecoder) decodeGeneric(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errCBORTooDeep
+	}
+	major, info, arg, err := p.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return arg, nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("codec: CBOR negative integer overflows int64")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes:
+		b, err := p.raw(major, info, arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case cborText:
+		b, err := p.raw(major, info, arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case cborArray:
+		n, err := p.length(info, arg)
+		if err != nil {
+			return nil, err
+		}
+		s := make([]interface{}, 0, maxInt(n, 0))
+		for i := 0; p.more(n, i); i++ {
+			elem, err := p.decodeGeneric(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, elem)
+		}
+		return s, nil
+	case cborMap:
+		n, err := p.length(info, arg)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[interface{}]interface{}, maxInt(n, 0))
+		allStrings := true
+		for i := 0; p.more(n, i); i++ {
+			k, err := p.decodeGeneric(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, fmt.Errorf("codec: invalid CBOR map key type %T", k)
+			}
+			if _, ok := k.(string); !ok {
+				allStrings = false
+			}
+			if m[k], err = p.decodeGeneric(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		if !allStrings {
+			return m, nil
+		}
+		sm := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			sm[k.(string)] = v
+		}
+		return sm, nil
+	case cborTag:
+		return p.decodeGeneric(depth + 1)
+	default:
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25, 26, 27:
+			return cborFloat(info, arg), nil
+		default:
+			return nil, fmt.Errorf("codec: unsupported CBOR simple value %d", info)
+		}
+	}
+}
+
+func (p *cborDecoder) skip(depth int) error {
+	_, err := p.decodeGeneric(depth)
+	return err
+}
+
+func cborFloat(info byte, bits uint64) float64 {
+	switch info {
+	case 25:
+		return float64(float16ToFloat32(uint16(bits)))
+	case 26:
+		return float64(math.Float32frombits(uint32(bits)))
+	default:
+		return math.Float64frombits(bits)
+	}
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1F
+	frac := uint32(h) & 0x3FF
+	switch exp {
+	case 0:
+		// zero or subnormal
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1F:
+		return math.Float32frombits(sign | 0x7F800000 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+// Package codec provides encoders and decoders of payload data keyed by MIME type.
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+type (
+	// Encoder encodes values to bytes.
+	Encoder interface {
+		// Encode encodes v to bytes.
+		Encode(v interface{}) ([]byte, error)
+	}
+
+	// Decoder decodes bytes to values.
+	Decoder interface {
+		// Decode decodes data into v, v should be a pointer.
+		Decode(data []byte, v interface{}) error
+	}
+
+	// Codec is both Encoder and Decoder.
+	Codec interface {
+		Encoder
+		Decoder
+	}
+
+	// Registry holds codecs keyed by MIME type.
+	Registry interface {
+		// Register registers codec for MIME type, it replaces the existing one.
+		Register(mime string, codec Codec)
+		// RegisterWellKnown registers codec for a well-known MIME type, it replaces the existing one.
+		RegisterWellKnown(mime extension.MIME, codec Codec)
+		// Lookup returns codec of MIME type.
+		Lookup(mime string) (Codec, bool)
+		// LookupWellKnown returns codec of a well-known MIME type.
+		LookupWellKnown(mime extension.MIME) (Codec, bool)
+		// Encode encodes v by codec of MIME type.
+		Encode(mime string, v interface{}) ([]byte, error)
+		// Decode decodes data into v by codec of MIME type.
+		Decode(mime string, data []byte, v interface{}) error
+	}
+)
+
+// UnsupportedMimeTypeError is returned when no codec is registered for a MIME type.
+type UnsupportedMimeTypeError struct {
+	MimeType string
+}
+
+func (e UnsupportedMimeTypeError) Error() string {
+	return fmt.Sprintf("codec: no codec for MIME type %s", e.MimeType)
+}
+
+var defaultRegistry = NewRegistry()
+
+// Built-in codecs.
+var (
+	// JSON encodes values as JSON by encoding/json.
+	JSON Codec = jsonCodec{}
+	// CBOR encodes values as CBOR(RFC 8949), structs are encoded as maps keyed by field names.
+	CBOR Codec = cborCodec{}
+	// Protobuf encodes protobuf messages which can marshal themselves.
+	Protobuf Codec = protobufCodec{}
+	// Text encodes strings, byte slices, encoding.TextMarshaler and fmt.Stringer as UTF8 text.
+	Text Codec = textCodec{}
+)
+
+// NewRegistry creates a new Registry with built-in codecs:
+// application/json, application/cbor, application/vnd.google.protobuf and text/plain.
+func NewRegistry() Registry {
+	r := &registry{
+		codecs: make(map[string]Codec),
+	}
+	r.RegisterWellKnown(extension.ApplicationJSON, JSON)
+	r.RegisterWellKnown(extension.ApplicationCBOR, CBOR)
+	r.RegisterWellKnown(extension.ApplicationProtobuf, Protobuf)
+	r.RegisterWellKnown(extension.TextPlain, Text)
+	return r
+}
+
+// Default returns the default Registry.
+func Default() Registry {
+	return defaultRegistry
+}
+
+// Register registers codec for MIME type into the default Registry.
+func Register(mime string, codec Codec) {
+	defaultRegistry.Register(mime, codec)
+}
+
+// Lookup returns codec of MIME type in the default Registry.
+func Lookup(mime string) (Codec, bool) {
+	return defaultRegistry.Lookup(mime)
+}
+
+// Encode encodes v by codec of MIME type in the default Registry.
+func Encode(mime string, v interface{}) ([]byte, error) {
+	return defaultRegistry.Encode(mime, v)
+}
+
+// Decode decodes data into v by codec of MIME type in the default Registry.
+func Decode(mime string, data []byte, v interface{}) error {
+	return defaultRegistry.Decode(mime, data, v)
+}
+
+type registry struct {
+	locker sync.RWMutex
+	codecs map[string]Codec
+}
+
+func (p *registry) Register(mime string, codec Codec) {
+	p.locker.Lock()
+	p.codecs[mime] = codec
+	p.locker.Unlock()
+}
+
+func (p *registry) RegisterWellKnown(mime extension.MIME, codec Codec) {
+	p.Register(mime.String(), codec)
+}
+
+func (p *registry) Lookup(mime string) (codec Codec, ok bool) {
+	p.locker.RLock()
+	codec, ok = p.codecs[mime]
+	p.locker.RUnlock()
+	return
+}
+
+func (p *registry) LookupWellKnown(mime extension.MIME) (Codec, bool) {
+	return p.Lookup(mime.String())
+}
+
+func (p *registry) Encode(mime string, v interface{}) ([]byte, error) {
+	codec, ok := p.Lookup(mime)
+	if !ok {
+		return nil, UnsupportedMimeTypeError{MimeType: mime}
+	}
+	return codec.Encode(v)
+}
+
+func (p *registry) Decode(mime string, data []byte, v interface{}) error {
+	codec, ok := p.Lookup(mime)
+	if !ok {
+		return UnsupportedMimeTypeError{MimeType: mime}
+	}
+	return codec.Decode(data, v)
+}
@@ -0,0 +1,325 @@
+package codec_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProto struct {
+	Value string
+}
+
+func (p *fakeProto) Reset()         { *p = fakeProto{} }
+func (p *fakeProto) String() string { return p.Value }
+func (p *fakeProto) ProtoMessage()  {}
+
+func (p *fakeProto) Marshal() ([]byte, error) {
+	return []byte(p.Value), nil
+}
+
+func (p *fakeProto) Unmarshal(b []byte) error {
+	p.Value = string(b)
+	return nil
+}
+
+type upperCodec struct{}
+
+func (upperCodec) Encode(v interface{}) ([]byte, error) {
+	return []byte("UPPER:" + v.(string)), nil
+}
+
+func (upperCodec) Decode(data []byte, v interface{}) error {
+	return errors.New("not implemented")
+}
+
+func TestRegistry(t *testing.T) {
+	r := codec.NewRegistry()
+	for _, it := range []extension.MIME{
+		extension.ApplicationJSON,
+		extension.ApplicationCBOR,
+		extension.ApplicationProtobuf,
+		extension.TextPlain,
+	} {
+		_, ok := r.LookupWellKnown(it)
+		assert.True(t, ok, "missing built-in codec %s", it)
+	}
+
+	b, err := r.Encode("application/json", map[string]int{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(b))
+	var m map[string]int
+	require.NoError(t, r.Decode("application/json", b, &m))
+	assert.Equal(t, 1, m["a"])
+
+	_, err = r.Encode("application/x-unknown", 1)
+	assert.Equal(t, codec.UnsupportedMimeTypeError{MimeType: "application/x-unknown"}, err)
+	assert.Error(t, r.Decode("application/x-unknown", nil, &m))
+
+	r.Register("application/x-upper", upperCodec{})
+	b, err = r.Encode("application/x-upper", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "UPPER:a", string(b))
+
+	// registries are isolated
+	_, ok := codec.Lookup("application/x-upper")
+	assert.False(t, ok)
+}
+
+func TestText(t *testing.T) {
+	b, err := codec.Text.Encode("hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	b, err = codec.Text.Encode(&fakeProto{Value: "stringer"})
+	require.NoError(t, err)
+	assert.Equal(t, "stringer", string(b))
+	_, err = codec.Text.Encode(42)
+	assert.Error(t, err)
+
+	var s string
+	require.NoError(t, codec.Text.Decode([]byte("world"), &s))
+	assert.Equal(t, "world", s)
+	var n int
+	assert.Error(t, codec.Text.Decode([]byte("1"), &n))
+}
+
+func TestProtobuf(t *testing.T) {
+	b, err := codec.Protobuf.Encode(&fakeProto{Value: "proto"})
+	require.NoError(t, err)
+	assert.Equal(t, "proto", string(b))
+	var msg fakeProto
+	require.NoError(t, codec.Protobuf.Decode(b, &msg))
+	assert.Equal(t, "proto", msg.Value)
+
+	_, err = codec.Protobuf.Encode("not a message")
+	assert.Error(t, err)
+	assert.Error(t, codec.Protobuf.Decode(b, new(string)))
+}
+
+func TestCBOR_Vectors(t *testing.T) {
+	// Examples from RFC 8949 Appendix A.
+	for _, it := range []struct {
+		v   interface{}
+		hex string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000000, "1a000f4240"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{float32(100000.0), "fa47c35000"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]interface{}{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[string]interface{}{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+	} {
+		b, err := codec.CBOR.Encode(it.v)
+		require.NoError(t, err)
+		assert.Equal(t, it.hex, hex.EncodeToString(b), "encode %v", it.v)
+	}
+}
+
+func TestCBOR_DecodeVectors(t *testing.T) {
+	// Examples from RFC 8949 Appendix A, including those can only be decoded.
+	for _, it := range []struct {
+		hex string
+		v   interface{}
+	}{
+		{"00", uint64(0)},
+		{"0a", uint64(10)},
+		{"1819", uint64(25)},
+		{"1903e8", uint64(1000)},
+		{"1b000000e8d4a51000", uint64(1000000000000)},
+		{"1bffffffffffffffff", uint64(18446744073709551615)},
+		{"29", int64(-10)},
+		{"3863", int64(-100)},
+		{"f90000", 0.0},
+		{"f93e00", 1.5},
+		{"f97bff", 65504.0},
+		{"fa7f7fffff", 3.4028234663852886e+38},
+		{"fb7e37e43c8800759c", 1.0e+300},
+		{"f90001", 5.960464477539063e-8},
+		{"f90400", 0.00006103515625},
+		{"fbc010666666666666", -4.1},
+		{"f97c00", math.Inf(1)},
+		{"f9fc00", math.Inf(-1)},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", nil},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"d74401020304", []byte{1, 2, 3, 4}},
+		{"40", []byte{}},
+		{"60", ""},
+		{"64f0908591", "\U00010151"},
+		{"80", []interface{}{}},
+		{"a0", map[string]interface{}{}},
+		{"a201020304", map[interface{}]interface{}{uint64(1): uint64(2), uint64(3): uint64(4)}},
+		{"826161a161626163", []interface{}{"a", map[string]interface{}{"b": "c"}}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"9fff", []interface{}{}},
+		{"83018202039f0405ff", []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)}}},
+		{"bf61610161629f0203ffff", map[string]interface{}{"a": uint64(1), "b": []interface{}{uint64(2), uint64(3)}}},
+		{"bf6346756ef563416d7421ff", map[string]interface{}{"Fun": true, "Amt": int64(-2)}},
+	} {
+		b, err := hex.DecodeString(it.hex)
+		require.NoError(t, err)
+		var v interface{}
+		require.NoError(t, codec.CBOR.Decode(b, &v), "decode %s", it.hex)
+		assert.Equal(t, it.v, v, "decode %s", it.hex)
+	}
+	var f float64
+	require.NoError(t, codec.CBOR.Decode([]byte{0xf9, 0x7e, 0x00}, &f))
+	assert.True(t, math.IsNaN(f))
+}
+
+type cborQuick struct {
+	Int    int64
+	Uint   uint32
+	Float  float64
+	Bool   bool
+	Text   string
+	Raw    []byte
+	Ints   []int16
+	Map    map[string]uint8
+	Nested *cborQuick
+}
+
+func TestCBOR_RoundTrip(t *testing.T) {
+	// encoding/json is used as reference: same value should have same generic form in both formats.
+	normalize := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+	f := func(in cborQuick) bool {
+		b, err := codec.CBOR.Encode(in)
+		if err != nil {
+			return false
+		}
+		var out cborQuick
+		if err := codec.CBOR.Decode(b, &out); err != nil {
+			return false
+		}
+		var generic interface{}
+		if err := codec.CBOR.Decode(b, &generic); err != nil {
+			return false
+		}
+		var reference interface{}
+		dec := json.NewDecoder(strings.NewReader(normalize(in)))
+		dec.UseNumber()
+		require.NoError(t, dec.Decode(&reference))
+		return normalize(in) == normalize(out) && normalize(reference) == normalize(generic)
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 500}))
+}
+
+func TestCBOR_Decode(t *testing.T) {
+	decode := func(s string, v interface{}) error {
+		b, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return codec.CBOR.Decode(b, v)
+	}
+	var generic interface{}
+	require.NoError(t, decode("a26161016162820203", &generic))
+	assert.Equal(t, map[string]interface{}{"a": uint64(1), "b": []interface{}{uint64(2), uint64(3)}}, generic)
+
+	// indefinite length items
+	require.NoError(t, decode("9f018202039f0405ffff", &generic))
+	assert.Equal(t, []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)}}, generic)
+	var s string
+	require.NoError(t, decode("7f657374726561646d696e67ff", &s))
+	assert.Equal(t, "streaming", s)
+
+	// half precision float and tag
+	var f float64
+	require.NoError(t, decode("f93c00", &f))
+	assert.Equal(t, 1.0, f)
+	require.NoError(t, decode("f9c400", &f))
+	assert.Equal(t, -4.0, f)
+	require.NoError(t, decode("c11a514b67b0", &f))
+	assert.Equal(t, 1363896240.0, f)
+
+	var n int8
+	assert.Error(t, decode("1903e8", &n), "overflow")
+	var u uint
+	assert.Error(t, decode("20", &u), "negative")
+	assert.Error(t, decode("1a000f42", &u), "truncated")
+	assert.Error(t, decode("0000", &u), "extra bytes")
+	assert.Error(t, decode("6449455446", &u), "type mismatch")
+	assert.Error(t, decode("9b00000000ffffffff", &generic), "huge length")
+	assert.Error(t, codec.CBOR.Decode([]byte{0}, u), "not a pointer")
+}
+
+type cborInner struct {
+	Tags []string `json:"tags"`
+}
+
+type cborOuter struct {
+	cborInner
+	ID       int               `cbor:"id"`
+	Name     string            `json:"name,omitempty"`
+	Skipped  string            `json:"-"`
+	Price    float64           `json:"price"`
+	Raw      []byte            `json:"raw"`
+	Attrs    map[string]string `json:"attrs"`
+	Next     *cborOuter        `json:"next"`
+	Any      interface{}       `json:"any"`
+	Fixed    [2]int            `json:"fixed"`
+	internal int
+}
+
+func TestCBOR_Struct(t *testing.T) {
+	in := cborOuter{
+		cborInner: cborInner{Tags: []string{"a", "b"}},
+		ID:        -7,
+		Name:      "outer",
+		Skipped:   "skipped",
+		Price:     9.5,
+		Raw:       []byte{0xFF},
+		Attrs:     map[string]string{"k": "v"},
+		Next:      &cborOuter{ID: 2},
+		Any:       "any",
+		Fixed:     [2]int{1, 2},
+		internal:  1,
+	}
+	b, err := codec.CBOR.Encode(in)
+	require.NoError(t, err)
+
+	var out cborOuter
+	require.NoError(t, codec.CBOR.Decode(b, &out))
+	in.Skipped, in.internal = "", 0
+	assert.Equal(t, in, out)
+
+	var generic map[string]interface{}
+	require.NoError(t, codec.CBOR.Decode(b, &generic))
+	assert.Equal(t, int64(-7), generic["id"])
+	assert.Equal(t, []interface{}{"a", "b"}, generic["tags"])
+	assert.NotContains(t, generic, "Skipped")
+	assert.Contains(t, generic["next"], "price")
+	assert.NotContains(t, generic["next"], "name", "empty field should be omitted")
+
+	// unknown fields are skipped
+	b, err = codec.CBOR.Encode(map[string]interface{}{"id": 1, "unknown": []int{1, 2}})
+	require.NoError(t, err)
+	out = cborOuter{}
+	require.NoError(t, codec.CBOR.Decode(b, &out))
+	assert.Equal(t, 1, out.ID)
+}
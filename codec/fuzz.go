@@ -0,0 +1,33 @@
+// +build gofuzz
+
+//go:generate GO111MODULE=off go-fuzz-build github.com/rsocket/rsocket-go/codec
+package codec
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Fuzz checks that any CBOR data accepted by decoder can be encoded, and the encoded data is stable after a round trip.
+func Fuzz(data []byte) int {
+	var v interface{}
+	if err := CBOR.Decode(data, &v); err != nil {
+		return 0
+	}
+	b, err := CBOR.Encode(v)
+	if err != nil {
+		panic(fmt.Sprintf("encode decoded value %#v failed: %s", v, err))
+	}
+	var again interface{}
+	if err := CBOR.Decode(b, &again); err != nil {
+		panic(fmt.Sprintf("decode encoded value %#v failed: %s", v, err))
+	}
+	b2, err := CBOR.Encode(again)
+	if err != nil {
+		panic(fmt.Sprintf("encode decoded value %#v failed: %s", again, err))
+	}
+	if !bytes.Equal(b, b2) {
+		panic(fmt.Sprintf("round trip mismatch: %x != %x", b, b2))
+	}
+	return 1
+}
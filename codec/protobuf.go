@@ -0,0 +1,62 @@
+package codec
+
+import "fmt"
+
+// ProtoMessage is the interface implemented by all generated protobuf messages.
+type ProtoMessage interface {
+	Reset()
+	String() string
+	ProtoMessage()
+}
+
+// Messages generated by gogo/protobuf or other generators marshal themselves.
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+type protoUnmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// Messages generated by golang/protobuf before APIv2.
+type protoXXXMarshaler interface {
+	XXX_Marshal(b []byte, deterministic bool) ([]byte, error)
+}
+
+type protoXXXUnmarshaler interface {
+	XXX_Unmarshal(b []byte) error
+}
+
+// protobufCodec encodes messages without depending on a protobuf runtime.
+// Messages which cannot marshal themselves need a custom codec registered, eg: one backed by proto.Marshal.
+type protobufCodec struct{}
+
+func (protobufCodec) Encode(v interface{}) ([]byte, error) {
+	if _, ok := v.(ProtoMessage); !ok {
+		return nil, fmt.Errorf("codec: %T is not a protobuf message", v)
+	}
+	switch m := v.(type) {
+	case protoMarshaler:
+		return m.Marshal()
+	case protoXXXMarshaler:
+		return m.XXX_Marshal(nil, false)
+	default:
+		return nil, fmt.Errorf("codec: protobuf message %T cannot marshal itself, please register a custom codec", v)
+	}
+}
+
+func (protobufCodec) Decode(data []byte, v interface{}) error {
+	msg, ok := v.(ProtoMessage)
+	if !ok {
+		return fmt.Errorf("codec: %T is not a protobuf message", v)
+	}
+	msg.Reset()
+	switch m := v.(type) {
+	case protoUnmarshaler:
+		return m.Unmarshal(data)
+	case protoXXXUnmarshaler:
+		return m.XXX_Unmarshal(data)
+	default:
+		return fmt.Errorf("codec: protobuf message %T cannot unmarshal itself, please register a custom codec", v)
+	}
+}
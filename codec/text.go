@@ -0,0 +1,39 @@
+package codec
+
+import (
+	"encoding"
+	"fmt"
+)
+
+type textCodec struct{}
+
+func (textCodec) Encode(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(t), nil
+	case []byte:
+		return t, nil
+	case encoding.TextMarshaler:
+		return t.MarshalText()
+	case fmt.Stringer:
+		return []byte(t.String()), nil
+	default:
+		return nil, fmt.Errorf("codec: cannot encode %T as text", v)
+	}
+}
+
+func (textCodec) Decode(data []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *string:
+		*t = string(data)
+	case *[]byte:
+		*t = append((*t)[:0], data...)
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText(data)
+	default:
+		return fmt.Errorf("codec: cannot decode text into %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package rsocket_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeListener accepts connections created by net.Pipe.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (p *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-p.conns:
+		return c, nil
+	case <-p.done:
+		return nil, errors.New("use of closed network connection")
+	}
+}
+
+func (p *pipeListener) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+func (p *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+func (p *pipeListener) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	c1, c2 := net.Pipe()
+	select {
+	case p.conns <- c2:
+		return c1, nil
+	case <-p.done:
+		return nil, errors.New("listener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string {
+	return "pipe"
+}
+
+func (pipeAddr) String() string {
+	return "pipe"
+}
+
+func TestCustomConn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+					return mono.Just(NewString("hello "+msg.DataUTF8(), ""))
+				})), nil
+			}).
+			Transport("tcp://pipe").
+			ServeListener(ctx, l)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	requestResponse := func(t *testing.T, cli Client) {
+		defer func() {
+			_ = cli.Close()
+		}()
+		res, err := cli.RequestResponse(NewString("world", "")).Block(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", res.DataUTF8())
+	}
+
+	t.Run("StartWithConn", func(t *testing.T) {
+		conn, err := l.dial(ctx, "tcp", "pipe")
+		require.NoError(t, err)
+		cli, err := Connect().
+			Transport("tcp://pipe").
+			StartWithConn(ctx, conn)
+		require.NoError(t, err)
+		requestResponse(t, cli)
+	})
+
+	t.Run("Dialer", func(t *testing.T) {
+		var dialed []string
+		cli, err := Connect().
+			Transport("tcp://pipe:7878", WithDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialed = append(dialed, network+"://"+addr)
+				return l.dial(ctx, network, addr)
+			})).
+			Start(ctx)
+		require.NoError(t, err)
+		requestResponse(t, cli)
+		assert.Equal(t, []string{"tcp://pipe:7878"}, dialed)
+	})
+
+	t.Run("TLSHandshakeCancelled", func(t *testing.T) {
+		// peer never answers handshake
+		var peers []net.Conn
+		defer func() {
+			for _, it := range peers {
+				_ = it.Close()
+			}
+		}()
+		ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := Connect().
+			Transport("tcp://pipe:7878", WithDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				c1, c2 := net.Pipe()
+				peers = append(peers, c2)
+				return c1, nil
+			})).
+			StartTLS(ctx, &tls.Config{InsecureSkipVerify: true})
+		assert.Error(t, err)
+		assert.True(t, time.Since(start) < 3*time.Second, "handshake should stop when context is done")
+	})
+
+	t.Run("UnsupportedDialer", func(t *testing.T) {
+		_, err := Connect().
+			Transport("ws://127.0.0.1:7878", WithDialer(l.dial)).
+			Start(ctx)
+		assert.Error(t, err)
+	})
+}
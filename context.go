@@ -0,0 +1,76 @@
+package rsocket
+
+import (
+	"context"
+	"net"
+
+	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// MetadataPushContext register request handler for MetadataPush, the handler receives context of the request.
+func MetadataPushContext(fn func(ctx context.Context, msg payload.Payload)) OptAbstractSocket {
+	return MetadataPush(func(msg payload.Payload) {
+		fn(socket.ContextOf(msg), msg)
+	})
+}
+
+// FireAndForgetContext register request handler for FireAndForget, the handler receives context of the request.
+func FireAndForgetContext(fn func(ctx context.Context, msg payload.Payload)) OptAbstractSocket {
+	return FireAndForget(func(msg payload.Payload) {
+		fn(socket.ContextOf(msg), msg)
+	})
+}
+
+// RequestResponseContext register request handler for RequestResponse, the handler receives context of the request.
+// The context will be cancelled when requester sends CANCEL or the connection is closed.
+func RequestResponseContext(fn func(ctx context.Context, msg payload.Payload) mono.Mono) OptAbstractSocket {
+	return RequestResponse(func(msg payload.Payload) mono.Mono {
+		return fn(socket.ContextOf(msg), msg)
+	})
+}
+
+// RequestStreamContext register request handler for RequestStream, the handler receives context of the request.
+// The context will be cancelled when requester sends CANCEL or the connection is closed.
+func RequestStreamContext(fn func(ctx context.Context, msg payload.Payload) flux.Flux) OptAbstractSocket {
+	return RequestStream(func(msg payload.Payload) flux.Flux {
+		return fn(socket.ContextOf(msg), msg)
+	})
+}
+
+// RequestChannelContext register request handler for RequestChannel, the handler receives context of the request.
+// The context will be cancelled when the channel is finished or the connection is closed.
+func RequestChannelContext(fn func(ctx context.Context, msgs rx.Publisher) flux.Flux) OptAbstractSocket {
+	return RequestChannel(func(msgs rx.Publisher) flux.Flux {
+		return fn(socket.ContextOf(msgs), msgs)
+	})
+}
+
+// ConnectionFromContext returns the connection which current request is received from.
+// It can be used to send requests back to the peer.
+func ConnectionFromContext(ctx context.Context) (conn CloseableRSocket, ok bool) {
+	c, ok := socket.ConnectionFromContext(ctx)
+	if !ok {
+		return
+	}
+	conn, ok = c.(CloseableRSocket)
+	return
+}
+
+// SetupFromContext returns the SETUP payload of connection which current request is received from.
+func SetupFromContext(ctx context.Context) (setup payload.SetupPayload, ok bool) {
+	return socket.SetupFromContext(ctx)
+}
+
+// RemoteAddrFromContext returns the remote address of connection which current request is received from.
+func RemoteAddrFromContext(ctx context.Context) (addr net.Addr, ok bool) {
+	return socket.RemoteAddrFromContext(ctx)
+}
+
+// PrincipalFromContext returns the principal of current request which is authenticated by server Authenticator.
+func PrincipalFromContext(ctx context.Context) (principal interface{}, ok bool) {
+	return socket.PrincipalFromContext(ctx)
+}
@@ -0,0 +1,100 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerContext(t *testing.T) {
+	const addr = "127.0.0.1:7999"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cancelled := make(chan error, 1)
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponseContext(func(ctx context.Context, msg Payload) mono.Mono {
+						setup, ok := SetupFromContext(ctx)
+						if !ok {
+							return mono.Error(assert.AnError)
+						}
+						addr, ok := RemoteAddrFromContext(ctx)
+						if !ok {
+							return mono.Error(assert.AnError)
+						}
+						conn, ok := ConnectionFromContext(ctx)
+						if !ok {
+							return mono.Error(assert.AnError)
+						}
+						return mono.Create(func(_ context.Context, s mono.Sink) {
+							// call back to the requester by the connection
+							res, err := conn.RequestResponse(NewString("ping", "")).Block(ctx)
+							if err != nil {
+								s.Error(err)
+								return
+							}
+							s.Success(NewString(setup.DataUTF8()+"|"+res.DataUTF8()+"|"+addr.Network(), ""))
+						})
+					}),
+					RequestStreamContext(func(ctx context.Context, msg Payload) flux.Flux {
+						return flux.Create(func(_ context.Context, s flux.Sink) {
+							s.Next(NewString("first", ""))
+							<-ctx.Done()
+							cancelled <- ctx.Err()
+							s.Complete()
+						})
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		SetupPayload(NewString("hello", "")).
+		Acceptor(func(socket RSocket) RSocket {
+			return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+				return mono.Just(NewString("pong", ""))
+			}))
+		}).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	res, err := cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello|pong|tcp", res.DataUTF8())
+
+	// context of stream is cancelled after receiving CANCEL
+	first, err := cli.RequestStream(NewString("hello", "")).Take(1).BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "first", first.DataUTF8())
+	select {
+	case err := <-cancelled:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "context should be cancelled")
+	}
+}
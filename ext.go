@@ -0,0 +1,31 @@
+package rsocket
+
+import (
+	"context"
+
+	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+type (
+	// ExtensionHandler handles an EXT frame of a custom extended type, msg carries data and metadata of the frame.
+	// It is invoked in the reading loop of connection, so it should not block.
+	// ctx carries information of the connection, see ConnectionFromContext.
+	ExtensionHandler = func(ctx context.Context, msg payload.Payload)
+
+	// ExtensionSender can send EXT frames to peer.
+	// Clients started by ClientBuilder implement it, so does the sending socket of server.
+	ExtensionSender interface {
+		// SendExtension sends an EXT frame of custom extended type.
+		// If canIgnore is true, peer skips the frame when it does not understand the extended type,
+		// otherwise peer closes the connection.
+		SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool)
+	}
+)
+
+func registerExtension(extensions **socket.Extensions, extendedType uint32, handler ExtensionHandler) {
+	if *extensions == nil {
+		*extensions = &socket.Extensions{}
+	}
+	(*extensions).Register(extendedType, handler)
+}
@@ -0,0 +1,112 @@
+package rsocket_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtension(t *testing.T) {
+	const addr = "127.0.0.1:8003"
+	const (
+		extPing uint32 = iota + 1
+		extPong
+		extUnknown
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Extension(extPing, func(ctx context.Context, msg Payload) {
+				conn, ok := ConnectionFromContext(ctx)
+				if !ok {
+					return
+				}
+				conn.(ExtensionSender).SendExtension(extPong, NewString("pong:"+msg.DataUTF8(), "meta"), false)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+					return mono.Just(msg)
+				})), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		pong := make(chan Payload, 1)
+		cli, err := Connect().
+			Extension(extPong, func(ctx context.Context, msg Payload) {
+				pong <- Clone(msg)
+			}).
+			Transport("tcp://" + addr).
+			Start(ctx)
+		require.NoError(t, err)
+		defer func() {
+			_ = cli.Close()
+		}()
+
+		// unknown extended type can be ignored.
+		cli.(ExtensionSender).SendExtension(extUnknown, NewString("unknown", ""), true)
+		cli.(ExtensionSender).SendExtension(extPing, NewString("hello", ""), false)
+		select {
+		case msg := <-pong:
+			assert.Equal(t, "pong:hello", msg.DataUTF8())
+			metadata, _ := msg.MetadataUTF8()
+			assert.Equal(t, "meta", metadata)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "wait EXT timeout")
+		}
+		res, err := cli.RequestResponse(NewString("ok", "")).Block(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res.DataUTF8())
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		c, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer func() {
+			_ = c.Close()
+		}()
+		conn := &rawConn{Conn: c, decoder: transport.NewLengthBasedFrameDecoder(c)}
+		conn.write(t, framing.NewFrameSetup(common.DefaultVersion, 10*time.Second, 60*time.Second, nil, []byte("text/plain"), []byte("text/plain"), nil, nil, false))
+		// reserved frame type with IGNORE flag should be skipped.
+		conn.write(t, &framing.FrameUnknown{
+			BaseFrame: framing.NewBaseFrame(framing.NewFrameHeader(0, framing.FrameType(0x20), framing.FlagIgnore), common.NewByteBuff()),
+		})
+		conn.write(t, framing.NewFrameExt(0, extUnknown, []byte("unknown"), nil, framing.FlagIgnore))
+		conn.write(t, framing.NewFrameRequestResponse(1, []byte("ok"), nil))
+		f := conn.read(t)
+		require.IsType(t, &framing.FramePayload{}, f)
+		assert.Equal(t, "ok", f.(*framing.FramePayload).DataUTF8())
+
+		// EXT frame which cannot be ignored closes the connection.
+		conn.write(t, framing.NewFrameExt(0, extUnknown, []byte("unknown"), nil))
+		require.NoError(t, c.SetReadDeadline(time.Now().Add(3*time.Second)))
+		_, err = conn.decoder.Read()
+		assert.Error(t, err, "connection should be closed")
+		if ne, ok := err.(net.Error); ok {
+			assert.False(t, ne.Timeout(), "connection should be closed")
+		}
+	})
+}
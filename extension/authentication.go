@@ -1,7 +1,9 @@
 package extension
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 const (
@@ -15,7 +17,11 @@ const (
 	_authenticationBearer wellKnownAuthenticationType = 0x01
 )
 
-var errInvalidAuthBytes = errors.New("invalid authentication bytes")
+var (
+	errInvalidAuthBytes          = errors.New("invalid authentication bytes")
+	errInvalidSimpleAuthBytes    = errors.New("invalid simple authentication bytes")
+	errSimpleAuthUsernameTooLong = errors.New("illegal username length: exceed 65535 bytes")
+)
 
 type wellKnownAuthenticationType uint8
 
@@ -65,6 +71,55 @@ func NewAuthentication(authType string, payload []byte) Authentication {
 	}
 }
 
+// NewSimpleAuth creates a new Authentication with well-known type "simple".
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Security/Simple.md
+// It returns an error if username exceeds 65535 bytes.
+func NewSimpleAuth(username, password string) (auth Authentication, err error) {
+	if len(username) > 0xFFFF {
+		err = errSimpleAuthUsernameTooLong
+		return
+	}
+	payload := make([]byte, 2, 2+len(username)+len(password))
+	binary.BigEndian.PutUint16(payload, uint16(len(username)))
+	payload = append(payload, username...)
+	payload = append(payload, password...)
+	auth = Authentication{
+		typ:     _simpleAuth,
+		payload: payload,
+	}
+	return
+}
+
+// ParseSimpleAuth parses username and password from a "simple" Authentication.
+func ParseSimpleAuth(auth Authentication) (username, password string, err error) {
+	if auth.typ != _simpleAuth {
+		err = fmt.Errorf("not a simple authentication: type=%s", auth.typ)
+		return
+	}
+	raw := auth.payload
+	if len(raw) < 2 {
+		err = errInvalidSimpleAuthBytes
+		return
+	}
+	n := int(binary.BigEndian.Uint16(raw))
+	if len(raw) < 2+n {
+		err = errInvalidSimpleAuthBytes
+		return
+	}
+	username = string(raw[2 : 2+n])
+	password = string(raw[2+n:])
+	return
+}
+
+// NewBearerAuth creates a new Authentication with well-known type "bearer".
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Security/Bearer.md
+func NewBearerAuth(token string) Authentication {
+	return Authentication{
+		typ:     _bearerAuth,
+		payload: []byte(token),
+	}
+}
+
 // Bytes encodes current Authentication to byte slice.
 func (a Authentication) Bytes() (raw []byte) {
 	if w, ok := parseWellKnownAuthenticateType(a.typ); ok {
@@ -1,6 +1,7 @@
 package extension_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/rsocket/rsocket-go/extension"
@@ -15,6 +16,35 @@ func TestAuthentication(t *testing.T) {
 	assert.Equal(t, au, au2, "not match")
 }
 
+func TestSimpleAuth(t *testing.T) {
+	au, err := extension.NewSimpleAuth("user", "pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "simple", au.Type())
+	assert.True(t, au.IsWellKnown())
+	au2, err := extension.ParseAuthentication(au.Bytes())
+	assert.NoError(t, err)
+	username, password, err := extension.ParseSimpleAuth(au2)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+
+	_, _, err = extension.ParseSimpleAuth(extension.NewBearerAuth("token"))
+	assert.Error(t, err, "should fail with bearer")
+	_, _, err = extension.ParseSimpleAuth(extension.NewAuthentication("simple", []byte{0x00, 0x05, 'u'}))
+	assert.Error(t, err, "should fail with short username")
+
+	_, err = extension.NewSimpleAuth(strings.Repeat("u", 0x10000), "pass")
+	assert.Error(t, err, "should fail with too long username")
+}
+
+func TestBearerAuth(t *testing.T) {
+	au := extension.NewBearerAuth("token")
+	au2, err := extension.ParseAuthentication(au.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "bearer", au2.Type())
+	assert.Equal(t, "token", string(au2.Payload()))
+}
+
 func BenchmarkAuthentication_Bytes(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		au := extension.NewAuthentication("simple", []byte("foobar"))
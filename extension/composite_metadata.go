@@ -2,12 +2,15 @@ package extension
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
 
 	"github.com/rsocket/rsocket-go/internal/common"
 )
 
+var errInvalidCompositeMetadataBytes = errors.New("invalid composite metadata bytes")
+
 // CompositeMetadata provides multi Metadata payloads with different MIME types.
 type CompositeMetadata []byte
 
@@ -50,6 +53,8 @@ func (c *CompositeMetadataScanner) MetadataUTF8() (mimeType string, metadata str
 func (c *CompositeMetadataScanner) Metadata() (mimeType string, metadata []byte, err error) {
 	l, mimeType, metadata, err := c.decodeCompositeMetadataOnce(c.raw[c.offset:])
 	if err != nil {
+		// Rest of metadata cannot be located any more.
+		c.offset = len(c.raw)
 		return
 	}
 	c.offset += l
@@ -65,10 +70,22 @@ func (c *CompositeMetadataScanner) decodeCompositeMetadataOnce(raw []byte) (leng
 	} else {
 		mimeTypeLen := int(idOrLen) + 1
 		size += mimeTypeLen
+		if len(raw) < size {
+			err = errInvalidCompositeMetadataBytes
+			return
+		}
 		mimeType = string(raw[1 : 1+mimeTypeLen])
 	}
+	if len(raw) < size+3 {
+		err = errInvalidCompositeMetadataBytes
+		return
+	}
 	metadataLen := common.NewUint24Bytes(raw[size : size+3]).AsInt()
 	length = size + 3 + metadataLen
+	if len(raw) < length {
+		err = errInvalidCompositeMetadataBytes
+		return
+	}
 	metadata = raw[size+3 : length]
 	return
 }
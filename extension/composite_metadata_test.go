@@ -21,3 +21,26 @@ func TestCompositeMetadataBuilder_Build(t *testing.T) {
 		fmt.Println("mimeType:", mimeType, "metadata:", string(metadata))
 	}
 }
+
+func TestCompositeMetadataScanner_Malformed(t *testing.T) {
+	cm, err := NewCompositeMetadataBuilder().
+		PushWellKnownString(TextPlain, "text").
+		PushString("application/custom", "custom").
+		Build()
+	assert.NoError(t, err, "build composite metadata failed")
+	// entries are truncated, extra capacity must not be read
+	for _, l := range []int{9, 10, 15, 20, len(cm) - 1} {
+		raw := make([]byte, len(cm), len(cm)*2)
+		copy(raw, cm)
+		scanner := CompositeMetadata(raw[:l]).Scanner()
+		assert.True(t, scanner.Scan())
+		mimeType, metadata, err := scanner.Metadata()
+		assert.NoError(t, err)
+		assert.Equal(t, TextPlain.String(), mimeType)
+		assert.Equal(t, "text", string(metadata))
+		assert.True(t, scanner.Scan())
+		_, _, err = scanner.Metadata()
+		assert.Error(t, err, "truncated at %d", l)
+		assert.False(t, scanner.Scan(), "scanning should stop after error")
+	}
+}
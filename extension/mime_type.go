@@ -0,0 +1,90 @@
+package extension
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errInvalidMimeTypeBytes = errors.New("invalid MIME type bytes")
+
+// EncodeMimeType encodes MIME type as content of message/x.rsocket.mime-type.v0 metadata.
+// Well-known MIME type is encoded as its ID, otherwise it is encoded as string.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/PerStreamDataMimeTypesDefinition.md
+func EncodeMimeType(mimeType string) (raw []byte, err error) {
+	return appendMimeType(nil, mimeType)
+}
+
+// ParseMimeType parses MIME type from content of message/x.rsocket.mime-type.v0 metadata.
+func ParseMimeType(raw []byte) (mimeType string, err error) {
+	mimeType, n, err := readMimeType(raw)
+	if err != nil {
+		return
+	}
+	if n != len(raw) {
+		err = errInvalidMimeTypeBytes
+	}
+	return
+}
+
+// EncodeAcceptMimeTypes encodes MIME types as content of message/x.rsocket.accept-mime-types.v0 metadata.
+func EncodeAcceptMimeTypes(mimeType string, otherMimeTypes ...string) (raw []byte, err error) {
+	if raw, err = appendMimeType(raw, mimeType); err != nil {
+		return
+	}
+	for _, it := range otherMimeTypes {
+		if raw, err = appendMimeType(raw, it); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ParseAcceptMimeTypes parses MIME types from content of message/x.rsocket.accept-mime-types.v0 metadata.
+func ParseAcceptMimeTypes(raw []byte) (mimeTypes []string, err error) {
+	for cursor := 0; cursor < len(raw); {
+		mimeType, n, e := readMimeType(raw[cursor:])
+		if e != nil {
+			return nil, e
+		}
+		mimeTypes = append(mimeTypes, mimeType)
+		cursor += n
+	}
+	return
+}
+
+func appendMimeType(raw []byte, mimeType string) ([]byte, error) {
+	if well, ok := ParseMIME(mimeType); ok {
+		return append(raw, 0x80|byte(well)), nil
+	}
+	size := len(mimeType)
+	if size < 1 || size > 0x80 {
+		return nil, fmt.Errorf("illegal MIME type length %d", size)
+	}
+	raw = append(raw, byte(size-1))
+	return append(raw, mimeType...), nil
+}
+
+// readMimeType reads a MIME type, it returns the MIME type and number of bytes read.
+func readMimeType(raw []byte) (mimeType string, n int, err error) {
+	if len(raw) < 1 {
+		err = errInvalidMimeTypeBytes
+		return
+	}
+	first := raw[0]
+	if first&0x80 != 0 {
+		mimeType = MIME(first & 0x7F).String()
+		if mimeType == "" {
+			err = fmt.Errorf("unknown well-known MIME type ID 0x%02X", first&0x7F)
+			return
+		}
+		n = 1
+		return
+	}
+	n = int(first) + 2
+	if len(raw) < n {
+		err = errInvalidMimeTypeBytes
+		return
+	}
+	mimeType = string(raw[1:n])
+	return
+}
@@ -0,0 +1,51 @@
+package extension
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMimeType(t *testing.T) {
+	raw, err := EncodeMimeType("application/json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80 | byte(ApplicationJSON)}, raw)
+	mimeType, err := ParseMimeType(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", mimeType)
+
+	raw, err = EncodeMimeType("application/x-custom")
+	require.NoError(t, err)
+	assert.Equal(t, byte(len("application/x-custom")-1), raw[0])
+	mimeType, err = ParseMimeType(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-custom", mimeType)
+
+	_, err = EncodeMimeType("")
+	assert.Error(t, err)
+	_, err = EncodeMimeType(strings.Repeat("x", 129))
+	assert.Error(t, err)
+	_, err = ParseMimeType(nil)
+	assert.Error(t, err)
+	_, err = ParseMimeType([]byte{0x80 | 0x70})
+	assert.Error(t, err, "reserved ID")
+	_, err = ParseMimeType(raw[:5])
+	assert.Error(t, err, "truncated")
+	_, err = ParseMimeType(append(raw, 'x'))
+	assert.Error(t, err, "extra bytes")
+}
+
+func TestAcceptMimeTypes(t *testing.T) {
+	raw, err := EncodeAcceptMimeTypes("application/cbor", "application/x-custom", "text/plain")
+	require.NoError(t, err)
+	mimeTypes, err := ParseAcceptMimeTypes(raw)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/cbor", "application/x-custom", "text/plain"}, mimeTypes)
+
+	_, err = ParseAcceptMimeTypes(raw[:len(raw)-2])
+	assert.Error(t, err)
+	_, err = EncodeAcceptMimeTypes("application/json", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package extension
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags of Zipkin tracing metadata.
+const (
+	zipkinFlagIDsSet     = 0x80
+	zipkinFlagDebug      = 0x40
+	zipkinFlagSampled    = 0x20
+	zipkinFlagNotSampled = 0x10
+	zipkinFlagTraceID128 = 0x08
+	zipkinFlagParentID   = 0x04
+)
+
+var errInvalidZipkinBytes = errors.New("invalid zipkin tracing bytes")
+
+// ZipkinSampling is the sampling decision of a trace.
+type ZipkinSampling int8
+
+// All sampling decisions
+const (
+	// ZipkinSamplingUnspecified means the sampling decision is deferred to the receiver.
+	ZipkinSamplingUnspecified ZipkinSampling = iota
+	// ZipkinSamplingSampled means the trace is sampled.
+	ZipkinSamplingSampled
+	// ZipkinSamplingNotSampled means the trace is not sampled.
+	ZipkinSamplingNotSampled
+	// ZipkinSamplingDebug means the trace is sampled and forced to be reported.
+	ZipkinSamplingDebug
+)
+
+func (z ZipkinSampling) String() string {
+	switch z {
+	case ZipkinSamplingUnspecified:
+		return "UNSPECIFIED"
+	case ZipkinSamplingSampled:
+		return "SAMPLED"
+	case ZipkinSamplingNotSampled:
+		return "NOT_SAMPLED"
+	case ZipkinSamplingDebug:
+		return "DEBUG"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ZipkinTracing is the tracing metadata which propagates Zipkin trace context across RSocket hops.
+// A zero TraceIDHigh means a 64-bit trace ID, a zero ParentID means the span has no parent.
+// If both TraceID and SpanID are zero, only the sampling decision will be propagated.
+// https://github.com/rsocket/rsocket/blob/master/Extensions/Tracing-Zipkin.md
+type ZipkinTracing struct {
+	TraceIDHigh uint64
+	TraceID     uint64
+	SpanID      uint64
+	ParentID    uint64
+	Sampling    ZipkinSampling
+}
+
+// HasIDs returns true if trace ID and span ID are present.
+func (z ZipkinTracing) HasIDs() bool {
+	return z.TraceID != 0 || z.TraceIDHigh != 0 || z.SpanID != 0
+}
+
+// Is128 returns true if trace ID is 128-bit.
+func (z ZipkinTracing) Is128() bool {
+	return z.TraceIDHigh != 0
+}
+
+// TraceIDString returns trace ID in hex, it has 32 characters if trace ID is 128-bit, otherwise it has 16 characters.
+func (z ZipkinTracing) TraceIDString() string {
+	if z.Is128() {
+		return fmt.Sprintf("%016x%016x", z.TraceIDHigh, z.TraceID)
+	}
+	return fmt.Sprintf("%016x", z.TraceID)
+}
+
+func (z ZipkinTracing) String() string {
+	return fmt.Sprintf(
+		"ZipkinTracing{traceId=%s,spanId=%016x,parentId=%016x,sampling=%s}",
+		z.TraceIDString(), z.SpanID, z.ParentID, z.Sampling,
+	)
+}
+
+// Bytes encodes current ZipkinTracing to byte slice.
+func (z ZipkinTracing) Bytes() (raw []byte) {
+	var flags byte
+	switch z.Sampling {
+	case ZipkinSamplingDebug:
+		flags |= zipkinFlagDebug
+	case ZipkinSamplingSampled:
+		flags |= zipkinFlagSampled
+	case ZipkinSamplingNotSampled:
+		flags |= zipkinFlagNotSampled
+	}
+	if !z.HasIDs() {
+		return []byte{flags}
+	}
+	flags |= zipkinFlagIDsSet
+	size := 17
+	if z.Is128() {
+		flags |= zipkinFlagTraceID128
+		size += 8
+	}
+	if z.ParentID != 0 {
+		flags |= zipkinFlagParentID
+		size += 8
+	}
+	raw = make([]byte, 1, size)
+	raw[0] = flags
+	if z.Is128() {
+		raw = appendUint64(raw, z.TraceIDHigh)
+	}
+	raw = appendUint64(raw, z.TraceID)
+	raw = appendUint64(raw, z.SpanID)
+	if z.ParentID != 0 {
+		raw = appendUint64(raw, z.ParentID)
+	}
+	return
+}
+
+// ParseZipkinTracing parse ZipkinTracing from raw bytes.
+func ParseZipkinTracing(raw []byte) (z ZipkinTracing, err error) {
+	if len(raw) < 1 {
+		err = errInvalidZipkinBytes
+		return
+	}
+	flags := raw[0]
+	switch {
+	case flags&zipkinFlagDebug != 0:
+		z.Sampling = ZipkinSamplingDebug
+	case flags&zipkinFlagSampled != 0:
+		z.Sampling = ZipkinSamplingSampled
+	case flags&zipkinFlagNotSampled != 0:
+		z.Sampling = ZipkinSamplingNotSampled
+	}
+	if flags&zipkinFlagIDsSet == 0 {
+		return
+	}
+	size := 17
+	if flags&zipkinFlagTraceID128 != 0 {
+		size += 8
+	}
+	if flags&zipkinFlagParentID != 0 {
+		size += 8
+	}
+	if len(raw) < size {
+		err = errInvalidZipkinBytes
+		return
+	}
+	cursor := 1
+	next := func() (n uint64) {
+		n = binary.BigEndian.Uint64(raw[cursor:])
+		cursor += 8
+		return
+	}
+	if flags&zipkinFlagTraceID128 != 0 {
+		z.TraceIDHigh = next()
+	}
+	z.TraceID = next()
+	z.SpanID = next()
+	if flags&zipkinFlagParentID != 0 {
+		z.ParentID = next()
+	}
+	return
+}
+
+func appendUint64(raw []byte, n uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	return append(raw, b[:]...)
+}
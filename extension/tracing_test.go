@@ -0,0 +1,42 @@
+package extension_test
+
+import (
+	"testing"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZipkinTracing(t *testing.T) {
+	for _, it := range []extension.ZipkinTracing{
+		{Sampling: extension.ZipkinSamplingNotSampled},
+		{TraceID: 1, SpanID: 2, Sampling: extension.ZipkinSamplingSampled},
+		{TraceID: 1, SpanID: 2, ParentID: 3, Sampling: extension.ZipkinSamplingDebug},
+		{TraceIDHigh: 4, TraceID: 1, SpanID: 2, ParentID: 3},
+	} {
+		raw := it.Bytes()
+		size := 1
+		if it.HasIDs() {
+			size += 16
+		}
+		if it.Is128() {
+			size += 8
+		}
+		if it.ParentID != 0 {
+			size += 8
+		}
+		assert.Len(t, raw, size)
+		parsed, err := extension.ParseZipkinTracing(raw)
+		assert.NoError(t, err, "bad zipkin tracing bytes")
+		assert.Equal(t, it, parsed, "not match")
+	}
+
+	z := extension.ZipkinTracing{TraceIDHigh: 1, TraceID: 2, SpanID: 3}
+	assert.Equal(t, "00000000000000010000000000000002", z.TraceIDString())
+	assert.Equal(t, []byte{0x80 | 0x08, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3}, z.Bytes())
+
+	_, err := extension.ParseZipkinTracing(nil)
+	assert.Error(t, err)
+	_, err = extension.ParseZipkinTracing(z.Bytes()[:20])
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package rsocket
+
+import (
+	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+type (
+	// Interceptor wraps all interaction models of a connection, both requests sent to peer and requests received from peer.
+	// It can be bound by ClientBuilder.Interceptor or ServerBuilder.Interceptor.
+	// Each method should call next to continue the chain, or return its own result to short-circuit it.
+	Interceptor = socket.Interceptor
+
+	// Call represents an intercepted request, it provides the side, stream ID, SETUP payload, remote address and connection.
+	Call = socket.Call
+
+	// OptInterceptor is option for abstract interceptor.
+	OptInterceptor func(*socket.AbstractInterceptor)
+)
+
+// NewInterceptor returns an abstract implementation of Interceptor.
+// You can specify the actual implementation of any request, others will be passed through.
+func NewInterceptor(opts ...OptInterceptor) Interceptor {
+	it := &socket.AbstractInterceptor{}
+	for _, fn := range opts {
+		fn(it)
+	}
+	return it
+}
+
+// InterceptFireAndForget register interceptor for FireAndForget.
+func InterceptFireAndForget(fn func(call *Call, msg payload.Payload, next func(payload.Payload))) OptInterceptor {
+	return func(it *socket.AbstractInterceptor) {
+		it.FF = fn
+	}
+}
+
+// InterceptMetadataPush register interceptor for MetadataPush.
+func InterceptMetadataPush(fn func(call *Call, msg payload.Payload, next func(payload.Payload))) OptInterceptor {
+	return func(it *socket.AbstractInterceptor) {
+		it.MP = fn
+	}
+}
+
+// InterceptRequestResponse register interceptor for RequestResponse.
+func InterceptRequestResponse(fn func(call *Call, msg payload.Payload, next func(payload.Payload) mono.Mono) mono.Mono) OptInterceptor {
+	return func(it *socket.AbstractInterceptor) {
+		it.RR = fn
+	}
+}
+
+// InterceptRequestStream register interceptor for RequestStream.
+func InterceptRequestStream(fn func(call *Call, msg payload.Payload, next func(payload.Payload) flux.Flux) flux.Flux) OptInterceptor {
+	return func(it *socket.AbstractInterceptor) {
+		it.RS = fn
+	}
+}
+
+// InterceptRequestChannel register interceptor for RequestChannel.
+func InterceptRequestChannel(fn func(call *Call, msgs rx.Publisher, next func(rx.Publisher) flux.Flux) flux.Flux) OptInterceptor {
+	return func(it *socket.AbstractInterceptor) {
+		it.RC = fn
+	}
+}
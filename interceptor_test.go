@@ -0,0 +1,152 @@
+package rsocket_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor(t *testing.T) {
+	const addr = "127.0.0.1:7993"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var locker sync.Mutex
+	var calls []Call
+	record := func(call *Call) {
+		locker.Lock()
+		calls = append(calls, *call)
+		locker.Unlock()
+	}
+
+	fnf := make(chan string, 1)
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Interceptor(NewInterceptor(
+				InterceptFireAndForget(func(call *Call, msg Payload, next func(Payload)) {
+					record(call)
+					next(msg)
+				}),
+				InterceptRequestResponse(func(call *Call, msg Payload, next func(Payload) mono.Mono) mono.Mono {
+					record(call)
+					if m, _ := msg.MetadataUTF8(); m != "token" {
+						return mono.Error(errors.New("denied"))
+					}
+					return next(msg)
+				}),
+				InterceptRequestStream(func(call *Call, msg Payload, next func(Payload) flux.Flux) flux.Flux {
+					record(call)
+					return next(msg).Map(func(input Payload) Payload {
+						return NewString(input.DataUTF8()+"!", "")
+					})
+				}),
+			)).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					FireAndForget(func(msg Payload) {
+						fnf <- msg.DataUTF8()
+					}),
+					RequestResponse(func(msg Payload) mono.Mono {
+						return mono.Just(msg)
+					}),
+					RequestStream(func(msg Payload) flux.Flux {
+						return flux.Just(msg, msg)
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	authorized := NewInterceptor(InterceptRequestResponse(func(call *Call, msg Payload, next func(Payload) mono.Mono) mono.Mono {
+		if msg.DataUTF8() == "rejected" {
+			return mono.Error(errors.New("rejected"))
+		}
+		res := next(NewString(msg.DataUTF8(), "token"))
+		record(call)
+		return res
+	}))
+	cli, err := Connect().
+		DataMimeType("text/plain").
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// Request without token should be denied by server interceptor.
+	_, err = cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	assert.Error(t, err)
+
+	cli2, err := Connect().
+		DataMimeType("text/plain").
+		Interceptor(authorized).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli2.Close()
+	}()
+
+	// Request rejected by client interceptor should not consume a stream ID.
+	_, err = cli2.RequestResponse(NewString("rejected", "")).Block(ctx)
+	assert.Error(t, err)
+
+	res, err := cli2.RequestResponse(NewString("hello", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", res.DataUTF8())
+
+	var received []string
+	_, err = cli2.RequestStream(NewString("world", "")).
+		DoOnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"world!", "world!"}, received)
+
+	cli2.FireAndForget(NewString("fnf", ""))
+	select {
+	case s := <-fnf:
+		assert.Equal(t, "fnf", s)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "FireAndForget should be received")
+	}
+
+	locker.Lock()
+	defer locker.Unlock()
+	require.Len(t, calls, 5)
+	// client side
+	assert.False(t, calls[1].Responder)
+	assert.Equal(t, uint32(1), calls[1].StreamID)
+	assert.Equal(t, calls[1].StreamID, calls[2].StreamID)
+	assert.Equal(t, addr, calls[1].RemoteAddr.String())
+	assert.NotNil(t, calls[1].Connection)
+	// server side
+	for _, i := range []int{0, 2, 3, 4} {
+		assert.True(t, calls[i].Responder)
+		assert.NotZero(t, calls[i].StreamID)
+		assert.Equal(t, "text/plain", calls[i].Setup.DataMimeType())
+		assert.NotNil(t, calls[i].RemoteAddr)
+		assert.NotNil(t, calls[i].Connection)
+	}
+}
@@ -214,11 +214,13 @@ func (p *BaseFrame) WriteTo(w io.Writer) (n int64, err error) {
 		return
 	}
 	n += wrote
-	wrote, err = p.body.WriteTo(w)
+	// Keep body unread, frame may be retransmitted when resuming.
+	var bodyWrote int
+	bodyWrote, err = w.Write(p.body.Bytes())
 	if err != nil {
 		return
 	}
-	n += wrote
+	n += int64(bodyWrote)
 	return
 }
 
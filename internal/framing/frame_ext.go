@@ -0,0 +1,102 @@
+package framing
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+)
+
+const extendedTypeLen = 4
+
+// FrameExt is extension frame, its content depends on the extended type.
+type FrameExt struct {
+	*BaseFrame
+}
+
+// Validate returns error if frame is invalid.
+func (p *FrameExt) Validate() (err error) {
+	if p.body.Len() < extendedTypeLen {
+		err = errIncompleteFrame
+	}
+	return
+}
+
+func (p *FrameExt) String() string {
+	m, _ := p.MetadataUTF8()
+	return fmt.Sprintf("FrameExt{%s,extendedType=%d,data=%s,metadata=%s}", p.header, p.ExtendedType(), p.DataUTF8(), m)
+}
+
+// ExtendedType returns extended type.
+func (p *FrameExt) ExtendedType() uint32 {
+	return binary.BigEndian.Uint32(p.body.Bytes())
+}
+
+// CanIgnore returns true if frame can be ignored when it is not understood.
+func (p *FrameExt) CanIgnore() bool {
+	return p.header.Flag().Check(FlagIgnore)
+}
+
+// Metadata returns metadata bytes.
+func (p *FrameExt) Metadata() ([]byte, bool) {
+	return p.trySliceMetadata(extendedTypeLen)
+}
+
+// Data returns data bytes.
+func (p *FrameExt) Data() []byte {
+	return p.trySliceData(extendedTypeLen)
+}
+
+// MetadataUTF8 returns metadata as UTF8 string.
+func (p *FrameExt) MetadataUTF8() (metadata string, ok bool) {
+	raw, ok := p.Metadata()
+	if ok {
+		metadata = string(raw)
+	}
+	return
+}
+
+// DataUTF8 returns data as UTF8 string.
+func (p *FrameExt) DataUTF8() string {
+	return string(p.Data())
+}
+
+// NewFrameExt returns a new extension frame.
+func NewFrameExt(sid uint32, extendedType uint32, data, metadata []byte, flags ...FrameFlag) *FrameExt {
+	fg := newFlags(flags...)
+	bf := common.NewByteBuff()
+	var b4 [4]byte
+	binary.BigEndian.PutUint32(b4[:], extendedType)
+	if _, err := bf.Write(b4[:]); err != nil {
+		panic(err)
+	}
+	if len(metadata) > 0 {
+		fg |= FlagMetadata
+		if err := bf.WriteUint24(len(metadata)); err != nil {
+			panic(err)
+		}
+		if _, err := bf.Write(metadata); err != nil {
+			panic(err)
+		}
+	}
+	if _, err := bf.Write(data); err != nil {
+		panic(err)
+	}
+	return &FrameExt{
+		NewBaseFrame(NewFrameHeader(sid, FrameTypeExt, fg), bf),
+	}
+}
+
+// FrameUnknown is a frame of reserved or unknown type, it can only be decoded when it carries IGNORE flag.
+type FrameUnknown struct {
+	*BaseFrame
+}
+
+// Validate returns error if frame is invalid.
+func (p *FrameUnknown) Validate() (err error) {
+	return
+}
+
+func (p *FrameUnknown) String() string {
+	return fmt.Sprintf("FrameUnknown{%s,type=%d}", p.header, p.header.Type())
+}
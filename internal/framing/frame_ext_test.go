@@ -0,0 +1,50 @@
+package framing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func decode(t *testing.T, f Frame) (Frame, error) {
+	bf := &bytes.Buffer{}
+	_, err := f.WriteTo(bf)
+	assert.NoError(t, err)
+	bs := bf.Bytes()
+	bb := common.NewByteBuff()
+	_, _ = bb.Write(bs[HeaderLen:])
+	return NewFromBase(NewBaseFrame(ParseFrameHeader(bs[:HeaderLen]), bb))
+}
+
+func TestFrameExt(t *testing.T) {
+	f, err := decode(t, NewFrameExt(0, 0x12345678, []byte("world"), []byte("hello"), FlagIgnore))
+	assert.NoError(t, err)
+	ext, ok := f.(*FrameExt)
+	assert.True(t, ok, "should be EXT")
+	assert.NoError(t, ext.Validate())
+	assert.Equal(t, uint32(0x12345678), ext.ExtendedType())
+	assert.True(t, ext.CanIgnore())
+	metadata, ok := ext.MetadataUTF8()
+	assert.True(t, ok)
+	assert.Equal(t, "hello", metadata)
+	assert.Equal(t, "world", ext.DataUTF8())
+
+	ext = NewFrameExt(1, 1, nil, nil)
+	assert.False(t, ext.CanIgnore())
+	_, ok = ext.Metadata()
+	assert.False(t, ok)
+	assert.Empty(t, ext.Data())
+}
+
+func TestFrameUnknown(t *testing.T) {
+	const reserved FrameType = 0x20
+	_, err := decode(t, &FrameUnknown{NewBaseFrame(NewFrameHeader(1, reserved), common.NewByteBuff())})
+	assert.Equal(t, common.ErrInvalidFrame, err)
+
+	f, err := decode(t, &FrameUnknown{NewBaseFrame(NewFrameHeader(1, reserved, FlagIgnore), common.NewByteBuff())})
+	assert.NoError(t, err)
+	assert.IsType(t, &FrameUnknown{}, f)
+	assert.Equal(t, reserved, f.Header().Type())
+}
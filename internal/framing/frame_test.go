@@ -1,6 +1,7 @@
 package framing
 
 import (
+	"bytes"
 	"encoding/hex"
 	"log"
 	"testing"
@@ -37,3 +38,15 @@ func TestDecode_Payload(t *testing.T) {
 	log.Println("actual:", hex.EncodeToString(lease.Bytes()))
 	log.Println("should: 00000000090000000bb800000005")
 }
+
+func TestBaseFrame_WriteTo(t *testing.T) {
+	f := NewFramePayload(1, []byte("hello"), []byte("world"), FlagNext)
+	var first, second bytes.Buffer
+	_, err := f.WriteTo(&first)
+	assert.NoError(t, err)
+	// Frame is retransmitted with the same bytes when resuming.
+	_, err = f.WriteTo(&second)
+	assert.NoError(t, err)
+	assert.Equal(t, f.Len(), first.Len())
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
@@ -44,8 +44,15 @@ func NewFromBase(f *BaseFrame) (frame Frame, err error) {
 		frame = &FrameResume{BaseFrame: f}
 	case FrameTypeResumeOK:
 		frame = &FrameResumeOK{BaseFrame: f}
+	case FrameTypeExt:
+		frame = &FrameExt{BaseFrame: f}
 	default:
-		err = common.ErrInvalidFrame
+		// Frames which are not understood can be skipped if they carry IGNORE flag.
+		if f.header.Flag().Check(FlagIgnore) {
+			frame = &FrameUnknown{BaseFrame: f}
+		} else {
+			err = common.ErrInvalidFrame
+		}
 	}
 	return
 }
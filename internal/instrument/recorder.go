@@ -0,0 +1,280 @@
+package instrument
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/rsocket/rsocket-go/rx"
+	"go.uber.org/atomic"
+)
+
+var (
+	frameTypes = []framing.FrameType{
+		framing.FrameTypeSetup,
+		framing.FrameTypeLease,
+		framing.FrameTypeKeepalive,
+		framing.FrameTypeRequestResponse,
+		framing.FrameTypeRequestFNF,
+		framing.FrameTypeRequestStream,
+		framing.FrameTypeRequestChannel,
+		framing.FrameTypeRequestN,
+		framing.FrameTypeCancel,
+		framing.FrameTypePayload,
+		framing.FrameTypeError,
+		framing.FrameTypeMetadataPush,
+		framing.FrameTypeResume,
+		framing.FrameTypeResumeOK,
+		framing.FrameTypeExt,
+	}
+	requestTypes = []framing.FrameType{
+		framing.FrameTypeRequestFNF,
+		framing.FrameTypeMetadataPush,
+		framing.FrameTypeRequestResponse,
+		framing.FrameTypeRequestStream,
+		framing.FrameTypeRequestChannel,
+	}
+	sides    = [2]string{"requester", "responder"}
+	outcomes = map[rx.SignalType]string{
+		rx.SignalComplete: "completed",
+		rx.SignalError:    "errored",
+		rx.SignalCancel:   "cancelled",
+	}
+)
+
+type requestKey struct {
+	responder bool
+	t         framing.FrameType
+}
+
+type finishKey struct {
+	requestKey
+	sig rx.SignalType
+}
+
+// Recorder records metrics of RSocket connections into a metrics.Registry.
+// It is created by ClientBuilder.Metrics or ServerBuilder.Metrics and driven by sockets internally.
+// All methods of a nil Recorder do nothing.
+type Recorder struct {
+	registry      metrics.Registry
+	role          string
+	seq           *atomic.Uint64
+	connections   metrics.Counter
+	connsActive   metrics.Gauge
+	streamsActive metrics.Gauge
+	reassemblies  metrics.Counter
+	keepaliveRTT  metrics.Histogram
+	leaseGranted  metrics.Counter
+	leaseRejected metrics.Counter
+	framesIn      map[framing.FrameType]metrics.Counter
+	framesOut     map[framing.FrameType]metrics.Counter
+	started       map[requestKey]metrics.Counter
+	finished      map[finishKey]metrics.Counter
+}
+
+// Traffic provides numbers of bytes read and written by a connection, it is implemented by transport.Counter.
+type Traffic interface {
+	// ReadBytes returns the number of bytes that have been read.
+	ReadBytes() uint64
+	// WriteBytes returns the number of bytes that have been written.
+	WriteBytes() uint64
+}
+
+// Connection records metrics of a single connection, its series of bytes have a label "connection".
+// All methods of a nil Connection do nothing.
+type Connection struct {
+	*Recorder
+	locker        sync.Mutex
+	traffic       Traffic
+	labelsIn      metrics.Labels
+	labelsOut     metrics.Labels
+	bytesIn       metrics.Counter
+	bytesOut      metrics.Counter
+	read, written uint64
+	closed        bool
+}
+
+// NewRecorder creates a Recorder for connections with given role.
+func NewRecorder(registry metrics.Registry, role string) *Recorder {
+	r := &Recorder{
+		registry:      registry,
+		role:          role,
+		seq:           atomic.NewUint64(0),
+		connections:   registry.Counter(metrics.NameConnections, "Total number of connections.", withRole(role)),
+		connsActive:   registry.Gauge(metrics.NameConnectionsActive, "Number of open connections.", withRole(role)),
+		streamsActive: registry.Gauge(metrics.NameStreamsActive, "Number of streams in flight.", withRole(role)),
+		reassemblies:  registry.Counter(metrics.NameReassemblies, "Total number of payloads reassembled from fragments.", withRole(role)),
+		keepaliveRTT:  registry.Histogram(metrics.NameKeepaliveRTT, "Round-trip time of KEEPALIVE in seconds.", metrics.DefaultBuckets, withRole(role)),
+		leaseGranted:  registry.Counter(metrics.NameLeaseGranted, "Total number of requests granted by LEASE.", withRole(role)),
+		leaseRejected: registry.Counter(metrics.NameLeaseRejected, "Total number of requests rejected because of lease.", withRole(role)),
+		framesIn:      make(map[framing.FrameType]metrics.Counter, len(frameTypes)),
+		framesOut:     make(map[framing.FrameType]metrics.Counter, len(frameTypes)),
+		started:       make(map[requestKey]metrics.Counter, len(requestTypes)*len(sides)),
+		finished:      make(map[finishKey]metrics.Counter, len(requestTypes)*len(sides)*len(outcomes)),
+	}
+	for _, t := range frameTypes {
+		r.framesIn[t] = registry.Counter(metrics.NameFrames, "Total number of frames.", withRole(role, "direction", "in", "type", t.String()))
+		r.framesOut[t] = registry.Counter(metrics.NameFrames, "Total number of frames.", withRole(role, "direction", "out", "type", t.String()))
+	}
+	for _, t := range requestTypes {
+		for i, side := range sides {
+			k := requestKey{responder: i == 1, t: t}
+			r.started[k] = registry.Counter(metrics.NameRequestsStarted, "Total number of started requests.", withRole(role, "side", side, "type", t.String()))
+			for sig, outcome := range outcomes {
+				r.finished[finishKey{requestKey: k, sig: sig}] = registry.Counter(
+					metrics.NameRequestsFinished,
+					"Total number of finished requests.",
+					withRole(role, "side", side, "type", t.String(), "outcome", outcome),
+				)
+			}
+		}
+	}
+	return r
+}
+
+// r0 returns labels with given role and pairs of name and value.
+func withRole(role string, kv ...string) metrics.Labels {
+	labels := metrics.Labels{"role": role}
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels[kv[i]] = kv[i+1]
+	}
+	return labels
+}
+
+// Connection records a new connection, bytes of it are taken from traffic.
+func (p *Recorder) Connection(traffic Traffic) *Connection {
+	if p == nil {
+		return nil
+	}
+	p.connections.Inc()
+	p.connsActive.Add(1)
+	id := strconv.FormatUint(p.seq.Inc(), 10)
+	c := &Connection{
+		Recorder:  p,
+		traffic:   traffic,
+		labelsIn:  withRole(p.role, "direction", "in", "connection", id),
+		labelsOut: withRole(p.role, "direction", "out", "connection", id),
+	}
+	c.bytesIn = p.registry.Counter(metrics.NameBytes, "Total bytes of frames.", c.labelsIn)
+	c.bytesOut = p.registry.Counter(metrics.NameBytes, "Total bytes of frames.", c.labelsOut)
+	return c
+}
+
+// FrameRead records a frame read from peer.
+func (p *Connection) FrameRead(t framing.FrameType) {
+	if p == nil {
+		return
+	}
+	if c, ok := p.framesIn[t]; ok {
+		c.Inc()
+	}
+	p.sync()
+}
+
+// FrameWritten records a frame written to peer.
+func (p *Connection) FrameWritten(t framing.FrameType) {
+	if p == nil {
+		return
+	}
+	if c, ok := p.framesOut[t]; ok {
+		c.Inc()
+	}
+	p.sync()
+}
+
+// Close records a closed connection, its series are unregistered if the registry supports it.
+func (p *Connection) Close() {
+	if p == nil {
+		return
+	}
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.connsActive.Add(-1)
+	if u, ok := p.registry.(metrics.Unregisterer); ok {
+		u.Unregister(metrics.NameBytes, p.labelsIn)
+		u.Unregister(metrics.NameBytes, p.labelsOut)
+	}
+}
+
+// sync adds bytes counted by traffic since last call.
+func (p *Connection) sync() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if p.closed {
+		return
+	}
+	if n := p.traffic.ReadBytes(); n > p.read {
+		p.bytesIn.Add(float64(n - p.read))
+		p.read = n
+	}
+	if n := p.traffic.WriteBytes(); n > p.written {
+		p.bytesOut.Add(float64(n - p.written))
+		p.written = n
+	}
+}
+
+// RequestStarted records a started request, t is the frame type of request.
+func (p *Recorder) RequestStarted(responder bool, t framing.FrameType) {
+	if p == nil {
+		return
+	}
+	if c, ok := p.started[requestKey{responder: responder, t: t}]; ok {
+		c.Inc()
+	}
+}
+
+// RequestFinished records a finished request with its final signal.
+func (p *Recorder) RequestFinished(responder bool, t framing.FrameType, sig rx.SignalType) {
+	if p == nil {
+		return
+	}
+	if c, ok := p.finished[finishKey{requestKey: requestKey{responder: responder, t: t}, sig: sig}]; ok {
+		c.Inc()
+	}
+}
+
+// StreamsActive adds delta to the number of streams in flight.
+func (p *Recorder) StreamsActive(delta int) {
+	if p == nil || delta == 0 {
+		return
+	}
+	p.streamsActive.Add(float64(delta))
+}
+
+// Reassembled records a payload reassembled from fragments.
+func (p *Recorder) Reassembled() {
+	if p == nil {
+		return
+	}
+	p.reassemblies.Inc()
+}
+
+// KeepaliveRTT records a round-trip time of KEEPALIVE.
+func (p *Recorder) KeepaliveRTT(rtt time.Duration) {
+	if p == nil {
+		return
+	}
+	p.keepaliveRTT.Observe(rtt.Seconds())
+}
+
+// LeaseGranted records requests granted by a LEASE frame.
+func (p *Recorder) LeaseGranted(n uint32) {
+	if p == nil {
+		return
+	}
+	p.leaseGranted.Add(float64(n))
+}
+
+// LeaseRejected records a request rejected because of lease.
+func (p *Recorder) LeaseRejected() {
+	if p == nil {
+		return
+	}
+	p.leaseRejected.Inc()
+}
@@ -3,6 +3,7 @@ package session
 import (
 	"container/heap"
 	"sync"
+	"time"
 )
 
 // Manager is used to manage RSocket session when resume is enabled.
@@ -60,6 +61,18 @@ func (p *Manager) Pop() (session *Session) {
 	return
 }
 
+// Expire removes and returns all sessions which are dead at given time.
+func (p *Manager) Expire(now time.Time) (deads []*Session) {
+	p.locker.Lock()
+	for len(*p.h) > 0 && now.After((*p.h)[0].deadline) {
+		session := heap.Pop(p.h).(*Session)
+		delete(p.m, (string)(session.Token()))
+		deads = append(deads, session)
+	}
+	p.locker.Unlock()
+	return
+}
+
 // NewManager returns a new blank session manager.
 func NewManager() *Manager {
 	return &Manager{
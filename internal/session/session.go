@@ -1,26 +1,51 @@
 package session
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
 	"github.com/rsocket/rsocket-go/internal/socket"
 )
 
+const snapshotVersion = 1
+
+var errInvalidSnapshot = errors.New("invalid session snapshot")
+
 // Session represents a lifecycle of a RSocket server socket.
+// A session which is decoded from a snapshot has no socket, it should be revived before resuming.
 type Session struct {
 	index    int
 	deadline time.Time
+	token    []byte
+	setup    *framing.FrameSetup
 	socket   socket.ServerSocket
+	// state of snapshot
+	receivedPosition       uint64
+	firstAvailablePosition uint64
+	frames                 []framing.Frame
 }
 
 // Socket returns RSocket server socket in current session.
+// It returns nil if current session is decoded from a snapshot.
 func (p *Session) Socket() socket.ServerSocket {
 	return p.socket
 }
 
+// Setup returns the SETUP frame of current session.
+func (p *Session) Setup() *framing.FrameSetup {
+	return p.setup
+}
+
 // Close close current session.
 func (p *Session) Close() error {
+	if p.socket == nil {
+		return nil
+	}
 	return p.socket.Close()
 }
 
@@ -30,21 +55,143 @@ func (p *Session) IsDead() (dead bool) {
 	return
 }
 
+// Deadline returns the time after which session expires.
+func (p *Session) Deadline() time.Time {
+	return p.deadline
+}
+
 func (p *Session) String() string {
-	tk, _ := p.socket.Token()
-	return fmt.Sprintf("Session{token=0x%02X,deadline=%d}", tk, p.deadline.Unix())
+	return fmt.Sprintf("Session{token=0x%02X,deadline=%d}", p.token, p.deadline.Unix())
 }
 
 // Token returns token of session.
 func (p *Session) Token() (token []byte) {
-	token, _ = p.socket.Token()
+	return p.token
+}
+
+// Snapshot returns last received position, first available position and retained frames.
+func (p *Session) Snapshot() (receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	if p.socket != nil {
+		return p.socket.Snapshot()
+	}
+	return p.receivedPosition, p.firstAvailablePosition, p.frames
+}
+
+// MarshalBinary encodes current session to a snapshot.
+func (p *Session) MarshalBinary() ([]byte, error) {
+	if p.setup == nil {
+		return nil, errors.New("cannot encode session without setup")
+	}
+	received, first, frames := p.Snapshot()
+	bf := &bytes.Buffer{}
+	bf.WriteByte(snapshotVersion)
+	writeBytes(bf, p.token)
+	_ = binary.Write(bf, binary.BigEndian, p.deadline.UnixNano())
+	writeBytes(bf, p.setup.Bytes())
+	_ = binary.Write(bf, binary.BigEndian, received)
+	_ = binary.Write(bf, binary.BigEndian, first)
+	_ = binary.Write(bf, binary.BigEndian, uint32(len(frames)))
+	for _, it := range frames {
+		writeBytes(bf, it.Bytes())
+	}
+	return bf.Bytes(), nil
+}
+
+// Unmarshal decodes a session from a snapshot.
+func Unmarshal(raw []byte) (session *Session, err error) {
+	r := bytes.NewReader(raw)
+	version, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	if version != snapshotVersion {
+		err = fmt.Errorf("unsupported session snapshot version %d", version)
+		return
+	}
+	s := &Session{
+		index: -1,
+	}
+	if s.token, err = readBytes(r); err != nil {
+		return
+	}
+	var deadline int64
+	if err = binary.Read(r, binary.BigEndian, &deadline); err != nil {
+		return
+	}
+	s.deadline = time.Unix(0, deadline)
+	setup, err := readFrame(r)
+	if err != nil {
+		return
+	}
+	var ok bool
+	if s.setup, ok = setup.(*framing.FrameSetup); !ok {
+		err = errInvalidSnapshot
+		return
+	}
+	if err = binary.Read(r, binary.BigEndian, &s.receivedPosition); err != nil {
+		return
+	}
+	if err = binary.Read(r, binary.BigEndian, &s.firstAvailablePosition); err != nil {
+		return
+	}
+	var n uint32
+	if err = binary.Read(r, binary.BigEndian, &n); err != nil {
+		return
+	}
+	for i := uint32(0); i < n; i++ {
+		var f framing.Frame
+		if f, err = readFrame(r); err != nil {
+			return
+		}
+		s.frames = append(s.frames, f)
+	}
+	session = s
 	return
 }
 
 // NewSession returns a new session.
-func NewSession(deadline time.Time, sk socket.ServerSocket) *Session {
+func NewSession(deadline time.Time, sk socket.ServerSocket, setup *framing.FrameSetup) *Session {
+	token, _ := sk.Token()
 	return &Session{
 		deadline: deadline,
+		token:    token,
+		setup:    setup,
 		socket:   sk,
 	}
 }
+
+func writeBytes(bf *bytes.Buffer, b []byte) {
+	_ = binary.Write(bf, binary.BigEndian, uint32(len(b)))
+	bf.Write(b)
+}
+
+func readBytes(r *bytes.Reader) (b []byte, err error) {
+	var n uint32
+	if err = binary.Read(r, binary.BigEndian, &n); err != nil {
+		return
+	}
+	if int(n) > r.Len() {
+		err = errInvalidSnapshot
+		return
+	}
+	b = make([]byte, n)
+	_, err = r.Read(b)
+	return
+}
+
+func readFrame(r *bytes.Reader) (f framing.Frame, err error) {
+	raw, err := readBytes(r)
+	if err != nil {
+		return
+	}
+	if len(raw) < framing.HeaderLen {
+		err = errInvalidSnapshot
+		return
+	}
+	bf := common.NewByteBuff()
+	if _, err = bf.Write(raw[framing.HeaderLen:]); err != nil {
+		return
+	}
+	f, err = framing.NewFromBase(framing.NewBaseFrame(framing.ParseFrameHeader(raw), bf))
+	return
+}
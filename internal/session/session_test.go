@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
 	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSession(t *testing.T) {
@@ -14,7 +16,7 @@ func TestSession(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		deadline := time.Now().Add(time.Duration(common.RandIntn(30)) * time.Second)
 		token := common.RandAlphanumeric(32)
-		manager.Push(NewSession(deadline, socket.NewServerResume(nil, []byte(token))))
+		manager.Push(NewSession(deadline, socket.NewServerResume(nil, []byte(token)), nil))
 	}
 
 	for _, value := range *(manager.h) {
@@ -26,3 +28,51 @@ func TestSession(t *testing.T) {
 		log.Println("session:", manager.Pop())
 	}
 }
+
+func TestManager_Expire(t *testing.T) {
+	manager := NewManager()
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		deadline := now.Add(time.Duration(i*2-3) * time.Second)
+		token := common.RandAlphanumeric(32)
+		manager.Push(NewSession(deadline, socket.NewServerResume(nil, []byte(token)), nil))
+	}
+	deads := manager.Expire(now)
+	assert.Len(t, deads, 2)
+	assert.Equal(t, 2, manager.Len())
+	for _, it := range deads {
+		_, ok := manager.Load(it.Token())
+		assert.False(t, ok)
+	}
+}
+
+func TestSession_MarshalBinary(t *testing.T) {
+	token := []byte(common.RandAlphanumeric(32))
+	setup := framing.NewFrameSetup(common.DefaultVersion, 20*time.Second, 90*time.Second, token, []byte("text/plain"), []byte("text/plain"), []byte("hello"), nil, false)
+	sk := socket.NewServerResume(socket.NewServerDuplexRSocket(0, nil), token)
+	frames := []framing.Frame{
+		framing.NewFramePayload(1, []byte("foo"), nil, framing.FlagNext),
+		framing.NewFramePayload(1, []byte("bar"), nil, framing.FlagNext|framing.FlagComplete),
+	}
+	sk.Restore(128, 64, frames)
+	deadline := time.Now().Add(time.Minute)
+	raw, err := NewSession(deadline, sk, setup).MarshalBinary()
+	assert.NoError(t, err)
+
+	decoded, err := Unmarshal(raw)
+	assert.NoError(t, err)
+	assert.Nil(t, decoded.Socket())
+	assert.Equal(t, token, decoded.Token())
+	assert.Equal(t, deadline.UnixNano(), decoded.Deadline().UnixNano())
+	assert.Equal(t, setup.Bytes(), decoded.Setup().Bytes())
+	received, first, restored := decoded.Snapshot()
+	assert.Equal(t, uint64(128), received)
+	assert.Equal(t, uint64(64), first)
+	assert.Len(t, restored, len(frames))
+	for i := range frames {
+		assert.Equal(t, frames[i].Bytes(), restored[i].Bytes())
+	}
+
+	_, err = Unmarshal(raw[:len(raw)-1])
+	assert.Error(t, err, "truncated snapshot should be rejected")
+}
@@ -2,7 +2,6 @@ package socket
 
 import (
 	"context"
-	"crypto/tls"
 
 	"github.com/rsocket/rsocket-go/internal/framing"
 	"github.com/rsocket/rsocket-go/internal/transport"
@@ -11,19 +10,20 @@ import (
 
 type defaultClientSocket struct {
 	*baseSocket
-	uri     *transport.URI
-	headers map[string][]string
-	tls     *tls.Config
+	dial transport.ClientTransportFunc
 }
 
 func (p *defaultClientSocket) Setup(ctx context.Context, setup *SetupInfo) (err error) {
-	tp, err := p.uri.MakeClientTransport(p.tls, p.headers)
+	tp, err := p.dial(ctx)
 	if err != nil {
 		return
 	}
 	tp.Connection().SetCounter(p.socket.counter)
+	tp.SetMetrics(p.socket.metrics)
 	tp.SetLifetime(setup.KeepaliveLifetime)
 
+	setupFrame := setup.toFrame()
+	p.socket.SetSetup(setupFrame)
 	p.socket.SetTransport(tp)
 
 	if setup.Lease {
@@ -51,17 +51,14 @@ func (p *defaultClientSocket) Setup(ctx context.Context, setup *SetupInfo) (err
 	go func(ctx context.Context) {
 		_ = p.socket.loopWrite(ctx)
 	}(ctx)
-	setupFrame := setup.toFrame()
 	err = p.socket.tp.Send(setupFrame, true)
 	return
 }
 
 // NewClient create a simple client-side socket.
-func NewClient(uri *transport.URI, socket *DuplexRSocket, tc *tls.Config, headers map[string][]string) ClientSocket {
+func NewClient(dial transport.ClientTransportFunc, socket *DuplexRSocket) ClientSocket {
 	return &defaultClientSocket{
 		baseSocket: newBaseSocket(socket),
-		uri:        uri,
-		headers:    headers,
-		tls:        tc,
+		dial:       dial,
 	}
 }
@@ -0,0 +1,402 @@
+package socket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var (
+	// ErrConnectionLost is used to fail requests in flight when transport of a reconnecting client is lost.
+	ErrConnectionLost = errors.New("rsocket: connection lost")
+	// ErrReconnecting is used to fail new requests when a fail-fast client is reconnecting.
+	ErrReconnecting = errors.New("rsocket: client is reconnecting")
+)
+
+// ReconnectPolicy controls how a client reconnects after its transport is lost.
+type ReconnectPolicy struct {
+	// Backoff returns the delay before n-th attempt, n starts from 1.
+	Backoff func(attempt int) time.Duration
+	// MaxAttempts is the max number of attempts after each disconnection, zero means no limit.
+	MaxAttempts int
+	// FailFast fails new requests during outage instead of queueing them.
+	FailFast bool
+	// OnConnect is invoked after a connection is established.
+	OnConnect func()
+	// OnDisconnect is invoked after a connection is lost.
+	OnDisconnect func(err error)
+}
+
+type reconnectClientSocket struct {
+	locker       sync.Mutex
+	conn         *baseSocket
+	ready        chan struct{}
+	err          error
+	done         chan struct{}
+	once         sync.Once
+	closers      []func(error)
+	dial         transport.ClientTransportFunc
+	setup        *SetupInfo
+	policy       *ReconnectPolicy
+	newSocket    func() *DuplexRSocket
+	newResponder func() Responder
+}
+
+func (p *reconnectClientSocket) Setup(ctx context.Context, setup *SetupInfo) (err error) {
+	p.setup = setup
+	if err = p.connect(ctx); err != nil {
+		p.once.Do(func() {
+			p.markClosed(err)
+		})
+	}
+	return
+}
+
+func (p *reconnectClientSocket) OnClose(fn func(error)) {
+	if fn != nil {
+		p.closers = append(p.closers, fn)
+	}
+}
+
+func (p *reconnectClientSocket) Close() (err error) {
+	p.once.Do(func() {
+		if conn := p.markClosed(errSocketClosed); conn != nil {
+			err = conn.socket.Close()
+		}
+		p.fireClosers(err)
+	})
+	return
+}
+
+func (p *reconnectClientSocket) FireAndForget(message payload.Payload) {
+	conn, err := p.acquire()
+	if err != nil {
+		logger.Warnf("request FireAndForget failed: %v\n", err)
+		return
+	}
+	if conn != nil {
+		conn.FireAndForget(message)
+		return
+	}
+	go func() {
+		if conn, err := p.await(context.Background()); err != nil {
+			logger.Warnf("request FireAndForget failed: %v\n", err)
+		} else {
+			conn.FireAndForget(message)
+		}
+	}()
+}
+
+func (p *reconnectClientSocket) MetadataPush(message payload.Payload) {
+	conn, err := p.acquire()
+	if err != nil {
+		logger.Warnf("request MetadataPush failed: %v\n", err)
+		return
+	}
+	if conn != nil {
+		conn.MetadataPush(message)
+		return
+	}
+	go func() {
+		if conn, err := p.await(context.Background()); err != nil {
+			logger.Warnf("request MetadataPush failed: %v\n", err)
+		} else {
+			conn.MetadataPush(message)
+		}
+	}()
+}
+
+// SendExtension sends an EXT frame by current connection, it waits for reconnecting if the connection is lost.
+func (p *reconnectClientSocket) SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool) {
+	conn, err := p.acquire()
+	if err != nil {
+		logger.Warnf("send EXT frame failed: %v\n", err)
+		return
+	}
+	if conn != nil {
+		conn.SendExtension(extendedType, msg, canIgnore)
+		return
+	}
+	go func() {
+		if conn, err := p.await(context.Background()); err != nil {
+			logger.Warnf("send EXT frame failed: %v\n", err)
+		} else {
+			conn.SendExtension(extendedType, msg, canIgnore)
+		}
+	}()
+}
+
+func (p *reconnectClientSocket) RequestResponse(message payload.Payload) mono.Mono {
+	conn, err := p.acquire()
+	if err != nil {
+		return mono.Error(err)
+	}
+	if conn != nil {
+		return conn.RequestResponse(message)
+	}
+	// Queue request until next connection is ready.
+	return mono.Create(func(ctx context.Context, sink mono.Sink) {
+		go func() {
+			conn, err := p.await(ctx)
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			res, err := conn.RequestResponse(message).Block(ctx)
+			if err != nil {
+				sink.Error(err)
+			} else {
+				sink.Success(res)
+			}
+		}()
+	})
+}
+
+func (p *reconnectClientSocket) RequestStream(message payload.Payload) flux.Flux {
+	conn, err := p.acquire()
+	if err != nil {
+		return flux.Error(err)
+	}
+	if conn != nil {
+		return conn.RequestStream(message)
+	}
+	return p.deferFlux(func(conn *baseSocket) flux.Flux {
+		return conn.RequestStream(message)
+	})
+}
+
+func (p *reconnectClientSocket) RequestChannel(messages rx.Publisher) flux.Flux {
+	conn, err := p.acquire()
+	if err != nil {
+		return flux.Error(err)
+	}
+	if conn != nil {
+		return conn.RequestChannel(messages)
+	}
+	return p.deferFlux(func(conn *baseSocket) flux.Flux {
+		return conn.RequestChannel(messages)
+	})
+}
+
+// deferFlux queues a stream request until next connection is ready.
+func (p *reconnectClientSocket) deferFlux(request func(conn *baseSocket) flux.Flux) flux.Flux {
+	return flux.Create(func(ctx context.Context, sink flux.Sink) {
+		go func() {
+			conn, err := p.await(ctx)
+			if err != nil {
+				sink.Error(err)
+				return
+			}
+			request(conn).Subscribe(
+				ctx,
+				rx.OnNext(sink.Next),
+				rx.OnComplete(sink.Complete),
+				rx.OnError(sink.Error),
+			)
+		}()
+	})
+}
+
+// acquire returns current connection.
+// It returns nil connection without error if request should wait for next connection.
+func (p *reconnectClientSocket) acquire() (conn *baseSocket, err error) {
+	p.locker.Lock()
+	conn, err = p.conn, p.err
+	p.locker.Unlock()
+	if conn == nil && err == nil && p.policy.FailFast {
+		err = ErrReconnecting
+	}
+	return
+}
+
+// await blocks until a connection is ready or current client is closed.
+func (p *reconnectClientSocket) await(ctx context.Context) (*baseSocket, error) {
+	for {
+		p.locker.Lock()
+		conn, ready, err := p.conn, p.ready, p.err
+		p.locker.Unlock()
+		if conn != nil {
+			return conn, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ready:
+		}
+	}
+}
+
+func (p *reconnectClientSocket) connect(ctx context.Context) (err error) {
+	tp, err := p.dial(ctx)
+	if err != nil {
+		return
+	}
+	setupFrame := p.setup.toFrame()
+	sk := p.newSocket()
+	sk.SetSetup(setupFrame)
+	sk.SetResponder(p.newResponder())
+	conn := newBaseSocket(sk)
+
+	tp.SetMetrics(sk.metrics)
+	tp.SetLifetime(p.setup.KeepaliveLifetime)
+	if p.setup.Lease {
+		conn.refreshLease(0, 0)
+		tp.HandleLease(func(frame framing.Frame) (err error) {
+			lease := frame.(*framing.FrameLease)
+			conn.refreshLease(lease.TimeToLive(), int64(lease.NumberOfRequests()))
+			return
+		})
+	}
+	tp.HandleDisaster(func(frame framing.Frame) (err error) {
+		sk.SetError(frame.(*framing.FrameError))
+		return
+	})
+	sk.SetTransport(tp)
+
+	p.locker.Lock()
+	if err = p.err; err != nil {
+		p.locker.Unlock()
+		_ = tp.Close()
+		return
+	}
+	p.conn = conn
+	close(p.ready)
+	p.locker.Unlock()
+
+	go func(ctx context.Context, tp *transport.Transport) {
+		if err := tp.Start(ctx); err != nil {
+			logger.Warnf("client exit failed: %+v\n", err)
+		}
+		p.onLost(ctx, conn)
+	}(ctx, tp)
+	// SETUP must be the first frame, so send it before writing loop starts.
+	e := tp.Send(setupFrame, true)
+	go func(ctx context.Context) {
+		_ = sk.loopWrite(ctx)
+	}(ctx)
+	if e != nil {
+		// It will be handled as a disconnection, so don't return error here.
+		logger.Errorf("send setup frame failed: %s\n", e)
+		_ = tp.Close()
+		return
+	}
+	if p.policy.OnConnect != nil {
+		p.policy.OnConnect()
+	}
+	return
+}
+
+func (p *reconnectClientSocket) onLost(ctx context.Context, conn *baseSocket) {
+	err := conn.socket.abort(ErrConnectionLost)
+	p.locker.Lock()
+	if p.conn != conn || p.err != nil {
+		p.locker.Unlock()
+		return
+	}
+	p.conn = nil
+	p.ready = make(chan struct{})
+	p.locker.Unlock()
+
+	if p.policy.OnDisconnect != nil {
+		p.policy.OnDisconnect(err)
+	}
+	// Server will reject again if SETUP is invalid, so it's no use reconnecting.
+	if ce, ok := err.(common.CustomError); ok {
+		switch ce.ErrorCode() {
+		case common.ErrorCodeInvalidSetup, common.ErrorCodeUnsupportedSetup, common.ErrorCodeRejectedSetup:
+			p.shutdown(err)
+			return
+		}
+	}
+	go p.reconnect(ctx)
+}
+
+func (p *reconnectClientSocket) reconnect(ctx context.Context) {
+	for attempt := 1; p.policy.MaxAttempts < 1 || attempt <= p.policy.MaxAttempts; attempt++ {
+		timer := time.NewTimer(p.policy.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.shutdown(ctx.Err())
+			return
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		err := p.connect(ctx)
+		if err == nil {
+			return
+		}
+		logger.Warnf("reconnect failed: attempt=%d, err=%s\n", attempt, err)
+	}
+	p.shutdown(ErrConnectionLost)
+}
+
+// shutdown closes current client, all waiting requests will be failed.
+func (p *reconnectClientSocket) shutdown(err error) {
+	p.once.Do(func() {
+		if conn := p.markClosed(err); conn != nil {
+			_ = conn.socket.Close()
+		}
+		p.fireClosers(err)
+	})
+}
+
+func (p *reconnectClientSocket) markClosed(err error) (conn *baseSocket) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	conn = p.conn
+	if conn == nil {
+		// Wake up requests waiting for next connection.
+		close(p.ready)
+	}
+	p.conn = nil
+	p.err = err
+	close(p.done)
+	return
+}
+
+func (p *reconnectClientSocket) fireClosers(err error) {
+	for i, l := 0, len(p.closers); i < l; i++ {
+		func(fn func(error)) {
+			defer func() {
+				if e := tryRecover(recover()); e != nil {
+					logger.Errorf("handle socket closer failed: %s\n", e)
+				}
+			}()
+			fn(err)
+		}(p.closers[l-i-1])
+	}
+}
+
+// NewClientReconnect creates a client-side socket which reconnects with the policy after transport is lost.
+// A new DuplexRSocket and responder will be created for each connection.
+func NewClientReconnect(
+	dial transport.ClientTransportFunc,
+	policy *ReconnectPolicy,
+	newSocket func() *DuplexRSocket,
+	newResponder func() Responder,
+) ClientSocket {
+	return &reconnectClientSocket{
+		ready:        make(chan struct{}),
+		done:         make(chan struct{}),
+		dial:         dial,
+		policy:       policy,
+		newSocket:    newSocket,
+		newResponder: newResponder,
+	}
+}
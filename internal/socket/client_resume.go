@@ -2,9 +2,9 @@ package socket
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
-	"math"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rsocket/rsocket-go/internal/common"
@@ -14,19 +14,88 @@ import (
 	"go.uber.org/atomic"
 )
 
-const reconnectDelay = 1 * time.Second
+const (
+	defaultResumeDelay   = 1 * time.Second
+	defaultResumeTimeout = 10 * time.Second
+)
+
+var (
+	errResumeTimeout        = errors.New("resume timeout")
+	errUnresumableTransport = errors.New("resume is not supported by transport")
+)
+
+// ResumeEventType is type of resume event.
+type ResumeEventType int8
+
+const (
+	// ResumeAttempt means a new attempt of resuming starts.
+	ResumeAttempt ResumeEventType = iota
+	// ResumeSuccess means an attempt of resuming succeeds.
+	ResumeSuccess
+	// ResumeFailure means an attempt of resuming fails, next attempt may start later.
+	ResumeFailure
+	// ResumeGiveUp means no more attempt will start, client will be closed.
+	ResumeGiveUp
+)
+
+func (p ResumeEventType) String() string {
+	switch p {
+	case ResumeAttempt:
+		return "ATTEMPT"
+	case ResumeSuccess:
+		return "SUCCESS"
+	case ResumeFailure:
+		return "FAILURE"
+	case ResumeGiveUp:
+		return "GIVE_UP"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ResumeEvent represents an attempt or outcome of resuming.
+type ResumeEvent struct {
+	// Type is type of event.
+	Type ResumeEventType
+	// Attempt is the number of attempts since connection was lost, it starts from 1.
+	Attempt int
+	// Elapsed is the duration since connection was lost.
+	Elapsed time.Duration
+	// Err is the reason of failure.
+	Err error
+}
+
+func (p ResumeEvent) String() string {
+	return fmt.Sprintf("ResumeEvent{type=%s,attempt=%d,elapsed=%s,err=%v}", p.Type, p.Attempt, p.Elapsed, p.Err)
+}
+
+// ResumePolicy controls how a resume client reconnects after its transport is lost.
+type ResumePolicy struct {
+	// Backoff returns the delay before n-th attempt, n starts from 1.
+	Backoff func(attempt int) time.Duration
+	// MaxAttempts is the max number of attempts after each disconnection, zero means no limit.
+	MaxAttempts int
+	// Window is the max duration of resuming after each disconnection, zero means no limit.
+	Window time.Duration
+	// Timeout is the max duration of waiting for RESUME_OK.
+	Timeout time.Duration
+	// OnEvent is invoked with attempts and outcomes of resuming.
+	OnEvent func(event ResumeEvent)
+}
 
 type resumeClientSocket struct {
 	*baseSocket
-	connects *atomic.Int32
-	uri      *transport.URI
-	headers  map[string][]string
-	setup    *SetupInfo
-	tc       *tls.Config
+	closing *atomic.Bool
+	locker  sync.Mutex
+	active  *transport.Transport
+	dial    transport.ClientTransportFunc
+	setup   *SetupInfo
+	policy  *ResumePolicy
 }
 
 func (p *resumeClientSocket) Setup(ctx context.Context, setup *SetupInfo) error {
 	p.setup = setup
+	p.socket.SetSetup(setup.toFrame())
 	go func(ctx context.Context) {
 		_ = p.socket.loopWrite(ctx)
 	}(ctx)
@@ -44,67 +113,138 @@ func (p *resumeClientSocket) Close() (err error) {
 	return
 }
 
+// connect connects server with SETUP at first time.
 func (p *resumeClientSocket) connect(ctx context.Context) (err error) {
-	connects := p.connects.Inc()
-	if connects < 0 {
-		_ = p.Close()
+	tp, err := p.dial(ctx)
+	if err != nil {
 		return
 	}
-	tp, err := p.uri.MakeClientTransport(p.tc, p.headers)
-	if err != nil {
-		if connects == 1 {
-			return
-		}
-		time.Sleep(reconnectDelay)
-		_ = p.connect(ctx)
+	if !tp.Resumable() {
+		_ = tp.Close()
+		err = errUnresumableTransport
 		return
 	}
-	tp.Connection().SetCounter(p.socket.counter)
+	tp.SetMetrics(p.socket.metrics)
 	tp.SetLifetime(p.setup.KeepaliveLifetime)
+	tp.HandleDisaster(func(frame framing.Frame) (err error) {
+		p.socket.SetError(frame.(*framing.FrameError))
+		p.markClosing()
+		return
+	})
+	p.activate(tp)
+	go p.serve(ctx, tp)
+
+	err = tp.Send(p.setup.toFrame(), true)
+	p.socket.SetTransport(tp)
+	return
+}
+
+// serve reads frames from transport, it starts resuming if an active transport is lost.
+func (p *resumeClientSocket) serve(ctx context.Context, tp *transport.Transport) {
+	if err := tp.Start(ctx); err != nil {
+		logger.Errorf("client exit: %s\n", err)
+	}
+	p.locker.Lock()
+	lost := p.active == tp
+	if lost {
+		p.active = nil
+	}
+	p.locker.Unlock()
+	// Transport of a failed resume attempt has nothing to do.
+	if !lost {
+		return
+	}
+	p.socket.clearTransport()
+	if p.isClosed() {
+		_ = p.Close()
+		return
+	}
+	p.resume(ctx)
+}
 
-	go func(ctx context.Context, tp *transport.Transport) {
-		defer func() {
-			p.socket.clearTransport()
-			if p.isClosed() {
-				_ = p.Close()
-				return
-			}
-			time.Sleep(reconnectDelay)
-			_ = p.connect(ctx)
-		}()
-		err := tp.Start(ctx)
-		if err != nil {
-			logger.Errorf("client exit: %s\n", err)
+func (p *resumeClientSocket) activate(tp *transport.Transport) {
+	p.locker.Lock()
+	p.active = tp
+	p.locker.Unlock()
+}
+
+// resume tries to resume until success, or gives up according to the policy.
+func (p *resumeClientSocket) resume(ctx context.Context) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		delay := p.policy.Backoff(attempt)
+		var giveUp error
+		if p.policy.MaxAttempts > 0 && attempt > p.policy.MaxAttempts {
+			giveUp = fmt.Errorf("resume attempts exceed %d", p.policy.MaxAttempts)
+		} else if p.policy.Window > 0 && time.Since(start)+delay > p.policy.Window {
+			giveUp = fmt.Errorf("resume window %s exceeded", p.policy.Window)
+		}
+		if giveUp != nil {
+			p.emit(ResumeEvent{Type: ResumeGiveUp, Attempt: attempt - 1, Elapsed: time.Since(start), Err: giveUp})
+			_ = p.Close()
+			return
 		}
-	}(ctx, tp)
 
-	var f framing.Frame
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.emit(ResumeEvent{Type: ResumeGiveUp, Attempt: attempt - 1, Elapsed: time.Since(start), Err: ctx.Err()})
+			_ = p.Close()
+			return
+		case <-timer.C:
+		}
+		if p.isClosed() {
+			_ = p.Close()
+			return
+		}
 
-	// connect first time.
-	if len(p.setup.Token) < 1 || connects == 1 {
-		tp.HandleDisaster(func(frame framing.Frame) (err error) {
-			p.socket.SetError(frame.(*framing.FrameError))
-			p.markClosing()
+		p.emit(ResumeEvent{Type: ResumeAttempt, Attempt: attempt, Elapsed: time.Since(start)})
+		err := p.resumeOnce(ctx)
+		if err == nil {
+			p.emit(ResumeEvent{Type: ResumeSuccess, Attempt: attempt, Elapsed: time.Since(start)})
 			return
-		})
+		}
+		p.emit(ResumeEvent{Type: ResumeFailure, Attempt: attempt, Elapsed: time.Since(start), Err: err})
+		logger.Warnf("resume failed: attempt=%d, err=%s\n", attempt, err)
+		// Server rejected resuming, so it's no use trying again.
+		if p.isClosed() {
+			p.emit(ResumeEvent{Type: ResumeGiveUp, Attempt: attempt, Elapsed: time.Since(start), Err: err})
+			_ = p.Close()
+			return
+		}
+	}
+}
 
-		f = p.setup.toFrame()
-		err = tp.Send(f, true)
-		p.socket.SetTransport(tp)
+// resumeOnce dials server and sends RESUME, then waits for RESUME_OK.
+func (p *resumeClientSocket) resumeOnce(ctx context.Context) (err error) {
+	tp, err := p.dial(ctx)
+	if err != nil {
 		return
 	}
+	tp.SetMetrics(p.socket.metrics)
+	tp.SetLifetime(p.setup.KeepaliveLifetime)
 
-	f = framing.NewFrameResume(
-		common.DefaultVersion,
-		p.setup.Token,
-		p.socket.counter.WriteBytes(),
-		p.socket.counter.ReadBytes(),
-	)
-
-	resumeErr := make(chan string)
+	// 0: waiting, 1: RESUME_OK received, 2: timeout
+	state := atomic.NewInt32(0)
+	resumeOK := make(chan struct{})
+	resumeErr := make(chan string, 1)
+	resumeLost := make(chan error, 1)
 
 	tp.HandleResumeOK(func(frame framing.Frame) (err error) {
-		close(resumeErr)
+		if !state.CAS(0, 1) {
+			return
+		}
+		// Bind transport before delivering next frame, frames retransmitted by server will follow RESUME_OK.
+		position := frame.(*framing.FrameResumeOK).LastReceivedClientPosition()
+		if err = p.socket.ResumeFrom(position); err != nil {
+			// Server has lost some frames which are not retained any more.
+			resumeLost <- err
+			return
+		}
+		p.activate(tp)
+		p.socket.SetTransport(tp)
+		close(resumeOK)
 		return
 	})
 
@@ -113,48 +253,103 @@ func (p *resumeClientSocket) connect(ctx context.Context) (err error) {
 		f := frame.(*framing.FrameError)
 		if f.ErrorCode() == common.ErrorCodeRejectedResume {
 			resumeErr <- f.Error()
-			close(resumeErr)
 		}
 		return
 	})
 
-	err = tp.Send(f, true)
-	if err != nil {
-		return err
+	f := framing.NewFrameResume(
+		common.DefaultVersion,
+		p.setup.Token,
+		p.socket.FirstAvailablePosition(),
+		p.socket.counter.ReadBytes(),
+	)
+	if err = tp.Send(f, true); err != nil {
+		_ = tp.Close()
+		return
 	}
+	// Start reading after RESUME is sent, transport will be written by socket once RESUME_OK is received.
+	go p.serve(ctx, tp)
 
-	ctx2, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+	timer := time.NewTimer(p.policy.Timeout)
+	defer timer.Stop()
 
 	select {
-	case <-ctx2.Done():
-		err = ctx2.Err()
-	case reject, ok := <-resumeErr:
-		if ok {
-			err = errors.New(reject)
-			p.markClosing()
-		} else {
-			p.socket.SetTransport(tp)
+	case <-ctx.Done():
+		err = ctx.Err()
+		p.markClosing()
+		_ = tp.Close()
+	case <-timer.C:
+		if state.CAS(0, 2) {
+			err = errResumeTimeout
+			_ = tp.Close()
+			break
+		}
+		// RESUME_OK is arriving.
+		select {
+		case err = <-resumeLost:
+			p.abandon(tp, err)
+		case <-resumeOK:
 		}
+	case reject := <-resumeErr:
+		err = errors.New(reject)
+		p.markClosing()
+		_ = tp.Close()
+	case err = <-resumeLost:
+		p.abandon(tp, err)
+	case <-resumeOK:
 	}
 	return
 }
 
+// abandon rejects resuming because frames required by server are not retained any more.
+func (p *resumeClientSocket) abandon(tp *transport.Transport, err error) {
+	p.markClosing()
+	_ = tp.Send(framing.NewFrameError(0, common.ErrorCodeRejectedResume, []byte(err.Error())), true)
+	_ = tp.Close()
+}
+
+func (p *resumeClientSocket) emit(event ResumeEvent) {
+	if p.policy.OnEvent == nil {
+		return
+	}
+	defer func() {
+		if e := tryRecover(recover()); e != nil {
+			logger.Errorf("handle resume event failed: %s\n", e)
+		}
+	}()
+	p.policy.OnEvent(event)
+}
+
 func (p *resumeClientSocket) markClosing() {
-	p.connects.Store(math.MinInt32)
+	p.closing.Store(true)
 }
 
 func (p *resumeClientSocket) isClosed() bool {
-	return p.connects.Load() < 0
+	return p.closing.Load()
 }
 
 // NewClientResume creates a client-side socket with resume support.
-func NewClientResume(uri *transport.URI, socket *DuplexRSocket, tc *tls.Config, headers map[string][]string) ClientSocket {
+func NewClientResume(
+	dial transport.ClientTransportFunc,
+	socket *DuplexRSocket,
+	policy *ResumePolicy,
+) ClientSocket {
+	socket.enableResume()
+	if policy == nil {
+		policy = &ResumePolicy{}
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = func(int) time.Duration {
+			return defaultResumeDelay
+		}
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = defaultResumeTimeout
+	}
 	return &resumeClientSocket{
 		baseSocket: newBaseSocket(socket),
-		uri:        uri,
-		tc:         tc,
-		headers:    headers,
-		connects:   atomic.NewInt32(0),
+		dial:       dial,
+		closing:    atomic.NewBool(false),
+		policy:     policy,
 	}
 }
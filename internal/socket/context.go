@@ -0,0 +1,105 @@
+package socket
+
+import (
+	"context"
+	"net"
+
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+)
+
+type contextKey int
+
+const (
+	connectionKey contextKey = iota
+	principalKey
+)
+
+// Connection represents the connection which a request is received from, it can be used to send requests to peer.
+type Connection interface {
+	Closeable
+	Responder
+}
+
+type connectionInfo struct {
+	conn       Connection
+	setup      payload.SetupPayload
+	remoteAddr net.Addr
+}
+
+// requestPayload is an incoming payload which carries context of its stream.
+type requestPayload struct {
+	payload.MimeTypeAware
+	ctx context.Context
+}
+
+func (p *requestPayload) Context() context.Context {
+	return p.ctx
+}
+
+// follow binds MIME types and context of current payload to a following payload of the same stream.
+func (p *requestPayload) follow(msg payload.Payload) *requestPayload {
+	return &requestPayload{
+		MimeTypeAware: payload.WithMimeTypes(msg, p.DataMimeType(), p.AcceptMimeTypes()...),
+		ctx:           p.ctx,
+	}
+}
+
+// requestPublisher is incoming payloads of a channel which carries context of its stream.
+type requestPublisher struct {
+	flux.Flux
+	ctx context.Context
+}
+
+func (p *requestPublisher) Context() context.Context {
+	return p.ctx
+}
+
+// ContextOf returns context of an incoming payload or publisher, it returns context.Background() if there's no context.
+func ContextOf(v interface{}) context.Context {
+	if it, ok := v.(interface{ Context() context.Context }); ok {
+		return it.Context()
+	}
+	return context.Background()
+}
+
+// ConnectionFromContext returns the connection which the request is received from.
+func ConnectionFromContext(ctx context.Context) (conn Connection, ok bool) {
+	info, ok := ctx.Value(connectionKey).(*connectionInfo)
+	if ok && info.conn != nil {
+		return info.conn, true
+	}
+	return nil, false
+}
+
+// SetupFromContext returns the SETUP payload of connection which the request is received from.
+func SetupFromContext(ctx context.Context) (setup payload.SetupPayload, ok bool) {
+	info, ok := ctx.Value(connectionKey).(*connectionInfo)
+	if ok && info.setup != nil {
+		return info.setup, true
+	}
+	return nil, false
+}
+
+// RemoteAddrFromContext returns the remote address of connection which the request is received from.
+func RemoteAddrFromContext(ctx context.Context) (addr net.Addr, ok bool) {
+	info, ok := ctx.Value(connectionKey).(*connectionInfo)
+	if ok && info.remoteAddr != nil {
+		return info.remoteAddr, true
+	}
+	return nil, false
+}
+
+// PrincipalFromContext returns the principal of an authenticated request.
+func PrincipalFromContext(ctx context.Context) (principal interface{}, ok bool) {
+	v, ok := ctx.Value(principalKey).(*authenticated)
+	if !ok {
+		return nil, false
+	}
+	return v.principal, true
+}
+
+// authenticated wraps principal, so that a nil principal can be distinguished from an unauthenticated request.
+type authenticated struct {
+	principal interface{}
+}
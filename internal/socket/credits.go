@@ -0,0 +1,114 @@
+package socket
+
+import (
+	"sync"
+
+	"github.com/rsocket/rsocket-go/rx"
+)
+
+// credits tracks the amount of payloads which peer is allowed to send for a stream.
+// Credits are granted by REQUEST_N and consumed by every PAYLOAD with NEXT flag.
+type credits struct {
+	mu        sync.Mutex
+	n         int
+	unbounded bool
+}
+
+// grant adds n credits, requesting rx.RequestMax makes it unbounded.
+func (c *credits) grant(n int) {
+	if n < 1 {
+		return
+	}
+	c.mu.Lock()
+	if n >= rx.RequestMax || c.n >= rx.RequestMax-n {
+		c.unbounded = true
+	} else {
+		c.n += n
+	}
+	c.mu.Unlock()
+}
+
+// consume takes one credit, it returns false if peer exceeds the granted credits.
+func (c *credits) consume() (ok bool) {
+	c.mu.Lock()
+	if c.unbounded {
+		ok = true
+	} else if c.n > 0 {
+		c.n--
+		ok = true
+	}
+	c.mu.Unlock()
+	return
+}
+
+func newCredits(n int) *credits {
+	c := &credits{}
+	c.grant(n)
+	return c
+}
+
+// limiter splits demand of a subscriber into requests of at most prefetch credits.
+// Like limitRate of Reactor, requests are replenished when 75% of prefetch has been received.
+type limiter struct {
+	mu        sync.Mutex
+	prefetch  int
+	lowTide   int
+	pending   int
+	unbounded bool
+	inflight  int
+}
+
+// request adds demand of subscriber, it returns the amount which should be requested from peer.
+func (l *limiter) request(n int) int {
+	if n < 1 {
+		return 0
+	}
+	if l.prefetch < 1 {
+		return n
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n >= rx.RequestMax || l.pending >= rx.RequestMax-n {
+		l.unbounded = true
+	} else {
+		l.pending += n
+	}
+	return l.fill()
+}
+
+// received marks a payload as received, it returns the amount which should be replenished.
+func (l *limiter) received() int {
+	if l.prefetch < 1 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight > 0 {
+		l.inflight--
+	}
+	return l.fill()
+}
+
+func (l *limiter) fill() (n int) {
+	n = l.prefetch - l.inflight
+	// Avoid sending a REQUEST_N for every payload.
+	if l.inflight > 0 && n < l.lowTide {
+		return 0
+	}
+	if !l.unbounded {
+		if l.pending < n {
+			n = l.pending
+		}
+		l.pending -= n
+	}
+	l.inflight += n
+	return
+}
+
+// newLimiter returns a limiter, a non-positive prefetch makes it pass demand through.
+func newLimiter(prefetch int) *limiter {
+	return &limiter{
+		prefetch: prefetch,
+		lowTide:  prefetch - prefetch>>2,
+	}
+}
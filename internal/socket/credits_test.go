@@ -0,0 +1,49 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredits(t *testing.T) {
+	c := newCredits(2)
+	assert.True(t, c.consume())
+	assert.True(t, c.consume())
+	assert.False(t, c.consume(), "should exceed credits")
+
+	c.grant(1)
+	assert.True(t, c.consume())
+	assert.False(t, c.consume(), "should exceed credits")
+
+	c.grant(rx.RequestMax - 1)
+	c.grant(2)
+	for i := 0; i < 1000; i++ {
+		assert.True(t, c.consume(), "should be unbounded after overflow")
+	}
+
+	c = newCredits(rx.RequestMax)
+	assert.True(t, c.consume(), "should be unbounded")
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(0)
+	assert.Equal(t, rx.RequestMax, l.request(rx.RequestMax), "should pass through")
+	assert.Equal(t, 0, l.received())
+
+	l = newLimiter(8)
+	assert.Equal(t, 8, l.request(rx.RequestMax))
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, 0, l.received(), "should wait for low tide")
+	}
+	assert.Equal(t, 6, l.received(), "should replenish after 75% received")
+
+	l = newLimiter(4)
+	assert.Equal(t, 2, l.request(2))
+	assert.Equal(t, 0, l.request(1), "should wait for low tide")
+	assert.Equal(t, 1, l.received(), "pending demand should be requested")
+	assert.Equal(t, 0, l.received())
+	assert.Equal(t, 0, l.received(), "no demand")
+	assert.Equal(t, 3, l.request(3))
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/fragmentation"
 	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/instrument"
 	"github.com/rsocket/rsocket-go/internal/transport"
 	"github.com/rsocket/rsocket-go/lease"
 	"github.com/rsocket/rsocket-go/logger"
@@ -27,9 +29,12 @@ const outsSize = 64
 
 var (
 	errSocketClosed            = errors.New("socket closed already")
+	errResumeDisabled          = errors.New("resume is disabled")
 	unsupportedRequestStream   = []byte("Request-Stream not implemented.")
 	unsupportedRequestResponse = []byte("Request-Response not implemented.")
 	unsupportedRequestChannel  = []byte("Request-Channel not implemented.")
+	rejectedGoingAway          = []byte("socket is going away")
+	errExcessPayloads          = []byte("payloads exceed requested credits")
 )
 
 // DuplexRSocket represents a socket of RSocket which can be a requester or a responder.
@@ -38,18 +43,31 @@ type DuplexRSocket struct {
 	tp              *transport.Transport
 	outs            chan framing.Frame
 	outsPriority    []framing.Frame
+	outsReplay      []framing.Frame
+	frames          *frameStore
 	responder       Responder
 	messages        *u32map
 	sids            streamIDs
 	mtu             int
 	fragments       *u32map // key=streamID, value=Joiner
 	closed          *atomic.Bool
+	draining        *atomic.Bool
 	done            chan struct{}
 	keepaliver      *keepaliver
 	cond            *sync.Cond
 	singleScheduler scheduler.Scheduler
 	e               error
 	leases          lease.Leases
+	interceptors    interceptors
+	authenticate    func(msg payload.Payload) (principal interface{}, err error)
+	conn            Connection
+	ctx             context.Context
+	cancelCtx       context.CancelFunc
+	setup           payload.SetupPayload
+	metrics         *instrument.Recorder
+	keepaliveSentAt *atomic.Int64
+	prefetch        int
+	extensions      *Extensions
 }
 
 // SetError sets error for current socket.
@@ -77,6 +95,7 @@ func (p *DuplexRSocket) Close() error {
 	if !p.closed.CAS(false, true) {
 		return nil
 	}
+	p.cancelCtx()
 	if p.keepaliver != nil {
 		p.keepaliver.Stop()
 	}
@@ -89,11 +108,11 @@ func (p *DuplexRSocket) Close() error {
 	_ = p.fragments.Close()
 	<-p.done
 
-	if p.tp != nil {
+	if tp := p.currentTransport(); tp != nil {
 		if p.e == nil {
-			p.e = p.tp.Close()
+			p.e = tp.Close()
 		} else {
-			_ = p.tp.Close()
+			_ = tp.Close()
 		}
 	}
 
@@ -116,19 +135,236 @@ func (p *DuplexRSocket) Close() error {
 		}
 		return true
 	})
-	_ = p.messages.Close()
+	p.metrics.StreamsActive(-p.messages.Clear())
 	return p.e
 }
 
+// GoAway tells peer to stop sending new requests, new requests from peer will be rejected after that.
+// It sends a LEASE with zero requests if lease is enabled, otherwise it sends a METADATA_PUSH with given metadata.
+func (p *DuplexRSocket) GoAway(ttl time.Duration, metadata []byte) {
+	if !p.draining.CAS(false, true) {
+		return
+	}
+	if p.leases != nil {
+		p.sendFrame(framing.NewFrameLease(ttl, 0, nil))
+	} else {
+		p.sendFrame(framing.NewFrameMetadataPush(metadata))
+	}
+}
+
+// InFlight returns the number of streams which are not finished yet.
+func (p *DuplexRSocket) InFlight() int {
+	return p.messages.Len()
+}
+
+// CloseWithError sends an ERROR frame with zero StreamID to peer, then closes current socket.
+func (p *DuplexRSocket) CloseWithError(code common.ErrorCode, data []byte) error {
+	if p.closed.Load() {
+		return nil
+	}
+	p.sendFrame(framing.NewFrameError(0, code, data))
+	return p.Close()
+}
+
+// abort closes current socket because transport is lost.
+// Requests in flight will be failed with given error unless there's an error from peer already.
+func (p *DuplexRSocket) abort(e error) error {
+	if p.closed.Load() {
+		return nil
+	}
+	if p.e == nil {
+		p.e = e
+	}
+	return p.Close()
+}
+
+// Intercept appends interceptors which wrap requests of both sides, they will be invoked in order.
+func (p *DuplexRSocket) Intercept(interceptors ...Interceptor) {
+	p.interceptors = append(p.interceptors, interceptors...)
+}
+
+// SetSetup sets the SETUP payload of connection, it will be exposed to interceptors.
+func (p *DuplexRSocket) SetSetup(setup payload.SetupPayload) {
+	p.setup = setup
+}
+
+// SetMetrics binds a metrics recorder for current socket.
+func (p *DuplexRSocket) SetMetrics(recorder *instrument.Recorder) {
+	p.metrics = recorder
+}
+
+// SetPrefetch sets the max credits requested from peer by a request stream or request channel at a time.
+// Demand of subscribers will be requested in batches, zero means requesting demand as it is.
+func (p *DuplexRSocket) SetPrefetch(prefetch int) {
+	p.prefetch = prefetch
+}
+
+// SetExtensions binds a registry of handlers for EXT frames from peer.
+func (p *DuplexRSocket) SetExtensions(extensions *Extensions) {
+	p.extensions = extensions
+}
+
+// SendExtension sends an EXT frame of extended type to peer.
+// Peer skips it if canIgnore is true and the extended type is not understood.
+func (p *DuplexRSocket) SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool) {
+	var fg framing.FrameFlag
+	if canIgnore {
+		fg = framing.FlagIgnore
+	}
+	metadata, _ := msg.Metadata()
+	p.sendFrame(framing.NewFrameExt(0, extendedType, msg.Data(), metadata, fg))
+}
+
+func (p *DuplexRSocket) newCall(responder bool, sid uint32) *Call {
+	return &Call{
+		Responder:  responder,
+		StreamID:   sid,
+		Setup:      p.setup,
+		RemoteAddr: p.remoteAddr(),
+		Connection: p.conn,
+	}
+}
+
+func (p *DuplexRSocket) remoteAddr() net.Addr {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	if p.tp == nil {
+		return nil
+	}
+	return p.tp.Connection().RemoteAddr()
+}
+
+// Authenticate sets a function which authenticates incoming requests, requests will be rejected if it returns error.
+// Principal returned by it will be exposed by context of requests passed to responder.
+func (p *DuplexRSocket) Authenticate(fn func(msg payload.Payload) (principal interface{}, err error)) {
+	p.authenticate = fn
+}
+
+// newContext returns context for a request from peer, it carries information of current connection.
+// It will be cancelled when current socket is closed.
+func (p *DuplexRSocket) newContext() context.Context {
+	info := &connectionInfo{
+		conn:       p.conn,
+		setup:      p.setup,
+		remoteAddr: p.remoteAddr(),
+	}
+	return context.WithValue(p.ctx, connectionKey, info)
+}
+
+// resolveMimeTypes makes incoming payload MimeTypeAware, so responders can choose encoding by its MIME types.
+func (p *DuplexRSocket) resolveMimeTypes(msg payload.Payload) payload.MimeTypeAware {
+	if p.setup == nil {
+		return payload.WithMimeTypes(msg, "")
+	}
+	return payload.ResolveMimeTypes(p.setup, msg)
+}
+
+// prepare resolves MIME types and principal of incoming payload, then binds ctx to it.
+func (p *DuplexRSocket) prepare(ctx context.Context, msg payload.Payload) (*requestPayload, error) {
+	resolved := p.resolveMimeTypes(msg)
+	if p.authenticate != nil {
+		principal, err := p.authenticate(resolved)
+		if err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, principalKey, &authenticated{principal: principal})
+	}
+	return &requestPayload{MimeTypeAware: resolved, ctx: ctx}, nil
+}
+
+func (p *DuplexRSocket) handleMetadataPush(ctx context.Context, msg payload.Payload) {
+	prepared, err := p.prepare(ctx, msg)
+	if err != nil {
+		logger.Warnf("drop unauthenticated METADATA_PUSH: %s\n", err)
+		return
+	}
+	p.responder.MetadataPush(prepared)
+}
+
+func (p *DuplexRSocket) handleFireAndForget(ctx context.Context, msg payload.Payload) {
+	prepared, err := p.prepare(ctx, msg)
+	if err != nil {
+		logger.Warnf("drop unauthenticated FireAndForget: %s\n", err)
+		return
+	}
+	p.responder.FireAndForget(prepared)
+}
+
+func (p *DuplexRSocket) handleRequestResponse(ctx context.Context, msg payload.Payload) mono.Mono {
+	prepared, err := p.prepare(ctx, msg)
+	if err != nil {
+		return mono.Error(err)
+	}
+	return p.responder.RequestResponse(prepared)
+}
+
+func (p *DuplexRSocket) handleRequestStream(ctx context.Context, msg payload.Payload) flux.Flux {
+	prepared, err := p.prepare(ctx, msg)
+	if err != nil {
+		return flux.Error(err)
+	}
+	return p.responder.RequestStream(prepared)
+}
+
+// handleRequestChannel prepares the first payload, following payloads of stream share its MIME types and context.
+func (p *DuplexRSocket) handleRequestChannel(ctx context.Context, msgs rx.Publisher) flux.Flux {
+	inputs, ok := msgs.(flux.Flux)
+	if !ok {
+		return p.responder.RequestChannel(msgs)
+	}
+	if p.authenticate == nil {
+		var first *requestPayload
+		return p.responder.RequestChannel(&requestPublisher{
+			Flux: inputs.Map(func(msg payload.Payload) payload.Payload {
+				if first == nil {
+					first, _ = p.prepare(ctx, msg)
+					return first
+				}
+				return first.follow(msg)
+			}),
+			ctx: ctx,
+		})
+	}
+	// Reject the request before subscribing the responder if the first payload is not authenticated.
+	return inputs.SwitchOnFirst(func(s flux.Signal, f flux.Flux) flux.Flux {
+		raw, ok := s.Value()
+		if !ok {
+			return f
+		}
+		first, err := p.prepare(ctx, raw)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return p.responder.RequestChannel(&requestPublisher{
+			Flux: f.Map(func(msg payload.Payload) payload.Payload {
+				if msg == raw {
+					return first
+				}
+				return first.follow(msg)
+			}),
+			ctx: first.ctx,
+		})
+	})
+}
+
 // FireAndForget start a request of FireAndForget.
 func (p *DuplexRSocket) FireAndForget(sending payload.Payload) {
+	call := p.newCall(false, 0)
+	p.interceptors.fireAndForget(call, sending, func(sending payload.Payload) {
+		call.StreamID = p.nextStreamID()
+		p.fireAndForget(call.StreamID, sending)
+	})
+}
+
+func (p *DuplexRSocket) fireAndForget(sid uint32, sending payload.Payload) {
+	p.metrics.RequestStarted(false, framing.FrameTypeRequestFNF)
+	p.metrics.RequestFinished(false, framing.FrameTypeRequestFNF, rx.SignalComplete)
 	data := sending.Data()
 	size := framing.HeaderLen + len(sending.Data())
 	m, ok := sending.Metadata()
 	if ok {
 		size += 3 + len(m)
 	}
-	sid := p.nextStreamID()
 	if !p.shouldSplit(size) {
 		p.sendFrame(framing.NewFrameFNF(sid, data, m))
 		return
@@ -151,14 +387,25 @@ func (p *DuplexRSocket) FireAndForget(sending payload.Payload) {
 }
 
 // MetadataPush start a request of MetadataPush.
-func (p *DuplexRSocket) MetadataPush(payload payload.Payload) {
-	metadata, _ := payload.Metadata()
-	p.sendFrame(framing.NewFrameMetadataPush(metadata))
+func (p *DuplexRSocket) MetadataPush(sending payload.Payload) {
+	p.interceptors.metadataPush(p.newCall(false, 0), sending, func(sending payload.Payload) {
+		p.metrics.RequestStarted(false, framing.FrameTypeMetadataPush)
+		p.metrics.RequestFinished(false, framing.FrameTypeMetadataPush, rx.SignalComplete)
+		metadata, _ := sending.Metadata()
+		p.sendFrame(framing.NewFrameMetadataPush(metadata))
+	})
 }
 
 // RequestResponse start a request of RequestResponse.
-func (p *DuplexRSocket) RequestResponse(pl payload.Payload) (mo mono.Mono) {
-	sid := p.nextStreamID()
+func (p *DuplexRSocket) RequestResponse(pl payload.Payload) mono.Mono {
+	call := p.newCall(false, 0)
+	return p.interceptors.requestResponse(call, pl, func(pl payload.Payload) mono.Mono {
+		call.StreamID = p.nextStreamID()
+		return p.requestResponse(call.StreamID, pl)
+	})
+}
+
+func (p *DuplexRSocket) requestResponse(sid uint32, pl payload.Payload) (mo mono.Mono) {
 	resp := mono.CreateProcessor()
 
 	p.register(sid, reqRR{pc: resp})
@@ -171,9 +418,11 @@ func (p *DuplexRSocket) RequestResponse(pl payload.Payload) (mo mono.Mono) {
 				p.sendFrame(framing.NewFrameCancel(sid))
 			}
 			p.unregister(sid)
+			p.metrics.RequestFinished(false, framing.FrameTypeRequestResponse, s)
 		})
 
 	p.singleScheduler.Worker().Do(func() {
+		p.metrics.RequestStarted(false, framing.FrameTypeRequestResponse)
 		// sending...
 		size := framing.CalcPayloadFrameSize(data, metadata)
 		if !p.shouldSplit(size) {
@@ -200,11 +449,20 @@ func (p *DuplexRSocket) RequestResponse(pl payload.Payload) (mo mono.Mono) {
 }
 
 // RequestStream start a request of RequestStream.
-func (p *DuplexRSocket) RequestStream(sending payload.Payload) (ret flux.Flux) {
-	sid := p.nextStreamID()
+func (p *DuplexRSocket) RequestStream(sending payload.Payload) flux.Flux {
+	call := p.newCall(false, 0)
+	return p.interceptors.requestStream(call, sending, func(sending payload.Payload) flux.Flux {
+		call.StreamID = p.nextStreamID()
+		return p.requestStream(call.StreamID, sending)
+	})
+}
+
+func (p *DuplexRSocket) requestStream(sid uint32, sending payload.Payload) (ret flux.Flux) {
 	pc := flux.CreateProcessor()
+	inbound := newCredits(0)
+	limit := newLimiter(p.prefetch)
 
-	p.register(sid, reqRS{pc: pc})
+	p.register(sid, reqRS{pc: pc, credits: inbound, limiter: limit})
 
 	requested := make(chan struct{})
 
@@ -214,8 +472,15 @@ func (p *DuplexRSocket) RequestStream(sending payload.Payload) (ret flux.Flux) {
 				p.sendFrame(framing.NewFrameCancel(sid))
 			}
 			p.unregister(sid)
+			p.metrics.RequestFinished(false, framing.FrameTypeRequestStream, sig)
 		}).
 		DoOnRequest(func(n int) {
+			n = limit.request(n)
+			if n < 1 {
+				return
+			}
+			// Credits must be granted before peer knows them.
+			inbound.grant(n)
 			n32 := toU32N(n)
 
 			var newborn bool
@@ -233,6 +498,7 @@ func (p *DuplexRSocket) RequestStream(sending payload.Payload) (ret flux.Flux) {
 				return
 			}
 
+			p.metrics.RequestStarted(false, framing.FrameTypeRequestStream)
 			data := sending.Data()
 			metadata, _ := sending.Metadata()
 
@@ -263,19 +529,48 @@ func (p *DuplexRSocket) RequestStream(sending payload.Payload) (ret flux.Flux) {
 }
 
 // RequestChannel start a request of RequestChannel.
-func (p *DuplexRSocket) RequestChannel(publisher rx.Publisher) (ret flux.Flux) {
-	sid := p.nextStreamID()
+func (p *DuplexRSocket) RequestChannel(publisher rx.Publisher) flux.Flux {
+	call := p.newCall(false, 0)
+	return p.interceptors.requestChannel(call, publisher, func(publisher rx.Publisher) flux.Flux {
+		call.StreamID = p.nextStreamID()
+		return p.requestChannel(call.StreamID, publisher)
+	})
+}
 
+func (p *DuplexRSocket) requestChannel(sid uint32, publisher rx.Publisher) (ret flux.Flux) {
 	sending := publisher.(flux.Flux)
 	receiving := flux.CreateProcessor()
 
 	rcvRequested := make(chan struct{})
+	closeHalf := p.newChannelHalves(sid)
+	inbound := newCredits(0)
+	limit := newLimiter(p.prefetch)
 
 	ret = receiving.
 		DoFinally(func(sig rx.SignalType) {
-			p.unregister(sid)
+			p.metrics.RequestFinished(false, framing.FrameTypeRequestChannel, sig)
+			select {
+			case <-rcvRequested:
+			default:
+				// Nothing has been sent to peer.
+				return
+			}
+			// Cancelling receiving cancels the whole channel.
+			if sig == rx.SignalCancel {
+				if v, ok := p.messages.Load(sid); ok {
+					p.sendFrame(framing.NewFrameCancel(sid))
+					v.(reqRC).snd.Cancel()
+				}
+			}
+			closeHalf()
 		}).
 		DoOnRequest(func(n int) {
+			n = limit.request(n)
+			if n < 1 {
+				return
+			}
+			// Credits must be granted before peer knows them.
+			inbound.grant(n)
 			n32 := toU32N(n)
 			var newborn bool
 			select {
@@ -291,6 +586,7 @@ func (p *DuplexRSocket) RequestChannel(publisher rx.Publisher) (ret flux.Flux) {
 				return
 			}
 
+			p.metrics.RequestStarted(false, framing.FrameTypeRequestChannel)
 			sndRequested := make(chan struct{})
 			sub := rx.NewSubscriber(
 				rx.OnNext(func(item payload.Payload) {
@@ -333,21 +629,26 @@ func (p *DuplexRSocket) RequestChannel(publisher rx.Publisher) (ret flux.Flux) {
 					})
 				}),
 				rx.OnSubscribe(func(s rx.Subscription) {
-					p.register(sid, reqRC{rcv: receiving, snd: s})
+					p.register(sid, reqRC{rcv: receiving, snd: s, credits: inbound, limiter: limit})
 					s.Request(1)
 				}),
+				rx.OnComplete(func() {
+					// Half-close: receiving keeps running until peer completes.
+					complete := framing.NewFramePayload(sid, nil, nil, framing.FlagComplete)
+					p.sendFrame(complete)
+					<-complete.DoneNotify()
+				}),
+				rx.OnError(func(e error) {
+					// ERROR terminates both directions.
+					if _, ok := p.messages.Load(sid); ok {
+						p.writeError(sid, e)
+						receiving.Error(e)
+					}
+				}),
 			)
 			sending.
 				DoFinally(func(sig rx.SignalType) {
-					// TODO: handle cancel or error
-					switch sig {
-					case rx.SignalComplete:
-						complete := framing.NewFramePayload(sid, nil, nil, framing.FlagComplete)
-						p.sendFrame(complete)
-						<-complete.DoneNotify()
-					default:
-						panic(fmt.Errorf("unsupported sending channel signal: %s", sig))
-					}
+					closeHalf()
 				}).
 				SubscribeOn(scheduler.Elastic()).
 				SubscribeWith(context.Background(), sub)
@@ -358,7 +659,7 @@ func (p *DuplexRSocket) RequestChannel(publisher rx.Publisher) (ret flux.Flux) {
 func (p *DuplexRSocket) onFrameRequestResponse(frame framing.Frame) error {
 	// fragment
 	receiving, ok := p.doFragment(frame.(*framing.FrameRequestResponse))
-	if !ok {
+	if !ok || p.rejectGoingAway(receiving) {
 		return nil
 	}
 	return p.respondRequestResponse(receiving)
@@ -366,23 +667,34 @@ func (p *DuplexRSocket) onFrameRequestResponse(frame framing.Frame) error {
 
 func (p *DuplexRSocket) respondRequestResponse(receiving fragmentation.HeaderAndPayload) error {
 	sid := receiving.Header().StreamID()
+	p.metrics.RequestStarted(true, framing.FrameTypeRequestResponse)
+
+	// context of request will be cancelled when it's finished or cancelled.
+	ctx, cancel := context.WithCancel(p.newContext())
+	handle := func(msg payload.Payload) mono.Mono {
+		return p.handleRequestResponse(ctx, msg)
+	}
 
 	// 1. execute socket handler
 	sending, err := func() (mono mono.Mono, err error) {
 		defer func() {
 			err = tryRecover(recover())
 		}()
-		mono = p.responder.RequestResponse(receiving)
+		mono = p.interceptors.requestResponse(p.newCall(true, sid), receiving, handle)
 		return
 	}()
 	// 2. sending error with panic
 	if err != nil {
+		cancel()
 		p.writeError(sid, err)
+		p.metrics.RequestFinished(true, framing.FrameTypeRequestResponse, rx.SignalError)
 		return nil
 	}
 	// 3. sending error with unsupported handler
 	if sending == nil {
+		cancel()
 		p.writeError(sid, framing.NewFrameError(sid, common.ErrorCodeApplicationError, unsupportedRequestResponse))
+		p.metrics.RequestFinished(true, framing.FrameTypeRequestResponse, rx.SignalError)
 		return nil
 	}
 
@@ -401,7 +713,9 @@ func (p *DuplexRSocket) respondRequestResponse(receiving fragmentation.HeaderAnd
 	)
 	sending.
 		DoFinally(func(sig rx.SignalType) {
+			cancel()
 			p.unregister(sid)
+			p.metrics.RequestFinished(true, framing.FrameTypeRequestResponse, sig)
 		}).
 		SubscribeOn(scheduler.Elastic()).
 		SubscribeWith(context.Background(), sub)
@@ -410,7 +724,7 @@ func (p *DuplexRSocket) respondRequestResponse(receiving fragmentation.HeaderAnd
 
 func (p *DuplexRSocket) onFrameRequestChannel(input framing.Frame) error {
 	receiving, ok := p.doFragment(input.(*framing.FrameRequestChannel))
-	if !ok {
+	if !ok || p.rejectGoingAway(receiving) {
 		return nil
 	}
 	return p.respondRequestChannel(receiving)
@@ -429,24 +743,32 @@ func (p *DuplexRSocket) respondRequestChannel(pl fragmentation.HeaderAndPayload)
 	}
 
 	sid := pl.Header().StreamID()
+	p.metrics.RequestStarted(true, framing.FrameTypeRequestChannel)
 	receivingProcessor := flux.CreateProcessor()
-
-	ch := make(chan struct{}, 2)
+	closeHalf := p.newChannelHalves(sid)
+	inbound := newCredits(0)
+	firstRequest := atomic.NewBool(true)
 
 	receiving := receivingProcessor.
 		DoFinally(func(s rx.SignalType) {
-			ch <- struct{}{}
-			<-ch
-			select {
-			case _, ok := <-ch:
-				if ok {
-					close(ch)
-					p.unregister(sid)
+			// Cancelling receiving tells peer to stop sending, sending keeps running.
+			if s == rx.SignalCancel {
+				if _, ok := p.messages.Load(sid); ok {
+					p.sendFrame(framing.NewFrameCancel(sid))
 				}
-			default:
 			}
+			closeHalf()
 		}).
 		DoOnRequest(func(n int) {
+			// The first payload has been received with REQUEST_CHANNEL, so it takes one of the first request.
+			if firstRequest.CAS(true, false) && n < rx.RequestMax {
+				n--
+			}
+			if n < 1 {
+				return
+			}
+			// Credits must be granted before peer knows them.
+			inbound.grant(n)
 			frameN := framing.NewFrameRequestN(sid, toU32N(n))
 			p.sendFrame(frameN)
 			<-frameN.DoneNotify()
@@ -456,12 +778,17 @@ func (p *DuplexRSocket) respondRequestChannel(pl fragmentation.HeaderAndPayload)
 		receivingProcessor.Next(pl)
 	})
 
-	// TODO: if receiving == sending ???
+	// context of request will be cancelled when it's finished or cancelled.
+	ctx, cancel := context.WithCancel(p.newContext())
+	handle := func(msgs rx.Publisher) flux.Flux {
+		return p.handleRequestChannel(ctx, msgs)
+	}
+
 	sending, err := func() (flux flux.Flux, err error) {
 		defer func() {
 			err = tryRecover(recover())
 		}()
-		flux = p.responder.RequestChannel(receiving)
+		flux = p.interceptors.requestChannel(p.newCall(true, sid), receiving, handle)
 		if flux == nil {
 			err = framing.NewFrameError(sid, common.ErrorCodeApplicationError, unsupportedRequestChannel)
 		}
@@ -469,25 +796,42 @@ func (p *DuplexRSocket) respondRequestChannel(pl fragmentation.HeaderAndPayload)
 	}()
 
 	if err != nil {
+		cancel()
 		p.writeError(sid, err)
+		p.metrics.RequestFinished(true, framing.FrameTypeRequestChannel, rx.SignalError)
 		return nil
 	}
 
 	// Ensure registering message success before func end.
 	mustSub := make(chan struct{})
+	subscribed := func(s rx.Subscription) {
+		select {
+		case <-mustSub:
+		default:
+			p.register(sid, resRC{rcv: receivingProcessor, snd: s, credits: inbound})
+			close(mustSub)
+		}
+	}
 
 	sub := rx.NewSubscriber(
 		rx.OnError(func(e error) {
-			p.writeError(sid, e)
+			// Some publishers, such as an empty one, terminate without subscription.
+			subscribed(emptySubscription{})
+			// ERROR terminates both directions.
+			if _, ok := p.messages.Load(sid); ok {
+				p.unregister(sid)
+				p.writeError(sid, e)
+				p.terminateInbound(receivingProcessor, e)
+			}
 		}),
 		rx.OnComplete(func() {
+			subscribed(emptySubscription{})
 			complete := framing.NewFramePayload(sid, nil, nil, framing.FlagComplete)
 			p.sendFrame(complete)
 			<-complete.DoneNotify()
 		}),
 		rx.OnSubscribe(func(s rx.Subscription) {
-			p.register(sid, resRC{rcv: receivingProcessor, snd: s})
-			close(mustSub)
+			subscribed(s)
 			s.Request(initRequestN)
 		}),
 		rx.OnNext(func(elem payload.Payload) {
@@ -497,16 +841,9 @@ func (p *DuplexRSocket) respondRequestChannel(pl fragmentation.HeaderAndPayload)
 
 	sending.
 		DoFinally(func(s rx.SignalType) {
-			ch <- struct{}{}
-			<-ch
-			select {
-			case _, ok := <-ch:
-				if ok {
-					close(ch)
-					p.unregister(sid)
-				}
-			default:
-			}
+			cancel()
+			p.metrics.RequestFinished(true, framing.FrameTypeRequestChannel, s)
+			closeHalf()
 		}).
 		SubscribeOn(scheduler.Elastic()).
 		SubscribeWith(context.Background(), sub)
@@ -516,36 +853,70 @@ func (p *DuplexRSocket) respondRequestChannel(pl fragmentation.HeaderAndPayload)
 }
 
 func (p *DuplexRSocket) respondMetadataPush(input framing.Frame) (err error) {
+	p.metrics.RequestStarted(true, framing.FrameTypeMetadataPush)
 	defer func() {
 		if e := recover(); e != nil {
 			logger.Errorf("respond METADATA_PUSH failed: %s\n", e)
+			p.metrics.RequestFinished(true, framing.FrameTypeMetadataPush, rx.SignalError)
+		} else {
+			p.metrics.RequestFinished(true, framing.FrameTypeMetadataPush, rx.SignalComplete)
 		}
 	}()
-	p.responder.MetadataPush(input.(*framing.FrameMetadataPush))
+	ctx := p.newContext()
+	p.interceptors.metadataPush(p.newCall(true, 0), input.(*framing.FrameMetadataPush), func(msg payload.Payload) {
+		p.handleMetadataPush(ctx, msg)
+	})
+	return
+}
+
+func (p *DuplexRSocket) onFrameExt(input framing.Frame) (err error) {
+	f := input.(*framing.FrameExt)
+	handler, ok := p.extensions.Get(f.ExtendedType())
+	if !ok {
+		if f.CanIgnore() {
+			logger.Debugf("ignore EXT frame: extendedType=%d\n", f.ExtendedType())
+			return
+		}
+		return fmt.Errorf("unsupported extended type: %d", f.ExtendedType())
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Errorf("handle EXT frame failed: %s\n", e)
+		}
+	}()
+	handler(p.newContext(), f)
 	return
 }
 
 func (p *DuplexRSocket) onFrameFNF(frame framing.Frame) error {
 	receiving, ok := p.doFragment(frame.(*framing.FrameFNF))
-	if !ok {
+	// FireAndForget has no response, so it will be dropped silently after going away.
+	if !ok || p.draining.Load() {
 		return nil
 	}
 	return p.respondFNF(receiving)
 }
 
 func (p *DuplexRSocket) respondFNF(receiving fragmentation.HeaderAndPayload) (err error) {
+	p.metrics.RequestStarted(true, framing.FrameTypeRequestFNF)
 	defer func() {
 		if e := recover(); e != nil {
 			logger.Errorf("respond FireAndForget failed: %s\n", e)
+			p.metrics.RequestFinished(true, framing.FrameTypeRequestFNF, rx.SignalError)
+		} else {
+			p.metrics.RequestFinished(true, framing.FrameTypeRequestFNF, rx.SignalComplete)
 		}
 	}()
-	p.responder.FireAndForget(receiving)
+	ctx := p.newContext()
+	p.interceptors.fireAndForget(p.newCall(true, receiving.Header().StreamID()), receiving, func(msg payload.Payload) {
+		p.handleFireAndForget(ctx, msg)
+	})
 	return
 }
 
 func (p *DuplexRSocket) onFrameRequestStream(frame framing.Frame) error {
 	receiving, ok := p.doFragment(frame.(*framing.FrameRequestStream))
-	if !ok {
+	if !ok || p.rejectGoingAway(receiving) {
 		return nil
 	}
 	return p.respondRequestStream(receiving)
@@ -553,13 +924,19 @@ func (p *DuplexRSocket) onFrameRequestStream(frame framing.Frame) error {
 
 func (p *DuplexRSocket) respondRequestStream(receiving fragmentation.HeaderAndPayload) error {
 	sid := receiving.Header().StreamID()
+	p.metrics.RequestStarted(true, framing.FrameTypeRequestStream)
+
+	// context of request will be cancelled when it's finished or cancelled.
+	ctx, cancel := context.WithCancel(p.newContext())
 
 	// execute request stream handler
 	sending, err := func() (resp flux.Flux, err error) {
 		defer func() {
 			err = tryRecover(recover())
 		}()
-		resp = p.responder.RequestStream(receiving)
+		resp = p.interceptors.requestStream(p.newCall(true, sid), receiving, func(msg payload.Payload) flux.Flux {
+			return p.handleRequestStream(ctx, msg)
+		})
 		if resp == nil {
 			err = framing.NewFrameError(sid, common.ErrorCodeApplicationError, unsupportedRequestStream)
 		}
@@ -568,7 +945,9 @@ func (p *DuplexRSocket) respondRequestStream(receiving fragmentation.HeaderAndPa
 
 	// send error with panic
 	if err != nil {
+		cancel()
 		p.writeError(sid, err)
+		p.metrics.RequestFinished(true, framing.FrameTypeRequestStream, rx.SignalError)
 		return nil
 	}
 
@@ -602,13 +981,61 @@ func (p *DuplexRSocket) respondRequestStream(receiving fragmentation.HeaderAndPa
 	// async subscribe publisher
 	sending.
 		DoFinally(func(s rx.SignalType) {
+			cancel()
 			p.unregister(sid)
+			p.metrics.RequestFinished(true, framing.FrameTypeRequestStream, s)
 		}).
 		SubscribeOn(scheduler.Elastic()).
 		SubscribeWith(context.Background(), sub)
 	return nil
 }
 
+// terminateInbound completes inbound of a responded channel if err is nil, otherwise errors it.
+// It is done by single scheduler like the first payload, so they never race.
+func (p *DuplexRSocket) terminateInbound(rcv flux.Processor, err error) {
+	p.singleScheduler.Worker().Do(func() {
+		if err == nil {
+			rcv.Complete()
+		} else {
+			rcv.Error(err)
+		}
+	})
+}
+
+// rejectExcessPayload terminates a stream whose peer sends more payloads than the granted credits.
+func (p *DuplexRSocket) rejectExcessPayload(sid uint32, snd rx.Subscription, rcv flux.Processor) {
+	logger.Warnf("payloads exceed requested credits: sid=%d\n", sid)
+	p.unregister(sid)
+	p.sendFrame(framing.NewFrameError(sid, common.ErrorCodeInvalid, errExcessPayloads))
+	if snd != nil {
+		snd.Cancel()
+	}
+	rcv.Error(framing.NewFrameError(sid, common.ErrorCodeInvalid, errExcessPayloads))
+}
+
+// replenish requests more payloads from peer when a batch of prefetch is almost received.
+func (p *DuplexRSocket) replenish(sid uint32, inbound *credits, limit *limiter) {
+	n := limit.received()
+	if n < 1 {
+		return
+	}
+	if _, ok := p.messages.Load(sid); !ok {
+		return
+	}
+	inbound.grant(n)
+	p.sendFrame(framing.NewFrameRequestN(sid, toU32N(n)))
+}
+
+// rejectGoingAway rejects a new request from peer after going away.
+func (p *DuplexRSocket) rejectGoingAway(receiving fragmentation.HeaderAndPayload) bool {
+	if !p.draining.Load() {
+		return false
+	}
+	sid := receiving.Header().StreamID()
+	p.writeError(sid, framing.NewFrameError(sid, common.ErrorCodeRejected, rejectedGoingAway))
+	return true
+}
+
 func (p *DuplexRSocket) writeError(sid uint32, e error) {
 	// ignore sending error because current socket has been closed.
 	if e == errSocketClosed {
@@ -631,9 +1058,13 @@ func (p *DuplexRSocket) SetResponder(responder Responder) {
 
 func (p *DuplexRSocket) onFrameKeepalive(frame framing.Frame) (err error) {
 	f := frame.(*framing.FrameKeepalive)
+	if p.frames != nil {
+		p.frames.Release(f.LastReceivedPosition())
+	}
 	if f.Header().Flag().Check(framing.FlagRespond) {
-		f.SetHeader(framing.NewFrameHeader(0, framing.FrameTypeKeepalive))
-		p.sendFrame(f)
+		p.sendFrame(framing.NewFrameKeepalive(p.counter.ReadBytes(), f.Data(), false))
+	} else if sentAt := p.keepaliveSentAt.Swap(0); sentAt > 0 {
+		p.metrics.KeepaliveRTT(time.Duration(time.Now().UnixNano() - sentAt))
 	}
 	return
 }
@@ -652,6 +1083,14 @@ func (p *DuplexRSocket) onFrameCancel(frame framing.Frame) (err error) {
 		vv.su.Cancel()
 	case resRS:
 		vv.su.Cancel()
+	case reqRC:
+		// Peer doesn't want more payloads, receiving keeps running.
+		vv.snd.Cancel()
+	case resRC:
+		// Requester cancels the whole channel: it stops receiving and sending, so inbound is completed.
+		p.unregister(sid)
+		vv.snd.Cancel()
+		p.terminateInbound(vv.rcv, nil)
 	default:
 		panic(fmt.Errorf("illegal cancel target: %v", vv))
 	}
@@ -669,7 +1108,8 @@ func (p *DuplexRSocket) onFrameError(input framing.Frame) (err error) {
 
 	v, ok := p.messages.Load(sid)
 	if !ok {
-		err = fmt.Errorf("invalid stream id: %d", sid)
+		// Stream may have been finished, or lost by a revived socket.
+		logger.Warnf("ignore error of unknown stream: sid=%d\n", sid)
 		return
 	}
 
@@ -679,7 +1119,14 @@ func (p *DuplexRSocket) onFrameError(input framing.Frame) (err error) {
 	case reqRS:
 		vv.pc.Error(f)
 	case reqRC:
+		// ERROR terminates both directions.
+		p.unregister(sid)
+		vv.snd.Cancel()
 		vv.rcv.Error(f)
+	case resRC:
+		p.unregister(sid)
+		vv.snd.Cancel()
+		p.terminateInbound(vv.rcv, f)
 	default:
 		panic(fmt.Errorf("illegal value for error: %v", vv))
 	}
@@ -720,6 +1167,7 @@ func (p *DuplexRSocket) doFragment(input fragmentation.HeaderAndPayload) (out fr
 		if ok {
 			p.fragments.Delete(sid)
 			out = joiner
+			p.metrics.Reassembled()
 		}
 		return
 	}
@@ -765,25 +1213,45 @@ func (p *DuplexRSocket) onFramePayload(frame framing.Frame) error {
 	case reqRS:
 		fg := h.Flag()
 		isNext := fg.Check(framing.FlagNext)
+		if isNext && !vv.credits.consume() {
+			// Requester of a stream can only stop peer by CANCEL.
+			logger.Warnf("payloads exceed requested credits: sid=%d\n", sid)
+			p.unregister(sid)
+			p.sendFrame(framing.NewFrameCancel(sid))
+			vv.pc.Error(framing.NewFrameError(sid, common.ErrorCodeInvalid, errExcessPayloads))
+			return nil
+		}
 		if isNext {
 			vv.pc.Next(pl)
 		}
 		if fg.Check(framing.FlagComplete) {
 			// Release pure complete payload
 			vv.pc.Complete()
+		} else if isNext {
+			p.replenish(sid, vv.credits, vv.limiter)
 		}
 	case reqRC:
 		fg := h.Flag()
 		isNext := fg.Check(framing.FlagNext)
+		if isNext && !vv.credits.consume() {
+			p.rejectExcessPayload(sid, vv.snd, vv.rcv)
+			return nil
+		}
 		if isNext {
 			vv.rcv.Next(pl)
 		}
 		if fg.Check(framing.FlagComplete) {
 			vv.rcv.Complete()
+		} else if isNext {
+			p.replenish(sid, vv.credits, vv.limiter)
 		}
 	case resRC:
 		fg := h.Flag()
 		isNext := fg.Check(framing.FlagNext)
+		if isNext && !vv.credits.consume() {
+			p.rejectExcessPayload(sid, vv.snd, vv.rcv)
+			return nil
+		}
 		if isNext {
 			vv.rcv.Next(pl)
 		}
@@ -802,13 +1270,77 @@ func (p *DuplexRSocket) clearTransport() {
 	p.cond.L.Unlock()
 }
 
+// ResumeFrom prepares frames after the position which has been received by peer.
+// These frames will be retransmitted before any other frames once transport is ready.
+// It returns error if position is not available in retained frames.
+func (p *DuplexRSocket) ResumeFrom(position uint64) error {
+	if p.frames == nil {
+		return errResumeDisabled
+	}
+	replays, err := p.frames.Since(position)
+	if err != nil {
+		return err
+	}
+	p.cond.L.Lock()
+	p.outsReplay = replays
+	p.cond.L.Unlock()
+	return nil
+}
+
+// retainedStream returns the first stream which has frames to be retransmitted after position.
+func (p *DuplexRSocket) retainedStream(position uint64) (sid uint32, ok bool) {
+	if p.frames == nil {
+		return
+	}
+	frames, err := p.frames.Since(position)
+	if err != nil {
+		return
+	}
+	for _, it := range frames {
+		if sid = it.Header().StreamID(); sid != 0 {
+			return sid, true
+		}
+	}
+	return 0, false
+}
+
+// FirstAvailablePosition returns the earliest position which can be retransmitted.
+func (p *DuplexRSocket) FirstAvailablePosition() uint64 {
+	if p.frames == nil {
+		return 0
+	}
+	return p.frames.FirstAvailablePosition()
+}
+
+func (p *DuplexRSocket) snapshot() (receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	receivedPosition = p.counter.ReadBytes()
+	if p.frames != nil {
+		firstAvailablePosition, frames = p.frames.Snapshot()
+	}
+	return
+}
+
+func (p *DuplexRSocket) restore(receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	p.counter.SetReadBytes(receivedPosition)
+	if p.frames == nil {
+		p.enableResume()
+	}
+	p.frames.Reset(firstAvailablePosition, frames)
+}
+
+func (p *DuplexRSocket) enableResume() {
+	p.frames = newFrameStore(defaultFrameStoreSize)
+}
+
 // SetTransport sets a transport for current socket.
 func (p *DuplexRSocket) SetTransport(tp *transport.Transport) {
+	tp.Connection().SetCounter(p.counter)
 	tp.HandleCancel(p.onFrameCancel)
 	tp.HandleError(p.onFrameError)
 	tp.HandleRequestN(p.onFrameRequestN)
 	tp.HandlePayload(p.onFramePayload)
 	tp.HandleKeepalive(p.onFrameKeepalive)
+	tp.HandleExt(p.onFrameExt)
 
 	if p.responder != nil {
 		tp.HandleRequestResponse(p.onFrameRequestResponse)
@@ -859,17 +1391,18 @@ func (p *DuplexRSocket) sendPayload(
 	})
 }
 
-func (p *DuplexRSocket) drainWithKeepaliveAndLease(leaseChan <-chan lease.Lease) (ok bool) {
+func (p *DuplexRSocket) drainWithKeepaliveAndLease(tp *transport.Transport, leaseChan <-chan lease.Lease) (ok bool) {
 	if len(p.outs) > 0 {
-		p.drain(nil)
+		p.drain(tp, nil)
 	}
 	var out framing.Frame
 	select {
 	case <-p.keepaliver.C():
 		ok = true
 		out = framing.NewFrameKeepalive(p.counter.ReadBytes(), nil, true)
-		if p.tp != nil {
-			err := p.tp.Send(out, true)
+		p.keepaliveSentAt.Store(time.Now().UnixNano())
+		if tp != nil {
+			err := tp.Send(out, true)
 			if err != nil {
 				logger.Errorf("send keepalive frame failed: %s\n", err.Error())
 			}
@@ -879,10 +1412,15 @@ func (p *DuplexRSocket) drainWithKeepaliveAndLease(leaseChan <-chan lease.Lease)
 		if !ok {
 			return
 		}
+		// No more lease will be granted after going away.
+		if p.draining.Load() {
+			return
+		}
 		out = framing.NewFrameLease(ls.TimeToLive, ls.NumberOfRequests, ls.Metadata)
-		if p.tp == nil {
+		p.metrics.LeaseGranted(ls.NumberOfRequests)
+		if tp == nil {
 			p.outsPriority = append(p.outsPriority, out)
-		} else if err := p.tp.Send(out, true); err != nil {
+		} else if err := tp.Send(out, true); err != nil {
 			logger.Errorf("send frame failed: %s\n", err.Error())
 			p.outsPriority = append(p.outsPriority, out)
 		}
@@ -890,19 +1428,21 @@ func (p *DuplexRSocket) drainWithKeepaliveAndLease(leaseChan <-chan lease.Lease)
 		if !ok {
 			return
 		}
-		if p.tp == nil {
+		if tp == nil {
 			p.outsPriority = append(p.outsPriority, out)
-		} else if err := p.tp.Send(out, true); err != nil {
+		} else if err := tp.Send(out, true); err != nil {
 			logger.Errorf("send frame failed: %s\n", err.Error())
 			p.outsPriority = append(p.outsPriority, out)
+		} else {
+			p.retain(out)
 		}
 	}
 	return
 }
 
-func (p *DuplexRSocket) drainWithKeepalive() (ok bool) {
+func (p *DuplexRSocket) drainWithKeepalive(tp *transport.Transport) (ok bool) {
 	if len(p.outs) > 0 {
-		p.drain(nil)
+		p.drain(tp, nil)
 	}
 	var out framing.Frame
 
@@ -910,8 +1450,9 @@ func (p *DuplexRSocket) drainWithKeepalive() (ok bool) {
 	case <-p.keepaliver.C():
 		ok = true
 		out = framing.NewFrameKeepalive(p.counter.ReadBytes(), nil, true)
-		if p.tp != nil {
-			err := p.tp.Send(out, true)
+		p.keepaliveSentAt.Store(time.Now().UnixNano())
+		if tp != nil {
+			err := tp.Send(out, true)
 			if err != nil {
 				logger.Errorf("send keepalive frame failed: %s\n", err.Error())
 			}
@@ -920,17 +1461,19 @@ func (p *DuplexRSocket) drainWithKeepalive() (ok bool) {
 		if !ok {
 			return
 		}
-		if p.tp == nil {
+		if tp == nil {
 			p.outsPriority = append(p.outsPriority, out)
-		} else if err := p.tp.Send(out, true); err != nil {
+		} else if err := tp.Send(out, true); err != nil {
 			logger.Errorf("send frame failed: %s\n", err.Error())
 			p.outsPriority = append(p.outsPriority, out)
+		} else {
+			p.retain(out)
 		}
 	}
 	return
 }
 
-func (p *DuplexRSocket) drain(leaseChan <-chan lease.Lease) bool {
+func (p *DuplexRSocket) drain(tp *transport.Transport, leaseChan <-chan lease.Lease) bool {
 	var flush bool
 	cycle := len(p.outs)
 	if cycle < 1 {
@@ -942,71 +1485,95 @@ func (p *DuplexRSocket) drain(leaseChan <-chan lease.Lease) bool {
 			if !ok {
 				return false
 			}
-			if p.drainOne(framing.NewFrameLease(next.TimeToLive, next.NumberOfRequests, next.Metadata)) {
+			if p.draining.Load() {
+				continue
+			}
+			p.metrics.LeaseGranted(next.NumberOfRequests)
+			if p.drainOne(tp, framing.NewFrameLease(next.TimeToLive, next.NumberOfRequests, next.Metadata)) {
 				flush = true
 			}
 		case out, ok := <-p.outs:
 			if !ok {
 				return false
 			}
-			if p.drainOne(out) {
+			if p.drainOne(tp, out) {
 				flush = true
 			}
 		}
 	}
 	if flush {
-		if err := p.tp.Flush(); err != nil {
+		if err := tp.Flush(); err != nil {
 			logger.Errorf("flush failed: %v\n", err)
 		}
 	}
 	return true
 }
 
-func (p *DuplexRSocket) drainOne(out framing.Frame) (wrote bool) {
-	if p.tp == nil {
+func (p *DuplexRSocket) drainOne(tp *transport.Transport, out framing.Frame) (wrote bool) {
+	if tp == nil {
 		p.outsPriority = append(p.outsPriority, out)
 		return
 	}
-	err := p.tp.Send(out, false)
+	err := tp.Send(out, false)
 	if err != nil {
 		p.outsPriority = append(p.outsPriority, out)
 		logger.Errorf("send frame failed: %s\n", err.Error())
 		return
 	}
+	p.retain(out)
 	wrote = true
 	return
 }
 
-func (p *DuplexRSocket) drainOutBack() {
-	if len(p.outsPriority) < 1 {
+// retain keeps a written frame until peer acknowledges it, it does nothing if resume is disabled.
+func (p *DuplexRSocket) retain(out framing.Frame) {
+	if p.frames != nil {
+		p.frames.Push(out)
+	}
+}
+
+// drainReplay retransmits frames which have been lost in flight after resuming.
+func (p *DuplexRSocket) drainReplay(tp *transport.Transport) {
+	p.cond.L.Lock()
+	replays := p.outsReplay
+	p.outsReplay = nil
+	p.cond.L.Unlock()
+	if len(replays) < 1 || tp == nil {
 		return
 	}
-	defer func() {
-		p.outsPriority = p.outsPriority[:0]
-	}()
-	if p.tp == nil {
+	for _, out := range replays {
+		if err := tp.Send(out, false); err != nil {
+			logger.Errorf("retransmit frame failed: %v\n", err)
+			return
+		}
+	}
+	if err := tp.Flush(); err != nil {
+		logger.Errorf("flush failed: %v\n", err)
+	}
+}
+
+func (p *DuplexRSocket) drainOutBack(tp *transport.Transport) {
+	if len(p.outsPriority) < 1 || tp == nil {
 		return
 	}
-	var out framing.Frame
-	for i := range p.outsPriority {
-		out = p.outsPriority[i]
-		if err := p.tp.Send(out, false); err != nil {
-			out.Done()
+	for i, out := range p.outsPriority {
+		if err := tp.Send(out, false); err != nil {
 			logger.Errorf("send frame failed: %v\n", err)
+			// Transport is broken, keep unsent frames for the next transport.
+			p.outsPriority = append(p.outsPriority[:0], p.outsPriority[i:]...)
+			return
 		}
+		p.retain(out)
 	}
-	if err := p.tp.Flush(); err != nil {
+	p.outsPriority = p.outsPriority[:0]
+	if err := tp.Flush(); err != nil {
 		logger.Errorf("flush failed: %v\n", err)
 	}
 }
 
 func (p *DuplexRSocket) loopWriteWithKeepaliver(ctx context.Context, leaseChan <-chan lease.Lease) error {
 	for {
-		if p.tp == nil {
-			p.cond.L.Lock()
-			p.cond.Wait()
-			p.cond.L.Unlock()
-		}
+		tp := p.waitTransport()
 
 		select {
 		case <-ctx.Done():
@@ -1019,8 +1586,8 @@ func (p *DuplexRSocket) loopWriteWithKeepaliver(ctx context.Context, leaseChan <
 		select {
 		case <-p.keepaliver.C():
 			kf := framing.NewFrameKeepalive(p.counter.ReadBytes(), nil, true)
-			if p.tp != nil {
-				err := p.tp.Send(kf, true)
+			if tp != nil {
+				err := tp.Send(kf, true)
 				if err != nil {
 					logger.Errorf("send keepalive frame failed: %s\n", err.Error())
 				}
@@ -1028,17 +1595,36 @@ func (p *DuplexRSocket) loopWriteWithKeepaliver(ctx context.Context, leaseChan <
 		default:
 		}
 
-		p.drainOutBack()
-		if leaseChan == nil && !p.drainWithKeepalive() {
+		p.drainReplay(tp)
+		p.drainOutBack(tp)
+		if leaseChan == nil && !p.drainWithKeepalive(tp) {
 			break
 		}
-		if leaseChan != nil && !p.drainWithKeepaliveAndLease(leaseChan) {
+		if leaseChan != nil && !p.drainWithKeepaliveAndLease(tp, leaseChan) {
 			break
 		}
 	}
 	return nil
 }
 
+// waitTransport blocks until transport is ready or current socket is closed, it returns a snapshot of transport.
+// Transport may be swapped by resuming, so writing loop should use the snapshot instead of p.tp.
+func (p *DuplexRSocket) waitTransport() *transport.Transport {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	for p.tp == nil && !p.closed.Load() {
+		p.cond.Wait()
+	}
+	return p.tp
+}
+
+// currentTransport returns transport of current socket, it returns nil if there's no transport.
+func (p *DuplexRSocket) currentTransport() *transport.Transport {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	return p.tp
+}
+
 func (p *DuplexRSocket) cleanOuts() {
 	p.outsPriority = nil
 }
@@ -1062,11 +1648,7 @@ func (p *DuplexRSocket) loopWrite(ctx context.Context) error {
 		return p.loopWriteWithKeepaliver(ctx, leaseChan)
 	}
 	for {
-		if p.tp == nil {
-			p.cond.L.Lock()
-			p.cond.Wait()
-			p.cond.L.Unlock()
-		}
+		tp := p.waitTransport()
 
 		select {
 		case <-ctx.Done():
@@ -1075,8 +1657,9 @@ func (p *DuplexRSocket) loopWrite(ctx context.Context) error {
 		default:
 		}
 
-		p.drainOutBack()
-		if !p.drain(leaseChan) {
+		p.drainReplay(tp)
+		p.drainOutBack(tp)
+		if !p.drain(tp, leaseChan) {
 			break
 		}
 	}
@@ -1096,18 +1679,35 @@ func (p *DuplexRSocket) shouldSplit(size int) bool {
 }
 
 func (p *DuplexRSocket) register(sid uint32, msg interface{}) {
-	p.messages.Store(sid, msg)
+	if p.messages.Store(sid, msg) {
+		p.metrics.StreamsActive(1)
+	}
+}
+
+// newChannelHalves returns a function which should be called when one direction of a channel is terminated.
+// The channel will be unregistered after both directions are terminated.
+func (p *DuplexRSocket) newChannelHalves(sid uint32) (closeHalf func()) {
+	halves := atomic.NewInt32(2)
+	return func() {
+		if halves.Dec() == 0 {
+			p.unregister(sid)
+		}
+	}
 }
 
 func (p *DuplexRSocket) unregister(sid uint32) {
-	p.messages.Delete(sid)
+	if p.messages.Delete(sid) {
+		p.metrics.StreamsActive(-1)
+	}
 	p.fragments.Delete(sid)
 }
 
 // NewServerDuplexRSocket creates a new server-side DuplexRSocket.
-func NewServerDuplexRSocket(mtu int, leases lease.Leases) *DuplexRSocket {
-	return &DuplexRSocket{
+func NewServerDuplexRSocket(mtu int, leases lease.Leases) (s *DuplexRSocket) {
+	s = &DuplexRSocket{
 		closed:          atomic.NewBool(false),
+		draining:        atomic.NewBool(false),
+		keepaliveSentAt: atomic.NewInt64(0),
 		leases:          leases,
 		outs:            make(chan framing.Frame, outsSize),
 		mtu:             mtu,
@@ -1119,6 +1719,8 @@ func NewServerDuplexRSocket(mtu int, leases lease.Leases) *DuplexRSocket {
 		counter:         transport.NewCounter(),
 		singleScheduler: scheduler.NewSingle(64),
 	}
+	s.ctx, s.cancelCtx = context.WithCancel(context.Background())
+	return
 }
 
 // NewClientDuplexRSocket creates a new client-side DuplexRSocket.
@@ -1129,6 +1731,8 @@ func NewClientDuplexRSocket(
 	ka := newKeepaliver(keepaliveInterval)
 	s = &DuplexRSocket{
 		closed:          atomic.NewBool(false),
+		draining:        atomic.NewBool(false),
+		keepaliveSentAt: atomic.NewInt64(0),
 		outs:            make(chan framing.Frame, outsSize),
 		mtu:             mtu,
 		messages:        newU32Map(),
@@ -1140,5 +1744,6 @@ func NewClientDuplexRSocket(
 		keepaliver:      ka,
 		singleScheduler: scheduler.NewSingle(64),
 	}
+	s.ctx, s.cancelCtx = context.WithCancel(context.Background())
 	return
 }
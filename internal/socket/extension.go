@@ -0,0 +1,38 @@
+package socket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+// ExtensionHandler handles payload of an EXT frame, ctx carries information of the connection.
+type ExtensionHandler = func(ctx context.Context, msg payload.Payload)
+
+// Extensions is a registry of handlers for EXT frames, handlers are keyed by extended type.
+type Extensions struct {
+	mu       sync.RWMutex
+	handlers map[uint32]ExtensionHandler
+}
+
+// Register registers a handler for extended type, it replaces the previous one.
+func (p *Extensions) Register(extendedType uint32, handler ExtensionHandler) {
+	p.mu.Lock()
+	if p.handlers == nil {
+		p.handlers = make(map[uint32]ExtensionHandler)
+	}
+	p.handlers[extendedType] = handler
+	p.mu.Unlock()
+}
+
+// Get returns the handler of extended type.
+func (p *Extensions) Get(extendedType uint32) (handler ExtensionHandler, ok bool) {
+	if p == nil {
+		return
+	}
+	p.mu.RLock()
+	handler, ok = p.handlers[extendedType]
+	p.mu.RUnlock()
+	return
+}
@@ -0,0 +1,123 @@
+package socket
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rsocket/rsocket-go/internal/framing"
+)
+
+const defaultFrameStoreSize = 4 * 1024 * 1024
+
+// frameStore retains resumable frames which have been written but not acknowledged by peer yet.
+// Positions are implied positions: the total length of resumable frames which have been sent.
+type frameStore struct {
+	locker sync.Mutex
+	frames []framing.Frame
+	first  uint64
+	last   uint64
+	size   int
+	limit  int
+}
+
+// FirstAvailablePosition returns the earliest position which can be retransmitted.
+func (p *frameStore) FirstAvailablePosition() (pos uint64) {
+	p.locker.Lock()
+	pos = p.first
+	p.locker.Unlock()
+	return
+}
+
+// Position returns the implied position of frames have been sent.
+func (p *frameStore) Position() (pos uint64) {
+	p.locker.Lock()
+	pos = p.last
+	p.locker.Unlock()
+	return
+}
+
+// Push appends a written frame. The earliest frames will be dropped when store is full.
+func (p *frameStore) Push(frame framing.Frame) {
+	if !frame.CanResume() {
+		return
+	}
+	n := frame.Len()
+	p.locker.Lock()
+	p.frames = append(p.frames, frame)
+	p.size += n
+	p.last += uint64(n)
+	for p.size > p.limit && len(p.frames) > 0 {
+		p.shift()
+	}
+	p.locker.Unlock()
+}
+
+// Release drops frames which have been received by peer.
+func (p *frameStore) Release(position uint64) {
+	p.locker.Lock()
+	for len(p.frames) > 0 && p.first+uint64(p.frames[0].Len()) <= position {
+		p.shift()
+	}
+	p.locker.Unlock()
+}
+
+// Since releases frames before position and returns frames after it which should be retransmitted.
+func (p *frameStore) Since(position uint64) (frames []framing.Frame, err error) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if position < p.first || position > p.last {
+		err = fmt.Errorf("position %d is out of range [%d,%d]", position, p.first, p.last)
+		return
+	}
+	for len(p.frames) > 0 && p.first+uint64(p.frames[0].Len()) <= position {
+		p.shift()
+	}
+	if p.first != position {
+		err = fmt.Errorf("position %d is not a frame boundary", position)
+		return
+	}
+	frames = make([]framing.Frame, len(p.frames))
+	copy(frames, p.frames)
+	return
+}
+
+// Snapshot returns first available position and all retained frames.
+func (p *frameStore) Snapshot() (first uint64, frames []framing.Frame) {
+	p.locker.Lock()
+	first = p.first
+	frames = make([]framing.Frame, len(p.frames))
+	copy(frames, p.frames)
+	p.locker.Unlock()
+	return
+}
+
+// Reset replaces all retained frames.
+func (p *frameStore) Reset(first uint64, frames []framing.Frame) {
+	p.locker.Lock()
+	p.frames = p.frames[:0]
+	p.first, p.last, p.size = first, first, 0
+	for _, it := range frames {
+		n := it.Len()
+		p.frames = append(p.frames, it)
+		p.last += uint64(n)
+		p.size += n
+	}
+	p.locker.Unlock()
+}
+
+func (p *frameStore) shift() {
+	n := p.frames[0].Len()
+	p.frames[0] = nil
+	p.frames = p.frames[1:]
+	p.first += uint64(n)
+	p.size -= n
+}
+
+func newFrameStore(limit int) *frameStore {
+	if limit < 1 {
+		limit = defaultFrameStoreSize
+	}
+	return &frameStore{
+		limit: limit,
+	}
+}
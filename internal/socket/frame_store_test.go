@@ -0,0 +1,52 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameStore(t *testing.T) {
+	store := newFrameStore(1024)
+	var positions []uint64
+	for i := 0; i < 4; i++ {
+		f := framing.NewFramePayload(1, []byte("hello"), nil, framing.FlagNext)
+		store.Push(f)
+		positions = append(positions, store.Position())
+	}
+	// non-resumable frame should be ignored.
+	store.Push(framing.NewFrameKeepalive(0, nil, true))
+	assert.Equal(t, positions[3], store.Position())
+	assert.Equal(t, uint64(0), store.FirstAvailablePosition())
+
+	store.Release(positions[0])
+	assert.Equal(t, positions[0], store.FirstAvailablePosition())
+
+	frames, err := store.Since(positions[1])
+	assert.NoError(t, err)
+	assert.Len(t, frames, 2)
+	assert.Equal(t, positions[1], store.FirstAvailablePosition())
+
+	_, err = store.Since(positions[0])
+	assert.Error(t, err, "released position should be rejected")
+	_, err = store.Since(positions[1] + 1)
+	assert.Error(t, err, "position should be a frame boundary")
+	_, err = store.Since(positions[3] + 1)
+	assert.Error(t, err, "position should not exceed sent frames")
+
+	frames, err = store.Since(positions[3])
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+}
+
+func TestFrameStore_Limit(t *testing.T) {
+	f := framing.NewFramePayload(1, []byte("hello"), nil, framing.FlagNext)
+	store := newFrameStore(f.Len() * 2)
+	for i := 0; i < 3; i++ {
+		store.Push(framing.NewFramePayload(1, []byte("hello"), nil, framing.FlagNext))
+	}
+	assert.Equal(t, uint64(f.Len()), store.FirstAvailablePosition())
+	_, err := store.Since(0)
+	assert.Error(t, err, "dropped frames cannot be retransmitted")
+}
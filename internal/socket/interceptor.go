@@ -0,0 +1,149 @@
+package socket
+
+import (
+	"net"
+
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+// Call represents an intercepted request.
+type Call struct {
+	// Responder is true if the request is received from peer, false if it is sent to peer.
+	Responder bool
+	// StreamID is the stream ID of the request, it is zero for MetadataPush.
+	// For requests sent to peer, it is allocated when next is called, so a rejected request consumes no stream ID.
+	StreamID uint32
+	// Setup is the SETUP payload of connection.
+	Setup payload.SetupPayload
+	// RemoteAddr is the address of peer, it is nil if the transport is not ready.
+	RemoteAddr net.Addr
+	// Connection is the connection which the request belongs to.
+	Connection Connection
+}
+
+// Interceptor wraps requests of a socket, both requester and responder sides.
+// Each method should call next to continue the chain, or return its own result to short-circuit it.
+type Interceptor interface {
+	// FireAndForget intercepts a FireAndForget request.
+	FireAndForget(call *Call, message payload.Payload, next func(payload.Payload))
+	// MetadataPush intercepts a MetadataPush request.
+	MetadataPush(call *Call, message payload.Payload, next func(payload.Payload))
+	// RequestResponse intercepts a RequestResponse request.
+	RequestResponse(call *Call, message payload.Payload, next func(payload.Payload) mono.Mono) mono.Mono
+	// RequestStream intercepts a RequestStream request.
+	RequestStream(call *Call, message payload.Payload, next func(payload.Payload) flux.Flux) flux.Flux
+	// RequestChannel intercepts a RequestChannel request.
+	RequestChannel(call *Call, messages rx.Publisher, next func(rx.Publisher) flux.Flux) flux.Flux
+}
+
+// AbstractInterceptor represents an abstract Interceptor, requests without handler will be passed through.
+type AbstractInterceptor struct {
+	FF func(*Call, payload.Payload, func(payload.Payload))
+	MP func(*Call, payload.Payload, func(payload.Payload))
+	RR func(*Call, payload.Payload, func(payload.Payload) mono.Mono) mono.Mono
+	RS func(*Call, payload.Payload, func(payload.Payload) flux.Flux) flux.Flux
+	RC func(*Call, rx.Publisher, func(rx.Publisher) flux.Flux) flux.Flux
+}
+
+// FireAndForget intercepts a FireAndForget request.
+func (p AbstractInterceptor) FireAndForget(call *Call, message payload.Payload, next func(payload.Payload)) {
+	if p.FF == nil {
+		next(message)
+		return
+	}
+	p.FF(call, message, next)
+}
+
+// MetadataPush intercepts a MetadataPush request.
+func (p AbstractInterceptor) MetadataPush(call *Call, message payload.Payload, next func(payload.Payload)) {
+	if p.MP == nil {
+		next(message)
+		return
+	}
+	p.MP(call, message, next)
+}
+
+// RequestResponse intercepts a RequestResponse request.
+func (p AbstractInterceptor) RequestResponse(call *Call, message payload.Payload, next func(payload.Payload) mono.Mono) mono.Mono {
+	if p.RR == nil {
+		return next(message)
+	}
+	return p.RR(call, message, next)
+}
+
+// RequestStream intercepts a RequestStream request.
+func (p AbstractInterceptor) RequestStream(call *Call, message payload.Payload, next func(payload.Payload) flux.Flux) flux.Flux {
+	if p.RS == nil {
+		return next(message)
+	}
+	return p.RS(call, message, next)
+}
+
+// RequestChannel intercepts a RequestChannel request.
+func (p AbstractInterceptor) RequestChannel(call *Call, messages rx.Publisher, next func(rx.Publisher) flux.Flux) flux.Flux {
+	if p.RC == nil {
+		return next(messages)
+	}
+	return p.RC(call, messages, next)
+}
+
+// interceptors is a chain of Interceptor, the first one is the outermost.
+type interceptors []Interceptor
+
+func (c interceptors) fireAndForget(call *Call, message payload.Payload, last func(payload.Payload)) {
+	next := last
+	for i := len(c) - 1; i >= 0; i-- {
+		it, inner := c[i], next
+		next = func(message payload.Payload) {
+			it.FireAndForget(call, message, inner)
+		}
+	}
+	next(message)
+}
+
+func (c interceptors) metadataPush(call *Call, message payload.Payload, last func(payload.Payload)) {
+	next := last
+	for i := len(c) - 1; i >= 0; i-- {
+		it, inner := c[i], next
+		next = func(message payload.Payload) {
+			it.MetadataPush(call, message, inner)
+		}
+	}
+	next(message)
+}
+
+func (c interceptors) requestResponse(call *Call, message payload.Payload, last func(payload.Payload) mono.Mono) mono.Mono {
+	next := last
+	for i := len(c) - 1; i >= 0; i-- {
+		it, inner := c[i], next
+		next = func(message payload.Payload) mono.Mono {
+			return it.RequestResponse(call, message, inner)
+		}
+	}
+	return next(message)
+}
+
+func (c interceptors) requestStream(call *Call, message payload.Payload, last func(payload.Payload) flux.Flux) flux.Flux {
+	next := last
+	for i := len(c) - 1; i >= 0; i-- {
+		it, inner := c[i], next
+		next = func(message payload.Payload) flux.Flux {
+			return it.RequestStream(call, message, inner)
+		}
+	}
+	return next(message)
+}
+
+func (c interceptors) requestChannel(call *Call, messages rx.Publisher, last func(rx.Publisher) flux.Flux) flux.Flux {
+	next := last
+	for i := len(c) - 1; i >= 0; i-- {
+		it, inner := c[i], next
+		next = func(messages rx.Publisher) flux.Flux {
+			return it.RequestChannel(call, messages, inner)
+		}
+	}
+	return next(messages)
+}
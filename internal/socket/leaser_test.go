@@ -0,0 +1,37 @@
+package socket
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/rsocket/rsocket-go/internal/instrument"
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseSocket_LeaseRejected(t *testing.T) {
+	registry := metrics.NewRegistry()
+	sk := NewClientDuplexRSocket(fragmentation.MaxFragment, time.Second)
+	sk.SetMetrics(instrument.NewRecorder(registry, metrics.RoleClient))
+	// no lease is received
+	p := &baseSocket{
+		socket:   sk,
+		reqLease: newLeaser(time.Now(), 0),
+	}
+
+	p.FireAndForget(payload.NewString("hello", ""))
+	_, err := p.RequestResponse(payload.NewString("hello", "")).Block(context.Background())
+	assert.Error(t, err)
+
+	// rejected requests are not sent
+	assert.Empty(t, sk.outs)
+	sb := &strings.Builder{}
+	require.NoError(t, registry.WriteText(sb))
+	assert.Contains(t, sb.String(), `rsocket_lease_rejected_total{role="client"} 2`+"\n")
+	assert.Contains(t, sb.String(), `rsocket_requests_started_total{role="client",side="requester",type="REQUEST_FNF"} 0`+"\n")
+}
@@ -16,10 +16,17 @@ type u32map struct {
 }
 
 func (p *u32map) Close() error {
+	_ = p.Clear()
+	return nil
+}
+
+// Clear removes all entries and disables further storing, it returns the number of removed entries.
+func (p *u32map) Clear() (n int) {
 	p.k.Lock()
+	n = len(p.m)
 	p.m = nil
 	p.k.Unlock()
-	return nil
+	return
 }
 
 func (p *u32map) Range(fn func(uint32, interface{}) bool) {
@@ -32,6 +39,13 @@ func (p *u32map) Range(fn func(uint32, interface{}) bool) {
 	p.k.RUnlock()
 }
 
+func (p *u32map) Len() (n int) {
+	p.k.RLock()
+	n = len(p.m)
+	p.k.RUnlock()
+	return
+}
+
 func (p *u32map) Load(key uint32) (v interface{}, ok bool) {
 	p.k.RLock()
 	v, ok = p.m[key]
@@ -39,18 +53,25 @@ func (p *u32map) Load(key uint32) (v interface{}, ok bool) {
 	return
 }
 
-func (p *u32map) Store(key uint32, value interface{}) {
+// Store stores a value, it returns true if the key is new.
+func (p *u32map) Store(key uint32, value interface{}) (created bool) {
 	p.k.Lock()
 	if p.m != nil {
+		_, exist := p.m[key]
+		created = !exist
 		p.m[key] = value
 	}
 	p.k.Unlock()
+	return
 }
 
-func (p *u32map) Delete(key uint32) {
+// Delete deletes a value, it returns true if the key exists.
+func (p *u32map) Delete(key uint32) (deleted bool) {
 	p.k.Lock()
+	_, deleted = p.m[key]
 	delete(p.m, key)
 	p.k.Unlock()
+	return
 }
 
 func newU32Map() *u32map {
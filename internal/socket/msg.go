@@ -11,8 +11,19 @@ type closerWithError interface {
 	Close(error)
 }
 
+// emptySubscription is used for publishers which terminate without subscription.
+type emptySubscription struct{}
+
+func (emptySubscription) Request(n int) {
+}
+
+func (emptySubscription) Cancel() {
+}
+
 type reqRS struct {
-	pc flux.Processor
+	pc      flux.Processor
+	credits *credits
+	limiter *limiter
 }
 
 func (s reqRS) Close(err error) {
@@ -28,8 +39,10 @@ func (s reqRR) Close(err error) {
 }
 
 type reqRC struct {
-	snd rx.Subscription
-	rcv flux.Processor
+	snd     rx.Subscription
+	rcv     flux.Processor
+	credits *credits
+	limiter *limiter
 }
 
 func (s reqRC) Close(err error) {
@@ -56,8 +69,9 @@ func (s resRS) Close(err error) {
 }
 
 type resRC struct {
-	snd rx.Subscription
-	rcv flux.Processor
+	snd     rx.Subscription
+	rcv     flux.Processor
+	credits *credits
 }
 
 func (s resRC) Close(err error) {
@@ -26,6 +26,11 @@ func (p *serverSocket) Token() (token []byte, ok bool) {
 	return
 }
 
+func (p *serverSocket) Resume(_, _ uint64) (position uint64, err error) {
+	err = errResumeDisabled
+	return
+}
+
 func (p *serverSocket) Start(ctx context.Context) error {
 	defer func() {
 		_ = p.Close()
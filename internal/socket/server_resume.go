@@ -2,13 +2,17 @@ package socket
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/rsocket/rsocket-go/internal/framing"
 	"github.com/rsocket/rsocket-go/internal/transport"
 )
 
 type resumeServerSocket struct {
 	*baseSocket
 	token []byte
+	// revived is true if socket is restored from a snapshot, states of streams in the snapshot are lost.
+	revived bool
 }
 
 func (p *resumeServerSocket) Pause() bool {
@@ -29,6 +33,37 @@ func (p *resumeServerSocket) Token() (token []byte, ok bool) {
 	return
 }
 
+func (p *resumeServerSocket) Restore(receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	p.baseSocket.Restore(receivedPosition, firstAvailablePosition, frames)
+	p.revived = true
+}
+
+func (p *resumeServerSocket) Resume(lastReceivedServerPosition, firstAvailableClientPosition uint64) (position uint64, err error) {
+	position = p.socket.counter.ReadBytes()
+	// Client has dropped frames which have not been received yet.
+	if firstAvailableClientPosition > position {
+		err = fmt.Errorf(
+			"client cannot retransmit from position %d: first available client position is %d",
+			position, firstAvailableClientPosition,
+		)
+		return
+	}
+	// A revived socket knows nothing about streams of retained frames, so they cannot be retransmitted.
+	// Frames of these streams from client will be discarded as frames of unknown streams.
+	if p.revived {
+		if sid, ok := p.socket.retainedStream(lastReceivedServerPosition); ok {
+			err = fmt.Errorf("server cannot retransmit frames of stream %d: stream is lost after restoring", sid)
+			return
+		}
+	}
+	if e := p.socket.ResumeFrom(lastReceivedServerPosition); e != nil {
+		err = fmt.Errorf("server cannot retransmit from position %d: %s", lastReceivedServerPosition, e)
+		return
+	}
+	p.revived = false
+	return
+}
+
 func (p *resumeServerSocket) Start(ctx context.Context) error {
 	defer func() {
 		_ = p.Close()
@@ -38,6 +73,9 @@ func (p *resumeServerSocket) Start(ctx context.Context) error {
 
 // NewServerResume creates a new server-side socket with resume support.
 func NewServerResume(socket *DuplexRSocket, token []byte) ServerSocket {
+	if socket != nil {
+		socket.enableResume()
+	}
 	return &resumeServerSocket{
 		baseSocket: newBaseSocket(socket),
 		token:      token,
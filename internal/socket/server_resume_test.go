@@ -0,0 +1,32 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/fragmentation"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeServerSocket_Restored(t *testing.T) {
+	payload := framing.NewFramePayload(1, []byte("hello"), nil, framing.FlagNext|framing.FlagComplete)
+	connErr := framing.NewFrameError(0, common.ErrorCodeApplicationError, []byte("oops"))
+	restore := func(frames ...framing.Frame) ServerSocket {
+		sk := NewServerResume(NewServerDuplexRSocket(fragmentation.MaxFragment, nil), []byte("token"))
+		sk.Restore(0, 0, frames)
+		return sk
+	}
+
+	// Frames of streams which are not received by client cannot be handled after restoring.
+	_, err := restore(payload).Resume(0, 0)
+	assert.Error(t, err)
+
+	// Frames received by client are not retransmitted.
+	_, err = restore(payload).Resume(uint64(payload.Len()), 0)
+	assert.NoError(t, err)
+
+	// Frames of connection can be retransmitted.
+	_, err = restore(connErr).Resume(0, 0)
+	assert.NoError(t, err)
+}
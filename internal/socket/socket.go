@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
 	"github.com/rsocket/rsocket-go/internal/transport"
 	"github.com/rsocket/rsocket-go/logger"
 	"github.com/rsocket/rsocket-go/payload"
@@ -50,6 +52,8 @@ type ClientSocket interface {
 	Responder
 	// Setup setups current socket.
 	Setup(ctx context.Context, setup *SetupInfo) (err error)
+	// SendExtension sends an EXT frame of extended type.
+	SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool)
 }
 
 // ServerSocket represents a server-side socket.
@@ -66,6 +70,21 @@ type ServerSocket interface {
 	Start(ctx context.Context) error
 	// Token returns token of socket.
 	Token() (token []byte, ok bool)
+	// Resume prepares retransmission from the positions in a RESUME frame sent by client.
+	// It returns last received client position, or error if the gap cannot be bridged.
+	Resume(lastReceivedServerPosition, firstAvailableClientPosition uint64) (position uint64, err error)
+	// Snapshot returns last received position, first available position and retained frames.
+	Snapshot() (receivedPosition, firstAvailablePosition uint64, frames []framing.Frame)
+	// Restore restores positions and retained frames from a snapshot.
+	Restore(receivedPosition, firstAvailablePosition uint64, frames []framing.Frame)
+	// GoAway tells client to stop sending new requests.
+	GoAway(ttl time.Duration, metadata []byte)
+	// InFlight returns the number of streams which are not finished yet.
+	InFlight() int
+	// CloseWithError sends an ERROR frame to client, then closes current socket.
+	CloseWithError(code common.ErrorCode, data []byte) error
+	// SendExtension sends an EXT frame of extended type.
+	SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool)
 }
 
 // AbstractRSocket represents an abstract RSocket.
@@ -137,7 +156,9 @@ func (p *baseSocket) refreshLease(ttl time.Duration, n int64) {
 
 func (p *baseSocket) FireAndForget(message payload.Payload) {
 	if err := p.reqLease.allow(); err != nil {
+		p.socket.metrics.LeaseRejected()
 		logger.Warnf("request FireAndForget failed: %v\n", err)
+		return
 	}
 	p.socket.FireAndForget(message)
 }
@@ -148,6 +169,7 @@ func (p *baseSocket) MetadataPush(message payload.Payload) {
 
 func (p *baseSocket) RequestResponse(message payload.Payload) mono.Mono {
 	if err := p.reqLease.allow(); err != nil {
+		p.socket.metrics.LeaseRejected()
 		return mono.Error(err)
 	}
 	return p.socket.RequestResponse(message)
@@ -155,6 +177,7 @@ func (p *baseSocket) RequestResponse(message payload.Payload) mono.Mono {
 
 func (p *baseSocket) RequestStream(message payload.Payload) flux.Flux {
 	if err := p.reqLease.allow(); err != nil {
+		p.socket.metrics.LeaseRejected()
 		return flux.Error(err)
 	}
 	return p.socket.RequestStream(message)
@@ -162,11 +185,37 @@ func (p *baseSocket) RequestStream(message payload.Payload) flux.Flux {
 
 func (p *baseSocket) RequestChannel(messages rx.Publisher) flux.Flux {
 	if err := p.reqLease.allow(); err != nil {
+		p.socket.metrics.LeaseRejected()
 		return flux.Error(err)
 	}
 	return p.socket.RequestChannel(messages)
 }
 
+func (p *baseSocket) SendExtension(extendedType uint32, msg payload.Payload, canIgnore bool) {
+	p.socket.SendExtension(extendedType, msg, canIgnore)
+}
+
+func (p *baseSocket) Snapshot() (receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	return p.socket.snapshot()
+}
+
+func (p *baseSocket) Restore(receivedPosition, firstAvailablePosition uint64, frames []framing.Frame) {
+	p.socket.restore(receivedPosition, firstAvailablePosition, frames)
+}
+
+func (p *baseSocket) GoAway(ttl time.Duration, metadata []byte) {
+	p.socket.GoAway(ttl, metadata)
+}
+
+func (p *baseSocket) InFlight() int {
+	return p.socket.InFlight()
+}
+
+func (p *baseSocket) CloseWithError(code common.ErrorCode, data []byte) error {
+	_ = p.socket.CloseWithError(code, data)
+	return p.Close()
+}
+
 func (p *baseSocket) OnClose(fn func(error)) {
 	if fn != nil {
 		p.closers = append(p.closers, fn)
@@ -191,7 +240,12 @@ func (p *baseSocket) Close() (err error) {
 }
 
 func newBaseSocket(rawSocket *DuplexRSocket) *baseSocket {
-	return &baseSocket{
+	sk := &baseSocket{
 		socket: rawSocket,
 	}
+	// Requests from peer can send requests back by the connection in their context.
+	if rawSocket != nil {
+		rawSocket.conn = sk
+	}
+	return sk
 }
@@ -2,19 +2,20 @@ package transport
 
 import (
 	"io"
+	"net"
 	"time"
 
 	"github.com/rsocket/rsocket-go/internal/framing"
 )
 
-// Conn is connection for RSocket.
-type Conn interface {
+// RawConn is a connection which carries RSocket frames, connections of custom transports implement it.
+type RawConn interface {
 	io.Closer
 	// SetDeadline set deadline for current connection.
 	// After this deadline, connection will be closed.
 	SetDeadline(deadline time.Time) error
-	// SetCounter bind a counter which can count r/w bytes.
-	SetCounter(c *Counter)
+	// RemoteAddr returns the remote network address.
+	RemoteAddr() net.Addr
 	// Read reads next frame from Conn.
 	Read() (framing.Frame, error)
 	// Write writes a frame to Conn.
@@ -22,3 +23,44 @@ type Conn interface {
 	// Flush.
 	Flush() error
 }
+
+// Conn is connection for RSocket.
+type Conn interface {
+	RawConn
+	// SetCounter bind a counter which can count r/w bytes.
+	SetCounter(c *Counter)
+}
+
+// NewTransport creates a transport from a connection of custom transport.
+func NewTransport(c RawConn) *Transport {
+	if conn, ok := c.(Conn); ok {
+		return newTransportClient(conn)
+	}
+	return newTransportClient(&countingConn{RawConn: c})
+}
+
+// countingConn counts r/w bytes of frames for resuming.
+type countingConn struct {
+	RawConn
+	counter *Counter
+}
+
+func (p *countingConn) SetCounter(c *Counter) {
+	p.counter = c
+}
+
+func (p *countingConn) Read() (f framing.Frame, err error) {
+	f, err = p.RawConn.Read()
+	if err == nil && p.counter != nil && f.CanResume() {
+		p.counter.incrReadBytes(f.Len())
+	}
+	return
+}
+
+func (p *countingConn) Write(frame framing.Frame) (err error) {
+	err = p.RawConn.Write(frame)
+	if err == nil && p.counter != nil && frame.CanResume() {
+		p.counter.incrWriteBytes(frame.Len())
+	}
+	return
+}
@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/logger"
+	"go.uber.org/atomic"
+)
+
+const memBufferSize = 256
+
+var (
+	errMemConnClosed = errors.New("mem connection closed")
+	errMemTimeout    = memTimeoutError{}
+)
+
+type memTimeoutError struct{}
+
+func (memTimeoutError) Error() string {
+	return "mem connection i/o timeout"
+}
+
+func (memTimeoutError) Timeout() bool {
+	return true
+}
+
+func (memTimeoutError) Temporary() bool {
+	return true
+}
+
+// memAddr is address of an in-process connection.
+type memAddr string
+
+func (p memAddr) Network() string {
+	return schemaMem
+}
+
+func (p memAddr) String() string {
+	return string(p)
+}
+
+// memPipe is shared by both sides of an in-process connection, closing either side closes both.
+type memPipe struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (p *memPipe) close() {
+	p.once.Do(func() {
+		close(p.closed)
+	})
+}
+
+// memFrame is a frame in flight, it is carried in bytes if serialization is enabled.
+type memFrame struct {
+	frame framing.Frame
+	raw   []byte
+}
+
+type memConn struct {
+	pipe      *memPipe
+	addr      memAddr
+	rcv       chan memFrame
+	snd       chan<- memFrame
+	serialize bool
+	deadline  *atomic.Int64
+	counter   *Counter
+}
+
+func (p *memConn) SetCounter(c *Counter) {
+	p.counter = c
+}
+
+func (p *memConn) RemoteAddr() net.Addr {
+	return p.addr
+}
+
+func (p *memConn) SetDeadline(deadline time.Time) error {
+	p.deadline.Store(deadline.UnixNano())
+	return nil
+}
+
+func (p *memConn) Read() (f framing.Frame, err error) {
+	var timeout <-chan time.Time
+	if deadline := p.deadline.Load(); deadline > 0 {
+		timer := time.NewTimer(time.Until(time.Unix(0, deadline)))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var next memFrame
+	select {
+	case next = <-p.rcv:
+	case <-p.pipe.closed:
+		// Frames sent before closing should be delivered.
+		select {
+		case next = <-p.rcv:
+		default:
+			err = io.EOF
+			return
+		}
+	case <-timeout:
+		err = errMemTimeout
+		return
+	}
+	f, err = p.decode(next)
+	if err != nil {
+		err = errors.Wrap(err, "read frame failed")
+		return
+	}
+	if p.counter != nil && f.CanResume() {
+		p.counter.incrReadBytes(f.Len())
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("<--- rcv: %s\n", f)
+	}
+	return
+}
+
+func (p *memConn) decode(next memFrame) (f framing.Frame, err error) {
+	if next.raw == nil {
+		f = next.frame
+		return
+	}
+	bf := common.NewByteBuff()
+	if _, err = bf.Write(next.raw[framing.HeaderLen:]); err != nil {
+		return
+	}
+	f, err = framing.NewFromBase(framing.NewBaseFrame(framing.ParseFrameHeader(next.raw), bf))
+	if err != nil {
+		return
+	}
+	err = f.Validate()
+	return
+}
+
+func (p *memConn) Write(frame framing.Frame) (err error) {
+	next := memFrame{frame: frame}
+	if p.serialize {
+		bf := &bytes.Buffer{}
+		if _, err = frame.WriteTo(bf); err != nil {
+			err = errors.Wrap(err, "write frame failed")
+			return
+		}
+		next.raw = bf.Bytes()
+	}
+	select {
+	case <-p.pipe.closed:
+		err = errors.Wrap(errMemConnClosed, "write frame failed")
+		return
+	default:
+	}
+	select {
+	case p.snd <- next:
+	case <-p.pipe.closed:
+		err = errors.Wrap(errMemConnClosed, "write frame failed")
+		return
+	}
+	if p.counter != nil && frame.CanResume() {
+		p.counter.incrWriteBytes(frame.Len())
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("---> snd: %s\n", frame)
+	}
+	return
+}
+
+func (p *memConn) Flush() error {
+	return nil
+}
+
+func (p *memConn) Close() error {
+	p.pipe.close()
+	return nil
+}
+
+// newMemConnPair returns both sides of an in-process connection.
+// Frames are passed as objects, or as bytes if serialize is true which helps to find bugs of codec.
+func newMemConnPair(name string, serialize bool) (client, server *memConn) {
+	pipe := &memPipe{
+		closed: make(chan struct{}),
+	}
+	c2s := make(chan memFrame, memBufferSize)
+	s2c := make(chan memFrame, memBufferSize)
+	client = &memConn{
+		pipe:      pipe,
+		addr:      memAddr(name),
+		rcv:       s2c,
+		snd:       c2s,
+		serialize: serialize,
+		deadline:  atomic.NewInt64(0),
+	}
+	server = &memConn{
+		pipe:      pipe,
+		addr:      memAddr(name),
+		rcv:       c2s,
+		snd:       s2c,
+		serialize: serialize,
+		deadline:  atomic.NewInt64(0),
+	}
+	return
+}
@@ -23,6 +23,10 @@ func (p *tcpConn) SetCounter(c *Counter) {
 	p.counter = c
 }
 
+func (p *tcpConn) RemoteAddr() net.Addr {
+	return p.rawConn.RemoteAddr()
+}
+
 func (p *tcpConn) SetDeadline(deadline time.Time) error {
 	return p.rawConn.SetReadDeadline(deadline)
 }
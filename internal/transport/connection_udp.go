@@ -0,0 +1,487 @@
+package transport
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/logger"
+	"go.uber.org/atomic"
+)
+
+// Packet layout of udp transport:
+// type(1) | flags(1) | lane(4) | seq(4) | chunk of frame
+// A lane carries frames of the streams hashed to it, packets are ordered and retransmitted per lane,
+// so that a lost packet only stalls the RSocket streams of its own lane.
+// Lane 0 is reserved for frames of connection (stream id 0).
+const (
+	udpPacketData  byte = 0x01
+	udpPacketAck   byte = 0x02
+	udpPacketClose byte = 0x03
+
+	// udpFlagLast marks the last chunk of a frame.
+	udpFlagLast byte = 0x01
+
+	udpHeaderLen  = 10
+	udpMaxChunk   = 1200
+	udpWindow     = 512
+	udpLanes      = 16
+	udpRTO        = 100 * time.Millisecond
+	udpMaxRTO     = 2 * time.Second
+	udpMaxRetries = 20
+	udpTick       = 20 * time.Millisecond
+	udpLinger     = 3 * time.Second
+)
+
+var (
+	errUDPConnClosed = errors.New("udp connection closed")
+	errUDPTimeout    = udpTimeoutError{}
+	errUDPNoAck      = errors.New("udp connection lost: retransmission exceeded")
+)
+
+type udpTimeoutError struct{}
+
+func (udpTimeoutError) Error() string {
+	return "udp connection i/o timeout"
+}
+
+func (udpTimeoutError) Timeout() bool {
+	return true
+}
+
+func (udpTimeoutError) Temporary() bool {
+	return true
+}
+
+type udpPending struct {
+	packet  []byte
+	sentAt  time.Time
+	retries int
+}
+
+// udpLane keeps outbound packets in flight and reorders inbound chunks of a lane.
+// Each lane has its own send window, so that a lossy lane doesn't block writes of other lanes.
+type udpLane struct {
+	// Chunks of a frame must be sequential in its lane.
+	writeLock sync.Mutex
+	nextSeq   uint32
+	unacked   map[uint32]*udpPending
+
+	expected uint32
+	pending  map[uint32]udpChunk
+	partial  []byte
+}
+
+type udpChunk struct {
+	last bool
+	data []byte
+}
+
+// udpConn is a reliable connection over datagrams, frames are delivered in order per lane.
+type udpConn struct {
+	remote   net.Addr
+	send     func(packet []byte) error
+	onClose  func()
+	counter  *Counter
+	deadline *atomic.Int64
+
+	mu     sync.Mutex
+	window *sync.Cond
+	lanes  [udpLanes]udpLane
+	// Frames of other lanes are held by server until the first frame of connection lane (SETUP or RESUME) is delivered.
+	connReady bool
+	held      []framing.Frame
+	ready     []framing.Frame
+	notify    chan struct{}
+	closing   bool
+	err       error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (p *udpConn) SetCounter(c *Counter) {
+	p.counter = c
+}
+
+func (p *udpConn) RemoteAddr() net.Addr {
+	return p.remote
+}
+
+func (p *udpConn) SetDeadline(deadline time.Time) error {
+	p.deadline.Store(deadline.UnixNano())
+	return nil
+}
+
+func (p *udpConn) Read() (f framing.Frame, err error) {
+	var timeout <-chan time.Time
+	if deadline := p.deadline.Load(); deadline > 0 {
+		timer := time.NewTimer(time.Until(time.Unix(0, deadline)))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		p.mu.Lock()
+		if len(p.ready) > 0 {
+			f = p.ready[0]
+			p.ready[0] = nil
+			p.ready = p.ready[1:]
+			p.mu.Unlock()
+			break
+		}
+		closing, e := p.closing, p.err
+		p.mu.Unlock()
+		if e != nil {
+			err = e
+			return
+		}
+		if closing {
+			err = io.EOF
+			return
+		}
+		select {
+		case <-p.notify:
+		case <-p.done:
+		case <-timeout:
+			err = errUDPTimeout
+			return
+		}
+	}
+	if p.counter != nil && f.CanResume() {
+		p.counter.incrReadBytes(f.Len())
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("<--- rcv: %s\n", f)
+	}
+	return
+}
+
+func (p *udpConn) Write(frame framing.Frame) (err error) {
+	raw := frame.Bytes()
+	lane := udpLaneOf(frame.Header().StreamID())
+
+	l := &p.lanes[lane]
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+	for offset := 0; ; offset += udpMaxChunk {
+		end := offset + udpMaxChunk
+		var flags byte
+		if end >= len(raw) {
+			end = len(raw)
+			flags = udpFlagLast
+		}
+		if err = p.writeChunk(lane, flags, raw[offset:end]); err != nil {
+			err = errors.Wrap(err, "write frame failed")
+			return
+		}
+		if flags == udpFlagLast {
+			break
+		}
+	}
+	if p.counter != nil && frame.CanResume() {
+		p.counter.incrWriteBytes(frame.Len())
+	}
+	if logger.IsDebugEnabled() {
+		logger.Debugf("---> snd: %s\n", frame)
+	}
+	return
+}
+
+func (p *udpConn) writeChunk(lane uint32, flags byte, chunk []byte) error {
+	l := &p.lanes[lane]
+	p.mu.Lock()
+	for len(l.unacked) >= udpWindow && !p.closing {
+		p.window.Wait()
+	}
+	if p.closing {
+		p.mu.Unlock()
+		return errUDPConnClosed
+	}
+	seq := l.nextSeq
+	l.nextSeq++
+	packet := make([]byte, udpHeaderLen+len(chunk))
+	packet[0] = udpPacketData
+	packet[1] = flags
+	binary.BigEndian.PutUint32(packet[2:], lane)
+	binary.BigEndian.PutUint32(packet[6:], seq)
+	copy(packet[udpHeaderLen:], chunk)
+	if l.unacked == nil {
+		l.unacked = make(map[uint32]*udpPending)
+	}
+	l.unacked[seq] = &udpPending{
+		packet: packet,
+		sentAt: time.Now(),
+	}
+	p.mu.Unlock()
+	return p.send(packet)
+}
+
+func (p *udpConn) Flush() error {
+	return nil
+}
+
+// Close closes current connection after frames in flight are acknowledged.
+func (p *udpConn) Close() error {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closing = true
+	p.window.Broadcast()
+	p.mu.Unlock()
+	p.wakeup()
+	go func() {
+		deadline := time.Now().Add(udpLinger)
+		for time.Now().Before(deadline) && p.inflight() > 0 {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(udpTick):
+			}
+		}
+		_ = p.send([]byte{udpPacketClose})
+		p.shutdown(nil)
+	}()
+	return nil
+}
+
+func (p *udpConn) inflight() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for i := range p.lanes {
+		n += len(p.lanes[i].unacked)
+	}
+	return n
+}
+
+// shutdown releases current connection immediately.
+func (p *udpConn) shutdown(err error) {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closing = true
+		if p.err == nil {
+			p.err = err
+		}
+		for i := range p.lanes {
+			p.lanes[i].unacked = nil
+		}
+		p.window.Broadcast()
+		p.mu.Unlock()
+		close(p.done)
+		if p.onClose != nil {
+			p.onClose()
+		}
+	})
+}
+
+func (p *udpConn) wakeup() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// handle handles an inbound packet, it should be called by only one goroutine.
+func (p *udpConn) handle(packet []byte) {
+	if len(packet) < 1 {
+		return
+	}
+	switch packet[0] {
+	case udpPacketClose:
+		p.shutdown(nil)
+		p.wakeup()
+	case udpPacketAck:
+		if len(packet) < udpHeaderLen {
+			return
+		}
+		lane, seq := binary.BigEndian.Uint32(packet[2:]), binary.BigEndian.Uint32(packet[6:])
+		if lane >= udpLanes {
+			return
+		}
+		p.mu.Lock()
+		l := &p.lanes[lane]
+		if _, ok := l.unacked[seq]; ok {
+			delete(l.unacked, seq)
+			p.window.Broadcast()
+		}
+		p.mu.Unlock()
+	case udpPacketData:
+		if len(packet) < udpHeaderLen {
+			return
+		}
+		accepted, err := p.receive(packet)
+		if err != nil {
+			p.shutdown(err)
+			p.wakeup()
+			return
+		}
+		if !accepted {
+			// Not acknowledged, sender will retransmit it later.
+			return
+		}
+		ack := make([]byte, udpHeaderLen)
+		ack[0] = udpPacketAck
+		copy(ack[2:], packet[2:udpHeaderLen])
+		_ = p.send(ack)
+	}
+}
+
+// receive buffers an inbound data packet, it returns false if packet is dropped and should not be acknowledged.
+func (p *udpConn) receive(packet []byte) (accepted bool, err error) {
+	lane, seq := binary.BigEndian.Uint32(packet[2:]), binary.BigEndian.Uint32(packet[6:])
+	if lane >= udpLanes {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l := &p.lanes[lane]
+	if seq < l.expected {
+		// Duplicated by retransmission.
+		accepted = true
+		return
+	}
+	if seq-l.expected >= udpWindow {
+		// Beyond receive window.
+		return
+	}
+	accepted = true
+	if l.pending == nil {
+		l.pending = make(map[uint32]udpChunk)
+	}
+	if _, ok := l.pending[seq]; !ok {
+		data := make([]byte, len(packet)-udpHeaderLen)
+		copy(data, packet[udpHeaderLen:])
+		l.pending[seq] = udpChunk{
+			last: packet[1]&udpFlagLast == udpFlagLast,
+			data: data,
+		}
+	}
+	for {
+		chunk, ok := l.pending[l.expected]
+		if !ok {
+			break
+		}
+		delete(l.pending, l.expected)
+		l.expected++
+		l.partial = append(l.partial, chunk.data...)
+		if !chunk.last {
+			continue
+		}
+		raw := l.partial
+		l.partial = nil
+		var f framing.Frame
+		f, err = decodeUDPFrame(raw)
+		if err != nil {
+			err = errors.Wrap(err, "read frame failed")
+			return
+		}
+		p.deliver(lane, f)
+	}
+	return
+}
+
+func (p *udpConn) deliver(lane uint32, f framing.Frame) {
+	if lane != 0 && !p.connReady {
+		p.held = append(p.held, f)
+		return
+	}
+	p.ready = append(p.ready, f)
+	if lane == 0 && !p.connReady {
+		p.connReady = true
+		p.ready = append(p.ready, p.held...)
+		p.held = nil
+	}
+	p.wakeup()
+}
+
+// loopRetransmit resends packets which are not acknowledged in time.
+func (p *udpConn) loopRetransmit() {
+	ticker := time.NewTicker(udpTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			resend, lost := p.expired(now)
+			if lost {
+				p.shutdown(errUDPNoAck)
+				return
+			}
+			for _, it := range resend {
+				_ = p.send(it)
+			}
+		}
+	}
+}
+
+// udpLaneOf returns the lane of stream, lane 0 is used by connection only.
+func udpLaneOf(sid uint32) uint32 {
+	if sid == 0 {
+		return 0
+	}
+	return 1 + (sid-1)%(udpLanes-1)
+}
+
+// expired returns packets which should be resent, lost will be true if any packet exceeds max retries.
+func (p *udpConn) expired(now time.Time) (resend [][]byte, lost bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.lanes {
+		for _, it := range p.lanes[i].unacked {
+			rto := udpRTO << uint(it.retries)
+			if rto > udpMaxRTO {
+				rto = udpMaxRTO
+			}
+			if now.Sub(it.sentAt) < rto {
+				continue
+			}
+			if it.retries >= udpMaxRetries {
+				lost = true
+				return
+			}
+			it.retries++
+			it.sentAt = now
+			resend = append(resend, it.packet)
+		}
+	}
+	return
+}
+
+func decodeUDPFrame(raw []byte) (f framing.Frame, err error) {
+	if len(raw) < framing.HeaderLen {
+		err = common.ErrInvalidFrame
+		return
+	}
+	bf := common.NewByteBuff()
+	if _, err = bf.Write(raw[framing.HeaderLen:]); err != nil {
+		return
+	}
+	f, err = framing.NewFromBase(framing.NewBaseFrame(framing.ParseFrameHeader(raw), bf))
+	if err != nil {
+		return
+	}
+	err = f.Validate()
+	return
+}
+
+func newUDPConn(remote net.Addr, accepted bool, send func(packet []byte) error, onClose func()) *udpConn {
+	c := &udpConn{
+		remote:    remote,
+		connReady: !accepted,
+		send:      send,
+		onClose:   onClose,
+		deadline:  atomic.NewInt64(0),
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	c.window = sync.NewCond(&c.mu)
+	go c.loopRetransmit()
+	return c
+}
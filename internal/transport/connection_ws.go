@@ -2,6 +2,7 @@ package transport
 
 import (
 	"io"
+	"net"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -16,6 +17,10 @@ type wsConnection struct {
 	counter *Counter
 }
 
+func (p *wsConnection) RemoteAddr() net.Addr {
+	return p.c.RemoteAddr()
+}
+
 func (p *wsConnection) SetCounter(c *Counter) {
 	p.counter = c
 }
@@ -47,6 +52,9 @@ func (p *wsConnection) Read() (f framing.Frame, err error) {
 		return
 	}
 	base := framing.NewBaseFrame(header, bf)
+	if p.counter != nil && base.CanResume() {
+		p.counter.incrReadBytes(base.Len())
+	}
 	f, err = framing.NewFromBase(base)
 	if err != nil {
 		err = errors.Wrap(err, "read frame failed")
@@ -76,6 +84,9 @@ func (p *wsConnection) Write(frame framing.Frame) (err error) {
 		err = errors.Wrap(err, "write frame failed")
 		return
 	}
+	if p.counter != nil && frame.CanResume() {
+		p.counter.incrWriteBytes(frame.Len())
+	}
 	if logger.IsDebugEnabled() {
 		logger.Debugf("---> snd: %s\n", frame)
 	}
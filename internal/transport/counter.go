@@ -19,6 +19,11 @@ func (p Counter) WriteBytes() uint64 {
 	return p.w.Load()
 }
 
+// SetReadBytes resets the number of bytes that have been read.
+func (p Counter) SetReadBytes(n uint64) {
+	p.r.Store(n)
+}
+
 func (p Counter) incrWriteBytes(n int) {
 	p.w.Add(uint64(n))
 }
@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// ClientTransportFactory creates a client-side transport for uri.
+	ClientTransportFactory = func(ctx context.Context, uri *URI, tc *tls.Config, headers map[string][]string) (*Transport, error)
+	// ServerTransportFactory creates a server-side transport for uri.
+	ServerTransportFactory = func(uri *URI, tc *tls.Config) (ServerTransport, error)
+	// ClientTransportFunc creates a client-side transport, it is called for each connection of a client.
+	ClientTransportFunc = func(ctx context.Context) (*Transport, error)
+	// Dialer dials a connection to the address on the named network.
+	Dialer = func(ctx context.Context, network, addr string) (net.Conn, error)
+)
+
+var factories = struct {
+	sync.RWMutex
+	clients map[string]ClientTransportFactory
+	servers map[string]ServerTransportFactory
+}{
+	clients: make(map[string]ClientTransportFactory),
+	servers: make(map[string]ServerTransportFactory),
+}
+
+// Register registers factories of transport for URI scheme, factories of an existing scheme will be replaced.
+// A nil factory means the side is unsupported.
+func Register(scheme string, client ClientTransportFactory, server ServerTransportFactory) {
+	scheme = strings.ToLower(scheme)
+	factories.Lock()
+	defer factories.Unlock()
+	if client == nil {
+		delete(factories.clients, scheme)
+	} else {
+		factories.clients[scheme] = client
+	}
+	if server == nil {
+		delete(factories.servers, scheme)
+	} else {
+		factories.servers[scheme] = server
+	}
+}
+
+func clientFactory(scheme string) (f ClientTransportFactory, ok bool) {
+	factories.RLock()
+	f, ok = factories.clients[strings.ToLower(scheme)]
+	factories.RUnlock()
+	return
+}
+
+func serverFactory(scheme string) (f ServerTransportFactory, ok bool) {
+	factories.RLock()
+	f, ok = factories.servers[strings.ToLower(scheme)]
+	factories.RUnlock()
+	return
+}
+
+func init() {
+	Register(schemaTCP, func(_ context.Context, uri *URI, tc *tls.Config, _ map[string][]string) (*Transport, error) {
+		return newTCPClientTransport(schemaTCP, uri.Host, tc)
+	}, func(uri *URI, tc *tls.Config) (ServerTransport, error) {
+		return newTCPServerTransport(schemaTCP, uri.Host, tc), nil
+	})
+	Register(schemaUNIX, func(_ context.Context, uri *URI, tc *tls.Config, _ map[string][]string) (*Transport, error) {
+		return newTCPClientTransport(schemaUNIX, uri.Path, tc)
+	}, func(uri *URI, tc *tls.Config) (ServerTransport, error) {
+		return newTCPServerTransport(schemaUNIX, uri.Path, tc), nil
+	})
+	Register(schemaWebsocket, func(_ context.Context, uri *URI, tc *tls.Config, headers map[string][]string) (*Transport, error) {
+		if tc == nil {
+			return newWebsocketClientTransport(uri.pp().String(), nil, headers)
+		}
+		var clone = (url.URL)(*uri)
+		clone.Scheme = schemaWebsocketSecure
+		return newWebsocketClientTransport(clone.String(), tc, headers)
+	}, func(uri *URI, tc *tls.Config) (ServerTransport, error) {
+		return newWebsocketServerTransport(uri.Host, uri.Path, tc), nil
+	})
+	Register(schemaWebsocketSecure, func(_ context.Context, uri *URI, tc *tls.Config, headers map[string][]string) (*Transport, error) {
+		if tc == nil {
+			tc = tlsInsecure
+		}
+		return newWebsocketClientTransport(uri.pp().String(), tc, headers)
+	}, func(uri *URI, tc *tls.Config) (ServerTransport, error) {
+		if tc == nil {
+			return nil, errors.Errorf("missing TLS Config for proto %s", schemaWebsocketSecure)
+		}
+		return newWebsocketServerTransport(uri.Host, uri.Path, tc), nil
+	})
+	Register(schemaUDP, func(_ context.Context, uri *URI, _ *tls.Config, _ map[string][]string) (*Transport, error) {
+		return newUDPClientTransport(uri.Host)
+	}, func(uri *URI, _ *tls.Config) (ServerTransport, error) {
+		return newUDPServerTransport(uri.Host), nil
+	})
+	Register(schemaMem, func(_ context.Context, uri *URI, _ *tls.Config, _ map[string][]string) (*Transport, error) {
+		return newMemClientTransport(uri.Host, uri.serialize())
+	}, func(uri *URI, _ *tls.Config) (ServerTransport, error) {
+		return newMemServerTransport(uri.Host, uri.serialize()), nil
+	})
+}
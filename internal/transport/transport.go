@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/instrument"
 	"github.com/rsocket/rsocket-go/logger"
 )
 
@@ -38,6 +39,8 @@ type Transport struct {
 	maxLifetime time.Duration
 	lastRcvPos  uint64
 	once        sync.Once
+	traffic     *Counter
+	metrics     *instrument.Connection
 
 	hSetup           FrameHandler
 	hResume          FrameHandler
@@ -54,6 +57,7 @@ type Transport struct {
 	hError0          FrameHandler
 	hCancel          FrameHandler
 	hKeepalive       FrameHandler
+	hExt             FrameHandler
 }
 
 // HandleDisaster registers handler when receiving frame of DISASTER Error with zero StreamID.
@@ -61,6 +65,21 @@ func (p *Transport) HandleDisaster(handler FrameHandler) {
 	p.hError0 = handler
 }
 
+// SetMetrics binds a metrics recorder for current transport, it should be called before using.
+func (p *Transport) SetMetrics(recorder *instrument.Recorder) {
+	if p.metrics != nil || recorder == nil {
+		return
+	}
+	p.metrics = recorder.Connection(p.traffic)
+}
+
+// Resumable returns false if positions of frames cannot be kept by current connection, so that it cannot be resumed.
+// Frames of udp connection are delivered in order per lane only.
+func (p *Transport) Resumable() bool {
+	_, ok := p.conn.(*udpConn)
+	return !ok
+}
+
 // Connection returns current connection.
 func (p *Transport) Connection() Conn {
 	return p.conn
@@ -86,10 +105,13 @@ func (p *Transport) Send(frame framing.Frame, flush bool) (err error) {
 		err = errTransportClosed
 		return
 	}
+	size := frame.Len()
 	err = p.conn.Write(frame)
 	if err != nil {
 		return
 	}
+	p.traffic.incrWriteBytes(size)
+	p.metrics.FrameWritten(frame.Header().Type())
 	if !flush {
 		return
 	}
@@ -111,6 +133,7 @@ func (p *Transport) Flush() (err error) {
 func (p *Transport) Close() (err error) {
 	p.once.Do(func() {
 		err = p.conn.Close()
+		p.metrics.Close()
 	})
 	return
 }
@@ -124,6 +147,9 @@ func (p *Transport) ReadFirst(ctx context.Context) (frame framing.Frame, err err
 		frame, err = p.conn.Read()
 		if err != nil {
 			err = errors.Wrap(err, "read first frame failed")
+		} else {
+			p.traffic.incrReadBytes(frame.Len())
+			p.metrics.FrameRead(frame.Header().Type())
 		}
 	}
 	if err != nil {
@@ -148,6 +174,8 @@ L:
 			if err != nil {
 				break L
 			}
+			p.traffic.incrReadBytes(f.Len())
+			p.metrics.FrameRead(f.Header().Type())
 			err = p.DeliveryFrame(ctx, f)
 			if err != nil {
 				break L
@@ -233,6 +261,11 @@ func (p *Transport) HandleKeepalive(handler FrameHandler) {
 	p.hKeepalive = handler
 }
 
+// HandleExt registers handler when receiving a frame of Ext.
+func (p *Transport) HandleExt(handler FrameHandler) {
+	p.hExt = handler
+}
+
 // DeliveryFrame delivery incoming frames.
 func (p *Transport) DeliveryFrame(_ context.Context, frame framing.Frame) (err error) {
 	header := frame.Header()
@@ -286,6 +319,8 @@ func (p *Transport) DeliveryFrame(_ context.Context, frame framing.Frame) (err e
 		handler = p.hKeepalive
 	case framing.FrameTypeLease:
 		handler = p.hLease
+	case framing.FrameTypeExt:
+		handler = p.hExt
 	}
 
 	// Set deadline.
@@ -297,6 +332,11 @@ func (p *Transport) DeliveryFrame(_ context.Context, frame framing.Frame) (err e
 
 	// missing handler
 	if handler == nil {
+		// Frames which are not understood should be skipped if they carry IGNORE flag.
+		if header.Flag().Check(framing.FlagIgnore) {
+			logger.Debugf("rsocket.Transport: ignore frame %s\n", frame)
+			return
+		}
 		err = errors.Errorf("missing frame handler: type=%s", t)
 		return
 	}
@@ -313,5 +353,6 @@ func newTransportClient(c Conn) *Transport {
 	return &Transport{
 		conn:        c,
 		maxLifetime: common.DefaultKeepaliveMaxLifetime,
+		traffic:     NewCounter(),
 	}
 }
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var memServers = struct {
+	sync.Mutex
+	m map[string]*memServerTransport
+}{
+	m: make(map[string]*memServerTransport),
+}
+
+type memServerTransport struct {
+	name      string
+	serialize bool
+	acceptor  ServerTransportAcceptor
+	conns     chan *memConn
+	done      chan struct{}
+	onceClose sync.Once
+}
+
+func (p *memServerTransport) Accept(acceptor ServerTransportAcceptor) {
+	p.acceptor = acceptor
+}
+
+func (p *memServerTransport) Close() (err error) {
+	p.onceClose.Do(func() {
+		close(p.done)
+		memServers.Lock()
+		if memServers.m[p.name] == p {
+			delete(memServers.m, p.name)
+		}
+		memServers.Unlock()
+	})
+	return
+}
+
+func (p *memServerTransport) Listen(ctx context.Context, notifier chan<- struct{}) (err error) {
+	memServers.Lock()
+	if _, ok := memServers.m[p.name]; ok {
+		memServers.Unlock()
+		err = errors.Errorf("server listen failed: mem://%s is in use", p.name)
+		return
+	}
+	memServers.m[p.name] = p
+	memServers.Unlock()
+
+	defer func() {
+		_ = p.Close()
+	}()
+
+	notifier <- struct{}{}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		case c := <-p.conns:
+			go p.acceptor(ctx, newTransportClient(c))
+		}
+	}
+}
+
+// dial connects to the server, frames will be serialized if either side requires.
+func (p *memServerTransport) dial(serialize bool) (*Transport, error) {
+	client, server := newMemConnPair(p.name, serialize || p.serialize)
+	select {
+	case p.conns <- server:
+		return newTransportClient(client), nil
+	case <-p.done:
+		return nil, errors.Errorf("dial mem://%s failed: server closed", p.name)
+	}
+}
+
+func newMemServerTransport(name string, serialize bool) *memServerTransport {
+	return &memServerTransport{
+		name:      name,
+		serialize: serialize,
+		conns:     make(chan *memConn),
+		done:      make(chan struct{}),
+	}
+}
+
+func newMemClientTransport(name string, serialize bool) (*Transport, error) {
+	memServers.Lock()
+	s, ok := memServers.m[name]
+	memServers.Unlock()
+	if !ok {
+		return nil, errors.Errorf("dial mem://%s failed: no server", name)
+	}
+	return s.dial(serialize)
+}
@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemConn(t *testing.T) {
+	for _, serialize := range []bool{false, true} {
+		client, server := newMemConnPair("test", serialize)
+		sent := framing.NewFramePayload(1, []byte("hello"), []byte("world"), framing.FlagNext)
+		require.NoError(t, client.Write(sent))
+		f, err := server.Read()
+		require.NoError(t, err)
+		require.IsType(t, &framing.FramePayload{}, f)
+		assert.Equal(t, serialize, f != framing.Frame(sent), "frame should be passed as object unless serialized")
+		assert.Equal(t, sent.Bytes(), f.Bytes())
+		assert.Equal(t, "mem", server.RemoteAddr().Network())
+
+		// read deadline
+		require.NoError(t, client.SetDeadline(time.Now().Add(10*time.Millisecond)))
+		_, err = client.Read()
+		require.Error(t, err)
+		assert.True(t, err.(net.Error).Timeout())
+
+		// frames sent before closing are still delivered.
+		require.NoError(t, server.Write(framing.NewFrameCancel(1)))
+		require.NoError(t, server.Close())
+		require.NoError(t, client.SetDeadline(time.Time{}))
+		f, err = client.Read()
+		require.NoError(t, err)
+		assert.IsType(t, &framing.FrameCancel{}, f)
+		_, err = client.Read()
+		assert.Equal(t, io.EOF, err)
+		assert.Error(t, client.Write(framing.NewFrameCancel(1)))
+	}
+}
+
+func TestMemTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	uri, err := ParseURI("mem://test-transport?serialize=true")
+	require.NoError(t, err)
+
+	_, err = uri.MakeClientTransport(ctx, nil, nil)
+	assert.Error(t, err, "should fail without server")
+
+	st, err := uri.MakeServerTransport(nil)
+	require.NoError(t, err)
+	accepted := make(chan *Transport, 1)
+	st.Accept(func(ctx context.Context, tp *Transport) {
+		accepted <- tp
+	})
+	notifier := make(chan struct{})
+	served := make(chan error, 1)
+	go func() {
+		served <- st.Listen(ctx, notifier)
+	}()
+	<-notifier
+
+	dup, err := uri.MakeServerTransport(nil)
+	require.NoError(t, err)
+	assert.Error(t, dup.Listen(ctx, make(chan struct{}, 1)), "name should be in use")
+
+	tp, err := uri.MakeClientTransport(ctx, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, tp.Send(framing.NewFrameCancel(1), true))
+	select {
+	case server := <-accepted:
+		f, err := server.Connection().Read()
+		require.NoError(t, err)
+		assert.IsType(t, &framing.FrameCancel{}, f)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "accept timeout")
+	}
+
+	require.NoError(t, st.Close())
+	assert.NoError(t, <-served)
+	_, err = uri.MakeClientTransport(ctx, nil, nil)
+	assert.Error(t, err, "should fail after server closed")
+}
@@ -36,7 +36,9 @@ func (p *tcpServerTransport) Close() (err error) {
 }
 
 func (p *tcpServerTransport) Listen(ctx context.Context, notifier chan<- struct{}) (err error) {
-	if p.tls == nil {
+	if p.listener != nil {
+		// Listener is provided by user.
+	} else if p.tls == nil {
 		p.listener, err = net.Listen(p.network, p.addr)
 		if err != nil {
 			err = errors.Wrap(err, "server listen failed")
@@ -107,6 +109,20 @@ func newTCPServerTransport(network, addr string, c *tls.Config) *tcpServerTransp
 	}
 }
 
+// NewTCPServerTransport creates a server-side transport which accepts connections from listener.
+// Frames are length-prefixed as TCP transport.
+func NewTCPServerTransport(listener net.Listener) ServerTransport {
+	return &tcpServerTransport{
+		listener: listener,
+	}
+}
+
+// NewTCPClientTransport creates a client-side transport over an established connection.
+// Frames are length-prefixed as TCP transport.
+func NewTCPClientTransport(c net.Conn) *Transport {
+	return newTransportClient(newTCPRConnection(c))
+}
+
 func newTCPClientTransport(network, addr string, tlsConfig *tls.Config) (tp *Transport, err error) {
 	var rawConn net.Conn
 	if tlsConfig == nil {
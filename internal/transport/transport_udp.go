@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const udpReadBufferSize = 64 * 1024
+
+type udpServerTransport struct {
+	addr      string
+	acceptor  ServerTransportAcceptor
+	conn      *net.UDPConn
+	locker    sync.Mutex
+	sessions  map[string]*udpConn
+	onceClose sync.Once
+}
+
+func (p *udpServerTransport) Accept(acceptor ServerTransportAcceptor) {
+	p.acceptor = acceptor
+}
+
+func (p *udpServerTransport) Close() (err error) {
+	p.onceClose.Do(func() {
+		p.locker.Lock()
+		sessions := p.sessions
+		p.sessions = nil
+		p.locker.Unlock()
+		// Sessions share the socket of server.
+		for _, it := range sessions {
+			it.shutdown(errUDPConnClosed)
+		}
+		if p.conn != nil {
+			err = p.conn.Close()
+		}
+	})
+	return
+}
+
+func (p *udpServerTransport) Listen(ctx context.Context, notifier chan<- struct{}) (err error) {
+	addr, err := net.ResolveUDPAddr("udp", p.addr)
+	if err != nil {
+		err = errors.Wrap(err, "server listen failed")
+		return
+	}
+	p.conn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		err = errors.Wrap(err, "server listen failed")
+		return
+	}
+	notifier <- struct{}{}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = p.Close()
+	}()
+
+	buf := make([]byte, udpReadBufferSize)
+	for {
+		n, remote, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			if isClosedErr(err) {
+				return nil
+			}
+			return errors.Wrap(err, "read packet failed")
+		}
+		if c, ok := p.session(ctx, remote, buf[:n]); ok {
+			c.handle(buf[:n])
+		}
+	}
+}
+
+// session returns the session of remote address, a new one will be accepted if packet is the beginning of connection.
+func (p *udpServerTransport) session(ctx context.Context, remote *net.UDPAddr, packet []byte) (c *udpConn, ok bool) {
+	key := remote.String()
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if p.sessions == nil {
+		return
+	}
+	if c, ok = p.sessions[key]; ok {
+		return
+	}
+	// A connection must begin with the first packet of lane 0 (SETUP or RESUME).
+	if len(packet) < udpHeaderLen || packet[0] != udpPacketData ||
+		binary.BigEndian.Uint32(packet[2:]) != 0 || binary.BigEndian.Uint32(packet[6:]) != 0 {
+		return
+	}
+	c = newUDPConn(remote, true, func(packet []byte) error {
+		_, err := p.conn.WriteToUDP(packet, remote)
+		return err
+	}, func() {
+		p.locker.Lock()
+		if p.sessions != nil && p.sessions[key] == c {
+			delete(p.sessions, key)
+		}
+		p.locker.Unlock()
+	})
+	p.sessions[key] = c
+	go p.acceptor(ctx, newTransportClient(c))
+	return c, true
+}
+
+func newUDPServerTransport(addr string) *udpServerTransport {
+	return &udpServerTransport{
+		addr:     addr,
+		sessions: make(map[string]*udpConn),
+	}
+}
+
+func newUDPClientTransport(addr string) (*Transport, error) {
+	remote, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	rawConn, err := net.DialUDP("udp", nil, remote)
+	if err != nil {
+		return nil, err
+	}
+	c := newUDPConn(remote, false, func(packet []byte) error {
+		_, err := rawConn.Write(packet)
+		return err
+	}, func() {
+		_ = rawConn.Close()
+	})
+	go func() {
+		buf := make([]byte, udpReadBufferSize)
+		for {
+			n, err := rawConn.Read(buf)
+			if err != nil {
+				if !isClosedErr(err) {
+					c.shutdown(errors.Wrap(err, "read packet failed"))
+				}
+				return
+			}
+			c.handle(buf[:n])
+		}
+	}()
+	return newTransportClient(c), nil
+}
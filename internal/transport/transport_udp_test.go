@@ -0,0 +1,285 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLossyUDPPair links two connections by a network which drops packets if drop returns true.
+func newLossyUDPPair(drop func(packet []byte) bool) (a, b *udpConn) {
+	var locker sync.Mutex
+	link := func(peer **udpConn) func(packet []byte) error {
+		ch := make(chan []byte, 4096)
+		go func() {
+			for it := range ch {
+				(*peer).handle(it)
+			}
+		}()
+		return func(packet []byte) error {
+			locker.Lock()
+			dropped := drop(packet)
+			locker.Unlock()
+			if dropped {
+				return nil
+			}
+			clone := make([]byte, len(packet))
+			copy(clone, packet)
+			ch <- clone
+			return nil
+		}
+	}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	a = newUDPConn(addr, false, link(&b), nil)
+	b = newUDPConn(addr, true, link(&a), nil)
+	return
+}
+
+func udpPacketLane(packet []byte) (lane uint32, isData bool) {
+	if packet[0] != udpPacketData {
+		return
+	}
+	return binary.BigEndian.Uint32(packet[2:]), true
+}
+
+func TestUDPConn_Reliable(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	a, b := newLossyUDPPair(func(packet []byte) bool {
+		return rnd.Intn(100) < 20
+	})
+	defer func() {
+		_ = a.Close()
+		_ = b.Close()
+	}()
+
+	large := strings.Repeat("x", 5000)
+	go func() {
+		require.NoError(t, a.Write(framing.NewFrameKeepalive(0, nil, true)))
+		for i := 0; i < 50; i++ {
+			for _, sid := range []uint32{1, 3} {
+				require.NoError(t, a.Write(framing.NewFramePayload(sid, []byte(fmt.Sprintf("%d", i)), nil, framing.FlagNext)))
+			}
+		}
+		require.NoError(t, a.Write(framing.NewFramePayload(5, []byte(large), nil, framing.FlagNext)))
+	}()
+
+	require.NoError(t, b.SetDeadline(time.Now().Add(10*time.Second)))
+	f, err := b.Read()
+	require.NoError(t, err)
+	require.IsType(t, &framing.FrameKeepalive{}, f)
+	next := map[uint32]int{}
+	for i := 0; i < 101; i++ {
+		f, err := b.Read()
+		require.NoError(t, err)
+		sid := f.Header().StreamID()
+		data := f.(*framing.FramePayload).DataUTF8()
+		if sid == 5 {
+			assert.Equal(t, large, data)
+			continue
+		}
+		// frames are ordered in each stream
+		assert.Equal(t, fmt.Sprintf("%d", next[sid]), data)
+		next[sid]++
+	}
+	assert.Equal(t, map[uint32]int{1: 50, 3: 50}, next)
+}
+
+func TestUDPConn_Independent(t *testing.T) {
+	var dropped int
+	a, b := newLossyUDPPair(func(packet []byte) bool {
+		// the first transmissions of stream 1 are lost
+		if lane, isData := udpPacketLane(packet); isData && lane == 1 && dropped < 3 {
+			dropped++
+			return true
+		}
+		return false
+	})
+	defer func() {
+		_ = a.Close()
+		_ = b.Close()
+	}()
+
+	require.NoError(t, a.Write(framing.NewFrameKeepalive(0, nil, true)))
+	require.NoError(t, a.Write(framing.NewFramePayload(1, []byte("lost"), nil, framing.FlagNext)))
+	require.NoError(t, a.Write(framing.NewFramePayload(3, []byte("unrelated"), nil, framing.FlagNext)))
+
+	require.NoError(t, b.SetDeadline(time.Now().Add(10*time.Second)))
+	var received []uint32
+	for i := 0; i < 3; i++ {
+		f, err := b.Read()
+		require.NoError(t, err)
+		received = append(received, f.Header().StreamID())
+	}
+	// stream 3 is not blocked by lost packets of stream 1
+	assert.Equal(t, []uint32{0, 3, 1}, received)
+}
+
+func TestUDPConn_IndependentWindow(t *testing.T) {
+	a, b := newLossyUDPPair(func(packet []byte) bool {
+		// lane 1 is broken
+		lane, isData := udpPacketLane(packet)
+		return isData && lane == 1
+	})
+	defer func() {
+		_ = a.Close()
+		_ = b.Close()
+	}()
+
+	require.NoError(t, a.Write(framing.NewFrameKeepalive(0, nil, true)))
+	// fill send window of lane 1
+	for i := 0; i < udpWindow; i++ {
+		require.NoError(t, a.Write(framing.NewFramePayload(1, []byte("lost"), nil, framing.FlagNext)))
+	}
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- a.Write(framing.NewFramePayload(1, []byte("blocked"), nil, framing.FlagNext))
+	}()
+	select {
+	case <-blocked:
+		require.Fail(t, "write should be blocked by full send window of lane 1")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		written <- a.Write(framing.NewFramePayload(2, []byte("unrelated"), nil, framing.FlagNext))
+	}()
+	select {
+	case err := <-written:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "write of lane 2 should not be blocked by lane 1")
+	}
+
+	require.NoError(t, b.SetDeadline(time.Now().Add(3*time.Second)))
+	for _, sid := range []uint32{0, 2} {
+		f, err := b.Read()
+		require.NoError(t, err)
+		assert.Equal(t, sid, f.Header().StreamID())
+	}
+}
+
+func TestUDPConn_Lanes(t *testing.T) {
+	assert.Equal(t, uint32(0), udpLaneOf(0))
+	assert.Equal(t, uint32(1), udpLaneOf(1))
+	assert.Equal(t, uint32(udpLanes-1), udpLaneOf(udpLanes-1))
+	assert.Equal(t, uint32(1), udpLaneOf(udpLanes))
+	for _, sid := range []uint32{1, 2, 1<<31 - 1} {
+		lane := udpLaneOf(sid)
+		assert.True(t, lane > 0 && lane < udpLanes)
+	}
+}
+
+func TestUDPConn_Window(t *testing.T) {
+	c := newUDPConn(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, false, func(packet []byte) error {
+		return nil
+	}, nil)
+	defer c.shutdown(nil)
+	packet := func(lane, seq uint32) []byte {
+		b := make([]byte, udpHeaderLen+1)
+		b[0] = udpPacketData
+		binary.BigEndian.PutUint32(b[2:], lane)
+		binary.BigEndian.PutUint32(b[6:], seq)
+		return b
+	}
+	accepted, err := c.receive(packet(1, udpWindow-1))
+	assert.NoError(t, err)
+	assert.True(t, accepted)
+	// packets beyond receive window are dropped
+	accepted, err = c.receive(packet(1, udpWindow))
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+	// unknown lane
+	accepted, err = c.receive(packet(udpLanes, 0))
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+}
+
+func TestUDPConn_Close(t *testing.T) {
+	a, b := newLossyUDPPair(func(packet []byte) bool {
+		return false
+	})
+	require.NoError(t, a.Write(framing.NewFrameKeepalive(0, nil, true)))
+	require.NoError(t, a.Write(framing.NewFrameCancel(1)))
+	require.NoError(t, a.Close())
+	assert.Error(t, a.Write(framing.NewFrameCancel(1)))
+
+	require.NoError(t, b.SetDeadline(time.Now().Add(3*time.Second)))
+	for i := 0; i < 2; i++ {
+		_, err := b.Read()
+		require.NoError(t, err)
+	}
+	_, err := b.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestUDPTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	uri, err := ParseURI("udp://127.0.0.1:8005")
+	require.NoError(t, err)
+	st, err := uri.MakeServerTransport(nil)
+	require.NoError(t, err)
+	accepted := make(chan *Transport, 1)
+	st.Accept(func(ctx context.Context, tp *Transport) {
+		accepted <- tp
+	})
+	notifier := make(chan struct{})
+	go func() {
+		_ = st.Listen(ctx, notifier)
+	}()
+	<-notifier
+	defer func() {
+		_ = st.Close()
+	}()
+
+	// packets which are not the beginning of a connection are ignored
+	stray, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8005})
+	require.NoError(t, err)
+	for _, seq := range []uint32{1, 2} {
+		b := make([]byte, udpHeaderLen+1)
+		b[0] = udpPacketData
+		binary.BigEndian.PutUint32(b[6:], seq)
+		_, err = stray.Write(b)
+		require.NoError(t, err)
+	}
+	_ = stray.Close()
+	select {
+	case <-accepted:
+		require.Fail(t, "stray packets should not be accepted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	tp, err := uri.MakeClientTransport(ctx, nil, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = tp.Close()
+	}()
+	require.NoError(t, tp.Send(framing.NewFrameKeepalive(0, []byte("ping"), true), true))
+	select {
+	case server := <-accepted:
+		require.NoError(t, server.Connection().SetDeadline(time.Now().Add(3*time.Second)))
+		f, err := server.Connection().Read()
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(f.(*framing.FrameKeepalive).Data()))
+		require.NoError(t, server.Send(framing.NewFrameKeepalive(0, []byte("pong"), false), true))
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "accept timeout")
+	}
+	require.NoError(t, tp.Connection().SetDeadline(time.Now().Add(3*time.Second)))
+	f, err := tp.Connection().Read()
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(f.(*framing.FrameKeepalive).Data()))
+}
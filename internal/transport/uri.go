@@ -1,9 +1,13 @@
 package transport
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -13,6 +17,8 @@ const (
 	schemaTCP             = "tcp"
 	schemaWebsocket       = "ws"
 	schemaWebsocketSecure = "wss"
+	schemaMem             = "mem"
+	schemaUDP             = "udp"
 )
 
 // URI represents a URI of RSocket transport.
@@ -32,51 +38,97 @@ func (p *URI) IsWebsocket() bool {
 	}
 }
 
-// MakeClientTransport creates a new client-side transport.
-func (p *URI) MakeClientTransport(tc *tls.Config, headers map[string][]string) (*Transport, error) {
+// MakeClientTransport creates a new client-side transport by the factory registered for scheme.
+func (p *URI) MakeClientTransport(ctx context.Context, tc *tls.Config, headers map[string][]string) (*Transport, error) {
+	f, ok := clientFactory(p.Scheme)
+	if !ok {
+		return nil, errors.Errorf("unsupported transport url: %s", p.pp().String())
+	}
+	return f(ctx, p, tc, headers)
+}
+
+// DialClientTransport creates a new client-side transport over the connection dialed by dialer.
+// Only tcp and unix are supported, the connection will be wrapped with TLS if tc is not nil.
+func (p *URI) DialClientTransport(ctx context.Context, dialer Dialer, tc *tls.Config) (*Transport, error) {
+	var network, addr string
 	switch strings.ToLower(p.Scheme) {
 	case schemaTCP:
-		return newTCPClientTransport(schemaTCP, p.Host, tc)
-	case schemaWebsocket:
-		if tc == nil {
-			return newWebsocketClientTransport(p.pp().String(), nil, headers)
-		}
-		var clone = (url.URL)(*p)
-		clone.Scheme = "wss"
-		return newWebsocketClientTransport(clone.String(), tc, headers)
-	case schemaWebsocketSecure:
-		if tc == nil {
-			tc = tlsInsecure
-		}
-		return newWebsocketClientTransport(p.pp().String(), tc, headers)
+		network, addr = schemaTCP, p.Host
 	case schemaUNIX:
-		return newTCPClientTransport(schemaUNIX, p.Path, tc)
+		network, addr = schemaUNIX, p.Path
 	default:
-		return nil, errors.Errorf("unsupported transport url: %s", p.pp().String())
+		return nil, errors.Errorf("custom dialer is unsupported by transport url: %s", p.pp().String())
 	}
+	c, err := dialer(ctx, network, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial failed")
+	}
+	if tc != nil {
+		if tc.ServerName == "" && !tc.InsecureSkipVerify {
+			// Same as tls.Dial, verify hostname of address.
+			tc = tc.Clone()
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				tc.ServerName = host
+			} else {
+				tc.ServerName = addr
+			}
+		}
+		tlsConn := tls.Client(c, tc)
+		if err := handshake(ctx, tlsConn); err != nil {
+			_ = c.Close()
+			return nil, errors.Wrap(err, "tls handshake failed")
+		}
+		c = tlsConn
+	}
+	return NewTCPClientTransport(c), nil
 }
 
-// MakeServerTransport creates a new server-side transport.
-func (p *URI) MakeServerTransport(c *tls.Config) (tp ServerTransport, err error) {
-	switch strings.ToLower(p.Scheme) {
-	case schemaTCP:
-		tp = newTCPServerTransport(schemaTCP, p.Host, c)
-	case schemaWebsocket:
-		tp = newWebsocketServerTransport(p.Host, p.Path, c)
-	case schemaWebsocketSecure:
-		if c == nil {
-			err = errors.Errorf("missing TLS Config for proto %s", schemaWebsocketSecure)
+// handshake runs TLS handshake until it completes or ctx is done.
+func handshake(ctx context.Context, c *tls.Conn) (err error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = c.SetDeadline(deadline); err != nil {
 			return
 		}
-		tp = newWebsocketServerTransport(p.Host, p.Path, c)
-	case schemaUNIX:
-		tp = newTCPServerTransport(schemaUNIX, p.Path, c)
-	default:
-		err = errors.Errorf("unsupported transport url: %s", p.pp().String())
 	}
+	done := make(chan struct{})
+	cancelled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.Close()
+			cancelled <- true
+		case <-done:
+			cancelled <- false
+		}
+	}()
+	err = c.Handshake()
+	close(done)
+	if <-cancelled {
+		err = ctx.Err()
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = c.SetDeadline(time.Time{})
 	return
 }
 
+// MakeServerTransport creates a new server-side transport by the factory registered for scheme.
+func (p *URI) MakeServerTransport(c *tls.Config) (ServerTransport, error) {
+	f, ok := serverFactory(p.Scheme)
+	if !ok {
+		return nil, errors.Errorf("unsupported transport url: %s", p.pp().String())
+	}
+	return f(p, c)
+}
+
+// serialize returns true if frames of in-process transport should be serialized, eg: "mem://foo?serialize=true".
+func (p *URI) serialize() bool {
+	ok, _ := strconv.ParseBool(p.pp().Query().Get("serialize"))
+	return ok
+}
+
 func (p *URI) String() string {
 	return p.pp().String()
 }
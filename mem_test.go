@@ -0,0 +1,90 @@
+package rsocket_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemTransport(t *testing.T) {
+	for _, uri := range []string{"mem://test", "mem://test-serialize?serialize=true"} {
+		t.Run(uri, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			serving := make(chan struct{})
+			go func() {
+				_ = Receive().
+					Fragment(128).
+					OnStart(func() {
+						close(serving)
+					}).
+					Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+						return NewAbstractSocket(
+							RequestResponse(func(msg Payload) mono.Mono {
+								return mono.Just(msg)
+							}),
+							RequestStream(func(msg Payload) flux.Flux {
+								return flux.Create(func(ctx context.Context, s flux.Sink) {
+									for i := 0; i < 3; i++ {
+										s.Next(NewString(fmt.Sprintf("%s%d", msg.DataUTF8(), i), ""))
+									}
+									s.Complete()
+								})
+							}),
+							RequestChannel(func(msgs rx.Publisher) flux.Flux {
+								return msgs.(flux.Flux)
+							}),
+						), nil
+					}).
+					Transport(uri).
+					Serve(ctx)
+			}()
+			select {
+			case <-serving:
+			case <-time.After(3 * time.Second):
+				require.Fail(t, "server start timeout")
+			}
+
+			cli, err := Connect().
+				Fragment(128).
+				Transport(uri).
+				Start(ctx)
+			require.NoError(t, err)
+			defer func() {
+				_ = cli.Close()
+			}()
+
+			// large payloads are fragmented
+			large := make([]byte, 1024)
+			for i := range large {
+				large[i] = byte('a' + i%26)
+			}
+			res, err := cli.RequestResponse(New(large, []byte("metadata"))).Block(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, large, res.Data())
+
+			var received []string
+			_, err = cli.RequestStream(NewString("hello", "")).
+				DoOnNext(func(input Payload) {
+					received = append(received, input.DataUTF8())
+				}).
+				BlockLast(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"hello0", "hello1", "hello2"}, received)
+
+			last, err := cli.RequestChannel(flux.Just(NewString("a", ""), NewString("b", ""))).BlockLast(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "b", last.DataUTF8())
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// DefaultBuckets are default buckets of histogram, which are suitable for durations in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// Labels represents labels of a metric.
+	Labels map[string]string
+
+	// Counter is a metric whose value only goes up.
+	Counter interface {
+		// Inc increments the counter by 1.
+		Inc()
+		// Add adds the given non-negative value to the counter.
+		Add(delta float64)
+	}
+
+	// Gauge is a metric whose value can go up and down.
+	Gauge interface {
+		// Set sets the gauge to the given value.
+		Set(value float64)
+		// Add adds the given value to the gauge, it can be negative.
+		Add(delta float64)
+	}
+
+	// Histogram samples observations and counts them in buckets.
+	Histogram interface {
+		// Observe adds an observation.
+		Observe(value float64)
+	}
+
+	// Registry creates metrics, it can be implemented to bridge other monitoring systems.
+	// Same name and labels should result in same metric.
+	Registry interface {
+		// Counter returns a counter with given name and labels.
+		Counter(name, help string, labels Labels) Counter
+		// Gauge returns a gauge with given name and labels.
+		Gauge(name, help string, labels Labels) Gauge
+		// Histogram returns a histogram with given name, buckets and labels.
+		Histogram(name, help string, buckets []float64, labels Labels) Histogram
+	}
+
+	// Unregisterer is an optional interface of Registry, metrics of a closed connection are removed through it.
+	Unregisterer interface {
+		// Unregister removes the metric with given name and labels.
+		Unregister(name string, labels Labels)
+	}
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// NewRegistry returns a new registry which keeps metrics in memory.
+// Its metrics can be exposed in Prometheus text format by Handler.
+func NewRegistry() TextRegistry {
+	return &registry{
+		families: make(map[string]*family),
+	}
+}
+
+type registry struct {
+	locker   sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	buckets []float64
+	series  map[string]interface{}
+}
+
+func (p *registry) Counter(name, help string, labels Labels) Counter {
+	return p.lookup(name, help, typeCounter, nil, labels, func([]float64) interface{} {
+		return &counter{v: atomic.NewFloat64(0)}
+	}).(Counter)
+}
+
+func (p *registry) Gauge(name, help string, labels Labels) Gauge {
+	return p.lookup(name, help, typeGauge, nil, labels, func([]float64) interface{} {
+		return &gauge{v: atomic.NewFloat64(0)}
+	}).(Gauge)
+}
+
+func (p *registry) Histogram(name, help string, buckets []float64, labels Labels) Histogram {
+	if len(buckets) < 1 {
+		buckets = DefaultBuckets
+	}
+	return p.lookup(name, help, typeHistogram, buckets, labels, func(buckets []float64) interface{} {
+		return &histogram{
+			buckets: buckets,
+			counts:  make([]uint64, len(buckets)),
+		}
+	}).(Histogram)
+}
+
+func (p *registry) lookup(name, help, typ string, buckets []float64, labels Labels, create func([]float64) interface{}) interface{} {
+	key := renderLabels(labels)
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	f, ok := p.families[name]
+	if !ok {
+		if buckets != nil {
+			buckets = append([]float64(nil), buckets...)
+			sort.Float64s(buckets)
+		}
+		f = &family{
+			name:    name,
+			help:    help,
+			typ:     typ,
+			buckets: buckets,
+			series:  make(map[string]interface{}),
+		}
+		p.families[name] = f
+	} else if f.typ != typ {
+		panic(fmt.Sprintf("metrics: %s has been registered as %s", name, f.typ))
+	}
+	m, ok := f.series[key]
+	if !ok {
+		m = create(f.buckets)
+		f.series[key] = m
+	}
+	return m
+}
+
+func (p *registry) Unregister(name string, labels Labels) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	f, ok := p.families[name]
+	if !ok {
+		return
+	}
+	delete(f.series, renderLabels(labels))
+	if len(f.series) < 1 {
+		delete(p.families, name)
+	}
+}
+
+type counter struct {
+	v *atomic.Float64
+}
+
+func (p *counter) Inc() {
+	p.v.Add(1)
+}
+
+func (p *counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	p.v.Add(delta)
+}
+
+type gauge struct {
+	v *atomic.Float64
+}
+
+func (p *gauge) Set(value float64) {
+	p.v.Store(value)
+}
+
+func (p *gauge) Add(delta float64) {
+	p.v.Add(delta)
+}
+
+type histogram struct {
+	locker  sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (p *histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(p.buckets, value)
+	p.locker.Lock()
+	if i < len(p.counts) {
+		p.counts[i]++
+	}
+	p.count++
+	p.sum += value
+	p.locker.Unlock()
+}
+
+// snapshot returns cumulative counts of buckets, total count and sum.
+func (p *histogram) snapshot() (cumulative []uint64, count uint64, sum float64) {
+	cumulative = make([]uint64, len(p.counts))
+	p.locker.Lock()
+	var n uint64
+	for i, it := range p.counts {
+		n += it
+		cumulative[i] = n
+	}
+	count, sum = p.count, p.sum
+	p.locker.Unlock()
+	return
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// renderLabels renders labels sorted by name, eg: a="1",b="2".
+func renderLabels(labels Labels) string {
+	if len(labels) < 1 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	sb := strings.Builder{}
+	for i, k := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(labels[k]))
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rsocket/rsocket-go/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter("requests_total", "Total requests.", metrics.Labels{"side": "client", "path": `a"b`}).Add(2)
+	r.Counter("requests_total", "Total requests.", metrics.Labels{"path": `a"b`, "side": "client"}).Inc()
+	r.Gauge("active", "Active\nconnections.", nil).Set(-1.5)
+	h := r.Histogram("latency_seconds", "Latency.", []float64{1, 0.1}, metrics.Labels{"role": "server"})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	sb := &strings.Builder{}
+	assert.NoError(t, r.WriteText(sb))
+	assert.Equal(t, `# HELP active Active\nconnections.
+# TYPE active gauge
+active -1.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{role="server",le="0.1"} 1
+latency_seconds_bucket{role="server",le="1"} 2
+latency_seconds_bucket{role="server",le="+Inf"} 3
+latency_seconds_sum{role="server"} 3.55
+latency_seconds_count{role="server"} 3
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{path="a\"b",side="client"} 3
+`, sb.String())
+
+	assert.Panics(t, func() {
+		r.Gauge("requests_total", "", nil)
+	}, "type of metric should not be changed")
+	assert.Panics(t, func() {
+		r.Counter("requests_total", "", nil).Add(-1)
+	}, "counter should not decrease")
+}
+
+func TestHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter("foo_total", "Foo.", nil).Inc()
+	w := httptest.NewRecorder()
+	metrics.Handler(r).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP foo_total Foo.\n# TYPE foo_total counter\nfoo_total 1\n", w.Body.String())
+}
+
+func TestRegistry_Unregister(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter("foo_total", "Foo.", metrics.Labels{"a": "1"}).Inc()
+	r.Counter("foo_total", "Foo.", metrics.Labels{"a": "2"}).Inc()
+	u := r.(metrics.Unregisterer)
+
+	u.Unregister("foo_total", metrics.Labels{"a": "1"})
+	sb := &strings.Builder{}
+	assert.NoError(t, r.WriteText(sb))
+	assert.Equal(t, "# HELP foo_total Foo.\n# TYPE foo_total counter\nfoo_total{a=\"2\"} 1\n", sb.String())
+
+	u.Unregister("foo_total", metrics.Labels{"a": "2"})
+	u.Unregister("bar_total", nil)
+	sb.Reset()
+	assert.NoError(t, r.WriteText(sb))
+	assert.Empty(t, sb.String())
+}
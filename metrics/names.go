@@ -0,0 +1,35 @@
+package metrics
+
+// Names of metrics recorded by RSocket connections, all of them have a label "role" which is "client" or "server".
+const (
+	// NameConnections counts accepted or established connections.
+	NameConnections = "rsocket_connections_total"
+	// NameConnectionsActive is the number of open connections.
+	NameConnectionsActive = "rsocket_connections_active"
+	// NameFrames counts frames by label "direction" ("in" or "out") and "type".
+	NameFrames = "rsocket_frames_total"
+	// NameBytes counts bytes of frames by label "direction" and "connection".
+	// Series of a connection are unregistered when it is closed if the Registry implements Unregisterer.
+	NameBytes = "rsocket_bytes_total"
+	// NameRequestsStarted counts requests by label "side" ("requester" or "responder") and "type".
+	NameRequestsStarted = "rsocket_requests_started_total"
+	// NameRequestsFinished counts finished requests by label "side", "type" and "outcome" ("completed", "errored" or "cancelled").
+	NameRequestsFinished = "rsocket_requests_finished_total"
+	// NameStreamsActive is the number of streams in flight.
+	NameStreamsActive = "rsocket_streams_active"
+	// NameReassemblies counts payloads reassembled from fragments.
+	NameReassemblies = "rsocket_fragment_reassemblies_total"
+	// NameKeepaliveRTT is the round-trip time of KEEPALIVE in seconds.
+	NameKeepaliveRTT = "rsocket_keepalive_rtt_seconds"
+	// NameLeaseGranted counts requests granted by LEASE frames.
+	NameLeaseGranted = "rsocket_lease_granted_total"
+	// NameLeaseRejected counts requests rejected because of lease.
+	NameLeaseRejected = "rsocket_lease_rejected_total"
+)
+
+const (
+	// RoleClient is the role of client connections.
+	RoleClient = "client"
+	// RoleServer is the role of server connections.
+	RoleServer = "server"
+)
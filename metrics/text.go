@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+const textContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// TextRegistry is a Registry which can write its metrics in Prometheus text exposition format.
+type TextRegistry interface {
+	Registry
+	// WriteText writes all metrics in Prometheus text exposition format.
+	WriteText(w io.Writer) error
+}
+
+// Handler returns a http.Handler which exposes metrics of registry in Prometheus text exposition format.
+func Handler(registry TextRegistry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", textContentType)
+		if err := registry.WriteText(w); err != nil {
+			logger.Errorf("write metrics failed: %s\n", err)
+		}
+	})
+}
+
+func (p *registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range p.snapshot() {
+		bw.WriteString("# HELP ")
+		bw.WriteString(f.name)
+		bw.WriteByte(' ')
+		bw.WriteString(helpEscaper.Replace(f.help))
+		bw.WriteString("\n# TYPE ")
+		bw.WriteString(f.name)
+		bw.WriteByte(' ')
+		bw.WriteString(f.typ)
+		bw.WriteByte('\n')
+		for _, it := range f.series {
+			switch m := it.metric.(type) {
+			case *counter:
+				writeSample(bw, f.name, it.labels, "", m.v.Load())
+			case *gauge:
+				writeSample(bw, f.name, it.labels, "", m.v.Load())
+			case *histogram:
+				cumulative, count, sum := m.snapshot()
+				for i, le := range m.buckets {
+					writeSample(bw, f.name+"_bucket", it.labels, `le="`+formatFloat(le)+`"`, float64(cumulative[i]))
+				}
+				writeSample(bw, f.name+"_bucket", it.labels, `le="+Inf"`, float64(count))
+				writeSample(bw, f.name+"_sum", it.labels, "", sum)
+				writeSample(bw, f.name+"_count", it.labels, "", float64(count))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+type series struct {
+	labels string
+	metric interface{}
+}
+
+type familySnapshot struct {
+	*family
+	series []series
+}
+
+// snapshot returns families sorted by name, and their series sorted by labels.
+func (p *registry) snapshot() (families []familySnapshot) {
+	p.locker.Lock()
+	families = make([]familySnapshot, 0, len(p.families))
+	for _, f := range p.families {
+		ss := make([]series, 0, len(f.series))
+		for labels, m := range f.series {
+			ss = append(ss, series{labels: labels, metric: m})
+		}
+		sort.Slice(ss, func(i, j int) bool {
+			return ss[i].labels < ss[j].labels
+		})
+		families = append(families, familySnapshot{family: f, series: ss})
+	}
+	p.locker.Unlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	return
+}
+
+func writeSample(w *bufio.Writer, name, labels, extra string, value float64) {
+	w.WriteString(name)
+	if labels != "" || extra != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		if labels != "" && extra != "" {
+			w.WriteByte(',')
+		}
+		w.WriteString(extra)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,97 @@
+package rsocket_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/metrics"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	const addr = "127.0.0.1:7994"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registry := metrics.NewRegistry()
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Metrics(registry).
+			Fragment(128).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponse(func(msg Payload) mono.Mono {
+						return mono.Just(msg)
+					}),
+					RequestStream(func(msg Payload) flux.Flux {
+						return flux.Just(msg, msg, msg)
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		Metrics(registry).
+		Fragment(128).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	_, err = cli.RequestResponse(NewString(strings.Repeat("x", 1024), "")).Block(ctx)
+	require.NoError(t, err)
+	_, err = cli.RequestStream(NewString("hello", "")).BlockLast(ctx)
+	require.NoError(t, err)
+
+	// Finishing of requests is recorded asynchronously.
+	time.Sleep(100 * time.Millisecond)
+	sb := &strings.Builder{}
+	require.NoError(t, registry.WriteText(sb))
+	text := sb.String()
+	for _, line := range []string{
+		`rsocket_connections_active{role="client"} 1`,
+		`rsocket_connections_active{role="server"} 1`,
+		`rsocket_frames_total{direction="in",role="server",type="SETUP"} 1`,
+		`rsocket_frames_total{direction="out",role="client",type="SETUP"} 1`,
+		`rsocket_requests_started_total{role="client",side="requester",type="REQUEST_RESPONSE"} 1`,
+		`rsocket_requests_finished_total{outcome="completed",role="client",side="requester",type="REQUEST_RESPONSE"} 1`,
+		`rsocket_requests_started_total{role="server",side="responder",type="REQUEST_STREAM"} 1`,
+		`rsocket_requests_finished_total{outcome="completed",role="server",side="responder",type="REQUEST_STREAM"} 1`,
+		`rsocket_streams_active{role="client"} 0`,
+		`rsocket_streams_active{role="server"} 0`,
+		`rsocket_fragment_reassemblies_total{role="server"} 1`,
+		`rsocket_fragment_reassemblies_total{role="client"} 1`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+	assert.Regexp(t, `rsocket_bytes_total\{connection="1",direction="in",role="server"\} [1-9]`, text)
+	assert.Regexp(t, `rsocket_bytes_total\{connection="1",direction="out",role="client"\} [1-9]`, text)
+
+	// Series of bytes are removed with connection.
+	require.NoError(t, cli.Close())
+	time.Sleep(100 * time.Millisecond)
+	sb.Reset()
+	require.NoError(t, registry.WriteText(sb))
+	assert.NotRegexp(t, `rsocket_bytes_total\{[^}]*role="client"`, sb.String())
+	assert.Contains(t, sb.String(), `rsocket_connections_active{role="client"} 0`+"\n")
+}
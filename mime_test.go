@@ -0,0 +1,104 @@
+package rsocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/extension"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerStreamMimeTypes(t *testing.T) {
+	const addr = "127.0.0.1:7997"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponse(func(msg Payload) mono.Mono {
+						// decode request by its data MIME type, encode response by the first accepted MIME type.
+						var req map[string]string
+						if err := Decode(setup, msg, &req); err != nil {
+							return mono.Error(err)
+						}
+						mimeType := msg.(MimeTypeAware).DataMimeType()
+						if accepts := msg.(MimeTypeAware).AcceptMimeTypes(); len(accepts) > 0 {
+							mimeType = accepts[0]
+						}
+						res, err := Encode(mimeType, req["hello"]+"@"+msg.(MimeTypeAware).DataMimeType(), nil)
+						if err != nil {
+							return mono.Error(err)
+						}
+						return mono.Just(res)
+					}),
+					RequestChannel(func(msgs rx.Publisher) flux.Flux {
+						return msgs.(flux.Flux).Map(func(msg Payload) Payload {
+							return NewString(msg.(MimeTypeAware).DataMimeType(), "")
+						})
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		DataMimeType(extension.ApplicationJSON.String()).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// fallback to data MIME type of setup
+	req, err := Encode(extension.ApplicationJSON.String(), map[string]string{"hello": "json"}, nil)
+	require.NoError(t, err)
+	res, err := cli.RequestResponse(req).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `"json@application/json"`, res.DataUTF8())
+
+	// per-stream data MIME type and accepted MIME types
+	mimeType, _ := extension.EncodeMimeType(extension.ApplicationCBOR.String())
+	accepts, _ := extension.EncodeAcceptMimeTypes(extension.TextPlain.String())
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageMimeType, mimeType).
+		PushWellKnown(extension.MessageAcceptMimeTypes, accepts).
+		Build()
+	require.NoError(t, err)
+	data, err := codec.CBOR.Encode(map[string]string{"hello": "cbor"})
+	require.NoError(t, err)
+	res, err = cli.RequestResponse(New(data, metadata)).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cbor@application/cbor", res.DataUTF8())
+
+	// following payloads of channel share MIME types of the first one
+	var received []string
+	_, err = cli.RequestChannel(flux.Just(New(nil, metadata), NewString("second", ""))).
+		DoOnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/cbor", "application/cbor"}, received)
+}
@@ -0,0 +1,29 @@
+package payload
+
+import (
+	"github.com/rsocket/rsocket-go/codec"
+)
+
+// DataMimeType returns data MIME type of msg.
+// It is the resolved one if msg is MimeTypeAware, otherwise it is resolved by ResolveMimeTypes.
+func DataMimeType(setup SetupPayload, msg Payload) string {
+	if it, ok := msg.(MimeTypeAware); ok {
+		return it.DataMimeType()
+	}
+	return ResolveMimeTypes(setup, msg).DataMimeType()
+}
+
+// Encode creates a payload whose data is v encoded by the codec of MIME type in the default codec registry.
+func Encode(mime string, v interface{}, metadata []byte) (Payload, error) {
+	data, err := codec.Encode(mime, v)
+	if err != nil {
+		return nil, err
+	}
+	return New(data, metadata), nil
+}
+
+// Decode decodes data of msg into v by the codec of data MIME type in the default codec registry.
+// Data MIME type is resolved by DataMimeType.
+func Decode(setup SetupPayload, msg Payload, v interface{}) error {
+	return codec.Decode(DataMimeType(setup, msg), msg.Data(), v)
+}
@@ -0,0 +1,96 @@
+package payload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSetup struct {
+	Payload
+	dataMimeType, metadataMimeType string
+}
+
+func (p fakeSetup) DataMimeType() string                { return p.dataMimeType }
+func (p fakeSetup) MetadataMimeType() string            { return p.metadataMimeType }
+func (p fakeSetup) TimeBetweenKeepalive() time.Duration { return 0 }
+func (p fakeSetup) MaxLifetime() time.Duration          { return 0 }
+func (p fakeSetup) Version() common.Version             { return common.DefaultVersion }
+
+func TestEncodeDecode(t *testing.T) {
+	setup := fakeSetup{
+		Payload:          New(nil, nil),
+		dataMimeType:     extension.ApplicationJSON.String(),
+		metadataMimeType: extension.MessageCompositeMetadata.String(),
+	}
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	msg, err := Encode(setup.DataMimeType(), user{Name: "foo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"foo"}`, msg.DataUTF8())
+	var u user
+	require.NoError(t, Decode(setup, msg, &u))
+	assert.Equal(t, "foo", u.Name)
+
+	// per-stream data MIME type in composite metadata
+	for _, mimeType := range [][]byte{
+		{0x80 | byte(extension.ApplicationCBOR)},
+		append([]byte{byte(len("application/cbor") - 1)}, "application/cbor"...),
+	} {
+		metadata, err := extension.NewCompositeMetadataBuilder().
+			PushWellKnownString(extension.MessageRouting, "\x04user").
+			PushWellKnown(extension.MessageMimeType, mimeType).
+			Build()
+		require.NoError(t, err)
+		msg, err = Encode(extension.ApplicationCBOR.String(), user{Name: "bar"}, metadata)
+		require.NoError(t, err)
+		assert.Equal(t, extension.ApplicationCBOR.String(), DataMimeType(setup, msg))
+		u = user{}
+		require.NoError(t, Decode(setup, msg, &u))
+		assert.Equal(t, "bar", u.Name)
+	}
+
+	// fallback to setup if metadata is not composite metadata
+	setup.metadataMimeType = extension.MessageRouting.String()
+	assert.Equal(t, extension.ApplicationJSON.String(), DataMimeType(setup, msg))
+	setup.metadataMimeType = extension.MessageCompositeMetadata.String()
+	assert.Equal(t, extension.ApplicationJSON.String(), DataMimeType(setup, New(nil, []byte{0x01})))
+
+	_, err = Encode("application/x-unknown", u, nil)
+	assert.Error(t, err)
+}
+
+func TestResolveMimeTypes(t *testing.T) {
+	setup := fakeSetup{
+		Payload:          New(nil, nil),
+		dataMimeType:     extension.ApplicationJSON.String(),
+		metadataMimeType: extension.MessageCompositeMetadata.String(),
+	}
+	mimeType, _ := extension.EncodeMimeType("application/x-custom")
+	accepts, _ := extension.EncodeAcceptMimeTypes("application/cbor", "text/plain")
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageMimeType, mimeType).
+		PushWellKnown(extension.MessageAcceptMimeTypes, accepts).
+		Build()
+	require.NoError(t, err)
+
+	msg := ResolveMimeTypes(setup, New([]byte("data"), metadata))
+	assert.Equal(t, "application/x-custom", msg.DataMimeType())
+	assert.Equal(t, []string{"application/cbor", "text/plain"}, msg.AcceptMimeTypes())
+	assert.Equal(t, "data", msg.DataUTF8())
+	assert.Equal(t, "application/x-custom", DataMimeType(setup, msg))
+
+	msg = ResolveMimeTypes(setup, NewString("data", ""))
+	assert.Equal(t, extension.ApplicationJSON.String(), msg.DataMimeType())
+	assert.Empty(t, msg.AcceptMimeTypes())
+
+	msg = WithMimeTypes(msg, "text/plain", "application/json")
+	assert.Equal(t, "text/plain", msg.DataMimeType())
+	assert.Equal(t, []string{"application/json"}, msg.AcceptMimeTypes())
+}
@@ -0,0 +1,86 @@
+package payload
+
+import (
+	"github.com/rsocket/rsocket-go/extension"
+)
+
+// MimeTypeAware is a payload which knows its data MIME types.
+// Payloads of incoming requests passed to responders implement it.
+type MimeTypeAware interface {
+	Payload
+	// DataMimeType returns data MIME type of payload.
+	// It is the message/x.rsocket.mime-type.v0 entry of composite metadata, or data MIME type of setup if absent.
+	DataMimeType() string
+	// AcceptMimeTypes returns the message/x.rsocket.accept-mime-types.v0 entry of composite metadata.
+	// It is empty if absent, which means response should be encoded in data MIME type.
+	AcceptMimeTypes() []string
+}
+
+type mimePayload struct {
+	Payload
+	dataMimeType    string
+	acceptMimeTypes []string
+}
+
+func (p *mimePayload) DataMimeType() string {
+	return p.dataMimeType
+}
+
+func (p *mimePayload) AcceptMimeTypes() []string {
+	return p.acceptMimeTypes
+}
+
+// WithMimeTypes returns a MimeTypeAware payload with given MIME types.
+func WithMimeTypes(msg Payload, dataMimeType string, acceptMimeTypes ...string) MimeTypeAware {
+	if it, ok := msg.(*mimePayload); ok {
+		msg = it.Payload
+	}
+	return &mimePayload{
+		Payload:         msg,
+		dataMimeType:    dataMimeType,
+		acceptMimeTypes: acceptMimeTypes,
+	}
+}
+
+// ResolveMimeTypes returns a MimeTypeAware payload with MIME types resolved from metadata of msg and setup.
+// Per-stream MIME types are only resolved if metadata MIME type of setup is composite metadata.
+func ResolveMimeTypes(setup SetupPayload, msg Payload) MimeTypeAware {
+	if it, ok := msg.(*mimePayload); ok {
+		msg = it.Payload
+	}
+	ret := &mimePayload{
+		Payload:      msg,
+		dataMimeType: setup.DataMimeType(),
+	}
+	if setup.MetadataMimeType() != extension.MessageCompositeMetadata.String() {
+		return ret
+	}
+	if metadata, ok := msg.Metadata(); ok {
+		ret.scan(metadata)
+	}
+	return ret
+}
+
+// scan reads MIME type entries of composite metadata, malformed entries are ignored.
+func (p *mimePayload) scan(metadata []byte) {
+	defer func() {
+		_ = recover()
+	}()
+	scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+	for scanner.Scan() {
+		mimeType, raw, err := scanner.Metadata()
+		if err != nil {
+			return
+		}
+		switch mimeType {
+		case extension.MessageMimeType.String():
+			if dataMimeType, err := extension.ParseMimeType(raw); err == nil {
+				p.dataMimeType = dataMimeType
+			}
+		case extension.MessageAcceptMimeTypes.String():
+			if accepts, err := extension.ParseAcceptMimeTypes(raw); err == nil {
+				p.acceptMimeTypes = accepts
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package rsocket
+
+import (
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/socket"
+)
+
+const (
+	defaultReconnectMinBackoff = 1 * time.Second
+	defaultReconnectMaxBackoff = 30 * time.Second
+	defaultReconnectMultiplier = 2
+)
+
+var (
+	// ErrConnectionLost is the error of requests in flight when transport of a reconnecting client is lost.
+	// It is also the error passed to OnClose handlers if all reconnecting attempts failed.
+	ErrConnectionLost = socket.ErrConnectionLost
+	// ErrReconnecting is the error of new requests when a fail-fast client is reconnecting.
+	ErrReconnecting = socket.ErrReconnecting
+)
+
+// ReconnectPolicy represents the policy of reconnecting for a client without resume.
+// After transport is lost, client will dial the same URI and send SETUP again, the client acceptor will be invoked again.
+// Zero value of each field means its default value.
+type ReconnectPolicy struct {
+	// MinBackoff is the delay before first attempt, default is 1s.
+	MinBackoff time.Duration
+	// MaxBackoff is the max delay between attempts, default is 30s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor of delay growth, default is 2.
+	Multiplier float64
+	// Jitter is the random factor in [0,1] of each delay, default is 0 which means no jitter.
+	Jitter float64
+	// MaxAttempts is the max number of attempts after each disconnection, default is 0 which means no limit.
+	MaxAttempts int
+	// FailFast fails new requests with ErrReconnecting during outage, otherwise they will be queued until reconnected.
+	FailFast bool
+	// OnConnect is invoked after each connection is established.
+	OnConnect func()
+	// OnDisconnect is invoked after each connection is lost.
+	OnDisconnect func(err error)
+}
+
+func (p *ReconnectPolicy) backoff() Backoff {
+	min, max, multiplier := p.MinBackoff, p.MaxBackoff, p.Multiplier
+	if min <= 0 {
+		min = defaultReconnectMinBackoff
+	}
+	if max <= 0 {
+		max = defaultReconnectMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultReconnectMultiplier
+	}
+	backoff := ExponentialBackoff(min, max, multiplier)
+	if p.Jitter > 0 {
+		backoff = JitteredBackoff(backoff, p.Jitter)
+	}
+	return backoff
+}
+
+func (p *ReconnectPolicy) toSocketPolicy() *socket.ReconnectPolicy {
+	return &socket.ReconnectPolicy{
+		Backoff:      p.backoff(),
+		MaxAttempts:  p.MaxAttempts,
+		FailFast:     p.FailFast,
+		OnConnect:    p.OnConnect,
+		OnDisconnect: p.OnDisconnect,
+	}
+}
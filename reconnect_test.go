@@ -0,0 +1,173 @@
+package rsocket_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func startReconnectServer(ctx context.Context, t *testing.T, addr string, accepted *atomic.Int32) {
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				accepted.Inc()
+				return NewAbstractSocket(
+					RequestResponse(func(msg Payload) mono.Mono {
+						return mono.Just(msg)
+					}),
+					RequestStream(func(msg Payload) flux.Flux {
+						return flux.Create(func(ctx context.Context, s flux.Sink) {
+							for {
+								select {
+								case <-ctx.Done():
+									return
+								case <-time.After(10 * time.Millisecond):
+									s.Next(msg)
+								}
+							}
+						})
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+}
+
+func startProxy(t *testing.T, addr, target string) *brokenProxy {
+	l, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	proxy := &brokenProxy{listener: l}
+	go proxy.serve(target)
+	return proxy
+}
+
+func TestReconnect(t *testing.T) {
+	const (
+		serverAddr = "127.0.0.1:7984"
+		proxyAddr  = "127.0.0.1:7985"
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var accepted, connects atomic.Int32
+	startReconnectServer(ctx, t, serverAddr, &accepted)
+	proxy := startProxy(t, proxyAddr, serverAddr)
+	defer func() {
+		_ = proxy.listener.Close()
+	}()
+
+	disconnected := make(chan error, 1)
+	cli, err := Connect().
+		Reconnect(ReconnectPolicy{
+			MinBackoff: 200 * time.Millisecond,
+			Jitter:     0.5,
+			OnConnect: func() {
+				connects.Inc()
+			},
+			OnDisconnect: func(err error) {
+				disconnected <- err
+			},
+		}).
+		Transport("tcp://" + proxyAddr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	streamErr := make(chan error, 1)
+	cli.RequestStream(NewString("hello", "")).
+		Subscribe(ctx, rx.OnNext(func(input Payload) {
+			proxy.reset()
+		}), rx.OnError(func(e error) {
+			streamErr <- e
+		}))
+
+	select {
+	case err := <-streamErr:
+		assert.Equal(t, ErrConnectionLost, err, "in-flight stream should fail with ErrConnectionLost")
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "in-flight stream should be failed")
+	}
+	<-disconnected
+
+	// Request should be queued until reconnected.
+	timeout, cancelTimeout := context.WithTimeout(ctx, 3*time.Second)
+	defer cancelTimeout()
+	res, err := cli.RequestResponse(NewString("world", "")).Block(timeout)
+	require.NoError(t, err)
+	assert.Equal(t, "world", res.DataUTF8())
+	assert.Equal(t, int32(2), connects.Load())
+	assert.Equal(t, int32(2), accepted.Load(), "SETUP should be sent again")
+}
+
+func TestReconnect_FailFast(t *testing.T) {
+	const (
+		serverAddr = "127.0.0.1:7986"
+		proxyAddr  = "127.0.0.1:7987"
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var accepted atomic.Int32
+	startReconnectServer(ctx, t, serverAddr, &accepted)
+	proxy := startProxy(t, proxyAddr, serverAddr)
+
+	disconnected := make(chan struct{}, 1)
+	closed := make(chan error, 1)
+	cli, err := Connect().
+		Reconnect(ReconnectPolicy{
+			MinBackoff:  50 * time.Millisecond,
+			MaxAttempts: 2,
+			FailFast:    true,
+			OnDisconnect: func(err error) {
+				disconnected <- struct{}{}
+			},
+		}).
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Transport("tcp://" + proxyAddr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// Stop accepting, so all reconnecting attempts will fail.
+	_ = proxy.listener.Close()
+	proxy.reset()
+	<-disconnected
+
+	_, err = cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	assert.Equal(t, ErrReconnecting, err, "request should fail fast during outage")
+
+	select {
+	case err := <-closed:
+		assert.Equal(t, ErrConnectionLost, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "client should be closed after max attempts")
+	}
+	_, err = cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	assert.Error(t, err)
+}
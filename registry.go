@@ -0,0 +1,234 @@
+package rsocket
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rsocket/rsocket-go/payload"
+)
+
+type (
+	// Connection represents a client connection in the Registry of server.
+	Connection interface {
+		CloseableRSocket
+		// Key returns the unique key of connection.
+		Key() string
+		// Labels returns labels of connection.
+		Labels() []string
+		// Setup returns the SETUP payload of connection.
+		Setup() payload.SetupPayload
+	}
+
+	// ConnectionFilter is used to filter connections when broadcasting.
+	ConnectionFilter = func(conn Connection) bool
+
+	// Registry is used to address client connections of server, connections will be removed after closing.
+	Registry interface {
+		// Get returns connection with custom key.
+		Get(key string) (Connection, bool)
+		// GetByLabel returns all connections with custom label.
+		GetByLabel(label string) []Connection
+		// Range calls fn for each connection until fn returns false.
+		Range(fn func(conn Connection) bool)
+		// Len returns the number of connections.
+		Len() int
+		// FireAndForget sends a FireAndForget request to all connections which match all filters.
+		// It returns the number of connections sent.
+		FireAndForget(message payload.Payload, filters ...ConnectionFilter) int
+		// MetadataPush sends a MetadataPush request to all connections which match all filters.
+		// It returns the number of connections sent.
+		MetadataPush(message payload.Payload, filters ...ConnectionFilter) int
+		// Register adds a connection accepted by server, it is called by server after acceptor succeeds.
+		// Custom implementations should remove the connection by sendingSocket.OnClose.
+		Register(setup payload.SetupPayload, sendingSocket CloseableRSocket)
+	}
+
+	// OpRegistry represents options of Registry.
+	OpRegistry func(*registry)
+)
+
+// NewRegistry creates a new Registry, it should be bound to a server by ServerBuilder.Registry.
+// The key of each connection is a random UUID by default.
+func NewRegistry(opts ...OpRegistry) Registry {
+	r := &registry{
+		keyFn: func(payload.SetupPayload) string {
+			return uuid.New().String()
+		},
+		conns:  make(map[string]*connection),
+		labels: make(map[string]map[*connection]struct{}),
+	}
+	for _, it := range opts {
+		it(r)
+	}
+	return r
+}
+
+// WithRegistryKey sets a function to derive the key of connection from SETUP payload.
+// A connection with duplicated key will replace the previous one in the Registry.
+func WithRegistryKey(fn func(setup payload.SetupPayload) string) OpRegistry {
+	return func(r *registry) {
+		r.keyFn = fn
+	}
+}
+
+// WithRegistryLabels sets a function to derive labels of connection from SETUP payload.
+func WithRegistryLabels(fn func(setup payload.SetupPayload) []string) OpRegistry {
+	return func(r *registry) {
+		r.labelsFn = fn
+	}
+}
+
+// WithLabel returns a ConnectionFilter which matches connections with custom label.
+func WithLabel(label string) ConnectionFilter {
+	return func(conn Connection) bool {
+		for _, it := range conn.Labels() {
+			if it == label {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type connection struct {
+	CloseableRSocket
+	key    string
+	labels []string
+	setup  payload.SetupPayload
+}
+
+func (p *connection) Key() string {
+	return p.key
+}
+
+func (p *connection) Labels() []string {
+	return p.labels
+}
+
+func (p *connection) Setup() payload.SetupPayload {
+	return p.setup
+}
+
+type registry struct {
+	locker   sync.RWMutex
+	keyFn    func(payload.SetupPayload) string
+	labelsFn func(payload.SetupPayload) []string
+	conns    map[string]*connection
+	labels   map[string]map[*connection]struct{}
+}
+
+func (p *registry) Get(key string) (Connection, bool) {
+	p.locker.RLock()
+	conn, ok := p.conns[key]
+	p.locker.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return conn, true
+}
+
+func (p *registry) GetByLabel(label string) (conns []Connection) {
+	p.locker.RLock()
+	for it := range p.labels[label] {
+		conns = append(conns, it)
+	}
+	p.locker.RUnlock()
+	return
+}
+
+func (p *registry) Range(fn func(conn Connection) bool) {
+	for _, it := range p.snapshot() {
+		if !fn(it) {
+			return
+		}
+	}
+}
+
+func (p *registry) Len() (n int) {
+	p.locker.RLock()
+	n = len(p.conns)
+	p.locker.RUnlock()
+	return
+}
+
+func (p *registry) FireAndForget(message payload.Payload, filters ...ConnectionFilter) int {
+	return p.broadcast(func(conn Connection) {
+		conn.FireAndForget(message)
+	}, filters)
+}
+
+func (p *registry) MetadataPush(message payload.Payload, filters ...ConnectionFilter) int {
+	return p.broadcast(func(conn Connection) {
+		conn.MetadataPush(message)
+	}, filters)
+}
+
+func (p *registry) broadcast(send func(conn Connection), filters []ConnectionFilter) (n int) {
+L:
+	for _, it := range p.snapshot() {
+		for _, filter := range filters {
+			if !filter(it) {
+				continue L
+			}
+		}
+		send(it)
+		n++
+	}
+	return
+}
+
+func (p *registry) snapshot() (conns []Connection) {
+	p.locker.RLock()
+	conns = make([]Connection, 0, len(p.conns))
+	for _, it := range p.conns {
+		conns = append(conns, it)
+	}
+	p.locker.RUnlock()
+	return
+}
+
+// Register adds a new connection, it will be removed automatically after closing.
+func (p *registry) Register(setup payload.SetupPayload, sendingSocket CloseableRSocket) {
+	conn := &connection{
+		CloseableRSocket: sendingSocket,
+		key:              p.keyFn(setup),
+		setup:            setup,
+	}
+	if p.labelsFn != nil {
+		conn.labels = p.labelsFn(setup)
+	}
+	p.locker.Lock()
+	if prev, ok := p.conns[conn.key]; ok {
+		p.unlink(prev)
+	}
+	p.conns[conn.key] = conn
+	for _, label := range conn.labels {
+		set, ok := p.labels[label]
+		if !ok {
+			set = make(map[*connection]struct{})
+			p.labels[label] = set
+		}
+		set[conn] = struct{}{}
+	}
+	p.locker.Unlock()
+	sendingSocket.OnClose(func(error) {
+		p.locker.Lock()
+		// Connection may be replaced by another one with same key.
+		if p.conns[conn.key] == conn {
+			p.unlink(conn)
+		}
+		p.locker.Unlock()
+	})
+}
+
+func (p *registry) unlink(conn *connection) {
+	delete(p.conns, conn.key)
+	for _, label := range conn.labels {
+		if set, ok := p.labels[label]; ok {
+			delete(set, conn)
+			if len(set) < 1 {
+				delete(p.labels, label)
+			}
+		}
+	}
+}
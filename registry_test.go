@@ -0,0 +1,146 @@
+package rsocket_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	const addr = "127.0.0.1:7990"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registry := NewRegistry(
+		WithRegistryKey(func(setup SetupPayload) string {
+			return setup.DataUTF8()
+		}),
+		WithRegistryLabels(func(setup SetupPayload) []string {
+			labels, _ := setup.MetadataUTF8()
+			return strings.Split(labels, ",")
+		}),
+	)
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Registry(registry).
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	received := make(chan string, 16)
+	connect := func(key, labels string) Client {
+		cli, err := Connect().
+			SetupPayload(NewString(key, labels)).
+			Acceptor(func(socket RSocket) RSocket {
+				return NewAbstractSocket(
+					FireAndForget(func(msg Payload) {
+						received <- key + ":" + msg.DataUTF8()
+					}),
+					MetadataPush(func(msg Payload) {
+						m, _ := msg.MetadataUTF8()
+						received <- key + ":" + m
+					}),
+				)
+			}).
+			Transport("tcp://" + addr).
+			Start(ctx)
+		require.NoError(t, err)
+		return cli
+	}
+	foo := connect("foo", "red,green")
+	bar := connect("bar", "green")
+	defer func() {
+		_ = bar.Close()
+	}()
+
+	assert.Eventually(t, func() bool {
+		return registry.Len() == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	conn, ok := registry.Get("foo")
+	require.True(t, ok)
+	assert.Equal(t, "foo", conn.Key())
+	assert.Equal(t, []string{"red", "green"}, conn.Labels())
+	assert.Len(t, registry.GetByLabel("green"), 2)
+	assert.Len(t, registry.GetByLabel("red"), 1)
+
+	assert.Equal(t, 1, registry.FireAndForget(NewString("hello", ""), WithLabel("red")))
+	assert.Equal(t, "foo:hello", <-received)
+	assert.Equal(t, 2, registry.MetadataPush(NewString("", "ping")))
+	pings := []string{<-received, <-received}
+	assert.ElementsMatch(t, []string{"foo:ping", "bar:ping"}, pings)
+
+	// Connection should be removed after closing.
+	_ = foo.Close()
+	assert.Eventually(t, func() bool {
+		return registry.Len() == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	_, ok = registry.Get("foo")
+	assert.False(t, ok)
+	assert.Empty(t, registry.GetByLabel("red"))
+}
+
+// auditRegistry is a custom Registry which records registered connections.
+type auditRegistry struct {
+	Registry
+	registered chan string
+}
+
+func (p *auditRegistry) Register(setup SetupPayload, sendingSocket CloseableRSocket) {
+	p.registered <- setup.DataUTF8()
+	p.Registry.Register(setup, sendingSocket)
+}
+
+func TestRegistry_Custom(t *testing.T) {
+	const addr = "127.0.0.1:8013"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registry := &auditRegistry{
+		Registry:   NewRegistry(),
+		registered: make(chan string, 1),
+	}
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Registry(registry).
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	cli, err := Connect().
+		SetupPayload(NewString("foo", "")).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+	select {
+	case key := <-registry.registered:
+		assert.Equal(t, "foo", key)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "connection should be registered")
+	}
+	assert.Equal(t, 1, registry.Len())
+}
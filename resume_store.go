@@ -0,0 +1,269 @@
+package rsocket
+
+import (
+	"encoding"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/internal/session"
+	"github.com/rsocket/rsocket-go/logger"
+)
+
+const resumeSessionFileExt = ".session"
+
+type (
+	// ResumeSession represents a resumable session of RSocket server.
+	// It can be encoded to binary and decoded by UnmarshalResumeSession, so it can be shared between servers.
+	ResumeSession interface {
+		io.Closer
+		encoding.BinaryMarshaler
+		// Token returns resume token of current session.
+		Token() []byte
+		// Deadline returns the time after which current session expires.
+		Deadline() time.Time
+	}
+
+	// ResumeStore is used to store resumable sessions of RSocket server.
+	ResumeStore interface {
+		io.Closer
+		// Load returns session with custom token.
+		Load(token []byte) (ResumeSession, bool)
+		// Store stores a session, a session with same token will be replaced.
+		Store(session ResumeSession) error
+		// Remove removes a session with custom token and returns it.
+		Remove(token []byte) (ResumeSession, bool)
+		// Expire removes and returns all sessions which are dead at given time.
+		Expire(now time.Time) []ResumeSession
+	}
+)
+
+// UnmarshalResumeSession decodes a session which is encoded by ResumeSession.MarshalBinary.
+func UnmarshalResumeSession(raw []byte) (ResumeSession, error) {
+	s, err := session.Unmarshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewMemoryResumeStore returns a ResumeStore which keeps sessions in memory.
+// It is the default store of RSocket server.
+func NewMemoryResumeStore() ResumeStore {
+	return &memoryResumeStore{
+		m: session.NewManager(),
+	}
+}
+
+// NewFileResumeStore returns a ResumeStore which persists sessions as files in custom directory.
+// Sessions in the directory can be resumed after server restarts.
+func NewFileResumeStore(dir string) (ResumeStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	store := &fileResumeStore{
+		dir:       dir,
+		live:      make(map[string]*session.Session),
+		deadlines: make(map[string]time.Time),
+	}
+	for _, it := range files {
+		if it.IsDir() || !strings.HasSuffix(it.Name(), resumeSessionFileExt) {
+			continue
+		}
+		s, err := store.read(filepath.Join(dir, it.Name()))
+		if err != nil {
+			logger.Warnf("skip broken session file %s: %s\n", it.Name(), err)
+			continue
+		}
+		store.deadlines[string(s.Token())] = s.Deadline()
+	}
+	return store, nil
+}
+
+// WithServerResumeStore sets session store for RSocket server.
+func WithServerResumeStore(store ResumeStore) OpServerResume {
+	return func(o *serverResumeOptions) {
+		o.store = store
+	}
+}
+
+type memoryResumeStore struct {
+	m *session.Manager
+}
+
+func (p *memoryResumeStore) Load(token []byte) (ResumeSession, bool) {
+	s, ok := p.m.Load(token)
+	if !ok {
+		return nil, false
+	}
+	return s, true
+}
+
+func (p *memoryResumeStore) Store(s ResumeSession) error {
+	ss, err := toSession(s)
+	if err != nil {
+		return err
+	}
+	p.m.Remove(ss.Token())
+	p.m.Push(ss)
+	return nil
+}
+
+func (p *memoryResumeStore) Remove(token []byte) (ResumeSession, bool) {
+	s, ok := p.m.Remove(token)
+	if !ok {
+		return nil, false
+	}
+	return s, true
+}
+
+func (p *memoryResumeStore) Expire(now time.Time) (deads []ResumeSession) {
+	for _, it := range p.m.Expire(now) {
+		deads = append(deads, it)
+	}
+	return
+}
+
+func (p *memoryResumeStore) Close() (err error) {
+	for p.m.Len() > 0 {
+		if e := p.m.Pop().Close(); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+type fileResumeStore struct {
+	locker    sync.Mutex
+	dir       string
+	live      map[string]*session.Session
+	deadlines map[string]time.Time
+}
+
+func (p *fileResumeStore) Load(token []byte) (ResumeSession, bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if s, ok := p.live[string(token)]; ok {
+		return s, true
+	}
+	if _, ok := p.deadlines[string(token)]; !ok {
+		return nil, false
+	}
+	s, err := p.read(p.filename(token))
+	if err != nil {
+		logger.Warnf("load session file failed: %s\n", err)
+		return nil, false
+	}
+	return s, true
+}
+
+func (p *fileResumeStore) Store(s ResumeSession) error {
+	raw, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	ss, err := toSession(s)
+	if err != nil {
+		return err
+	}
+	filename := p.filename(ss.Token())
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return err
+	}
+	p.locker.Lock()
+	p.live[string(ss.Token())] = ss
+	p.deadlines[string(ss.Token())] = ss.Deadline()
+	p.locker.Unlock()
+	return nil
+}
+
+func (p *fileResumeStore) Remove(token []byte) (ResumeSession, bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	return p.remove(token)
+}
+
+func (p *fileResumeStore) Expire(now time.Time) (deads []ResumeSession) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	for token, deadline := range p.deadlines {
+		if !now.After(deadline) {
+			continue
+		}
+		if s, ok := p.remove([]byte(token)); ok {
+			deads = append(deads, s)
+		}
+	}
+	return
+}
+
+// Close closes all live sessions, session files will be kept for next startup.
+func (p *fileResumeStore) Close() (err error) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	for token, s := range p.live {
+		if e := s.Close(); e != nil {
+			err = e
+		}
+		delete(p.live, token)
+	}
+	return
+}
+
+func (p *fileResumeStore) remove(token []byte) (s ResumeSession, ok bool) {
+	if _, ok = p.deadlines[string(token)]; !ok {
+		return
+	}
+	filename := p.filename(token)
+	if live, exist := p.live[string(token)]; exist {
+		s = live
+	} else if decoded, err := p.read(filename); err == nil {
+		s = decoded
+	} else {
+		logger.Warnf("load session file failed: %s\n", err)
+		ok = false
+	}
+	delete(p.live, string(token))
+	delete(p.deadlines, string(token))
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("remove session file failed: %s\n", err)
+	}
+	return
+}
+
+func (p *fileResumeStore) read(filename string) (*session.Session, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return session.Unmarshal(raw)
+}
+
+func (p *fileResumeStore) filename(token []byte) string {
+	return filepath.Join(p.dir, hex.EncodeToString(token)+resumeSessionFileExt)
+}
+
+func toSession(s ResumeSession) (*session.Session, error) {
+	if ss, ok := s.(*session.Session); ok {
+		return ss, nil
+	}
+	raw, err := s.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "encode session failed")
+	}
+	return session.Unmarshal(raw)
+}
@@ -0,0 +1,414 @@
+package rsocket_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// brokenProxy forwards TCP connections and can reset all of them.
+type brokenProxy struct {
+	sync.Mutex
+	listener net.Listener
+	conns    []net.Conn
+}
+
+func (p *brokenProxy) serve(target string) {
+	for {
+		c, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			_ = c.Close()
+			continue
+		}
+		p.Lock()
+		p.conns = append(p.conns, c, upstream)
+		p.Unlock()
+		go func() {
+			_, _ = io.Copy(upstream, c)
+		}()
+		go func() {
+			_, _ = io.Copy(c, upstream)
+		}()
+	}
+}
+
+func (p *brokenProxy) reset() {
+	p.Lock()
+	defer p.Unlock()
+	for _, it := range p.conns {
+		if tc, ok := it.(*net.TCPConn); ok {
+			_ = tc.SetLinger(0)
+		}
+		_ = it.Close()
+	}
+	p.conns = nil
+}
+
+func TestResume(t *testing.T) {
+	const (
+		serverAddr = "127.0.0.1:7979"
+		proxyAddr  = "127.0.0.1:7980"
+		totals     = 30
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Resume().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(RequestStream(func(msg Payload) flux.Flux {
+					return flux.Create(func(ctx context.Context, s flux.Sink) {
+						for i := 0; i < totals; i++ {
+							s.Next(NewString(fmt.Sprintf("%d", i), ""))
+							time.Sleep(20 * time.Millisecond)
+						}
+						s.Complete()
+					})
+				})), nil
+			}).
+			Transport("tcp://" + serverAddr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	l, err := net.Listen("tcp", proxyAddr)
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+	proxy := &brokenProxy{listener: l}
+	go proxy.serve(serverAddr)
+
+	events := make(chan ResumeEvent, 16)
+	cli, err := Connect().
+		Resume(
+			WithClientResumeBackoff(ConstantBackoff(100*time.Millisecond)),
+			WithClientResumeListener(func(event ResumeEvent) {
+				events <- event
+			}),
+		).
+		Transport("tcp://" + proxyAddr).
+		Start(context.Background())
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	var received []string
+	done := make(chan struct{})
+	cli.RequestStream(NewString("hello", "")).
+		DoFinally(func(s rx.SignalType) {
+			close(done)
+		}).
+		Subscribe(ctx, rx.OnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+			if len(received) == 5 {
+				proxy.reset()
+			}
+		}))
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "resume timeout")
+	}
+	assert.Len(t, received, totals)
+	for i, it := range received {
+		assert.Equal(t, fmt.Sprintf("%d", i), it, "bad element order")
+	}
+	assert.Equal(t, ResumeAttempt, (<-events).Type)
+	success := <-events
+	assert.Equal(t, ResumeSuccess, success.Type)
+	assert.Equal(t, 1, success.Attempt)
+}
+
+func TestResume_GiveUp(t *testing.T) {
+	const (
+		serverAddr = "127.0.0.1:7988"
+		proxyAddr  = "127.0.0.1:7989"
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Resume().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(), nil
+			}).
+			Transport("tcp://" + serverAddr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	l, err := net.Listen("tcp", proxyAddr)
+	require.NoError(t, err)
+	proxy := &brokenProxy{listener: l}
+	go proxy.serve(serverAddr)
+
+	events := make(chan ResumeEvent, 16)
+	closed := make(chan struct{})
+	cli, err := Connect().
+		Resume(
+			WithClientResumeBackoff(ExponentialBackoff(20*time.Millisecond, 100*time.Millisecond, 2)),
+			WithClientResumeMaxAttempts(2),
+			WithClientResumeTimeout(time.Second),
+			WithClientResumeListener(func(event ResumeEvent) {
+				events <- event
+			}),
+		).
+		OnClose(func(err error) {
+			close(closed)
+		}).
+		Transport("tcp://" + proxyAddr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// Stop accepting, so all resume attempts will fail.
+	_ = l.Close()
+	proxy.reset()
+
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "client should be closed after max attempts")
+	}
+	close(events)
+	var types []ResumeEventType
+	for it := range events {
+		types = append(types, it.Type)
+	}
+	assert.Equal(t, []ResumeEventType{ResumeAttempt, ResumeFailure, ResumeAttempt, ResumeFailure, ResumeGiveUp}, types)
+}
+
+func TestResume_DuplicatedToken(t *testing.T) {
+	const addr = "127.0.0.1:8015"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Resume().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	u, err := transport.ParseURI("tcp://" + addr)
+	require.NoError(t, err)
+	setup := func() *transport.Transport {
+		tp, err := u.MakeClientTransport(ctx, nil, nil)
+		require.NoError(t, err)
+		setup := framing.NewFrameSetup(common.DefaultVersion, 10*time.Second, 60*time.Second, []byte("token"), nil, nil, nil, nil, false)
+		require.NoError(t, tp.Send(setup, true))
+		return tp
+	}
+
+	// session of the first connection is kept for resuming after it is lost.
+	_ = setup().Close()
+	time.Sleep(200 * time.Millisecond)
+
+	tp := setup()
+	defer func() {
+		_ = tp.Close()
+	}()
+	require.NoError(t, tp.Connection().SetDeadline(time.Now().Add(3*time.Second)))
+	f, err := tp.Connection().Read()
+	require.NoError(t, err)
+	require.IsType(t, &framing.FrameError{}, f)
+	assert.Equal(t, common.ErrorCodeRejectedSetup, f.(*framing.FrameError).ErrorCode())
+}
+
+func TestResume_FileStore(t *testing.T) {
+	const (
+		serverAddr1 = "127.0.0.1:7981"
+		serverAddr2 = "127.0.0.1:7982"
+		proxyAddr   = "127.0.0.1:7983"
+	)
+	dir, err := ioutil.TempDir("", "rsocket-sessions")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var accepted atomic.Int32
+	serve := func(addr string) {
+		store, err := NewFileResumeStore(dir)
+		require.NoError(t, err)
+		serving := make(chan struct{})
+		go func() {
+			_ = Receive().
+				Resume(WithServerResumeStore(store)).
+				OnStart(func() {
+					close(serving)
+				}).
+				Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+					accepted.Inc()
+					return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+						return mono.Just(msg)
+					})), nil
+				}).
+				Transport("tcp://" + addr).
+				Serve(ctx)
+		}()
+		<-serving
+	}
+	proxyTo := func(target string) *brokenProxy {
+		l, err := net.Listen("tcp", proxyAddr)
+		require.NoError(t, err)
+		proxy := &brokenProxy{listener: l}
+		go proxy.serve(target)
+		return proxy
+	}
+
+	serve(serverAddr1)
+	proxy := proxyTo(serverAddr1)
+
+	cli, err := Connect().
+		Resume().
+		Transport("tcp://" + proxyAddr).
+		Start(context.Background())
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+	res, err := cli.RequestResponse(NewString("hello", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", res.DataUTF8())
+
+	// Break connection, then resume on another server which shares session files.
+	_ = proxy.listener.Close()
+	proxy.reset()
+	time.Sleep(300 * time.Millisecond)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "session should be persisted")
+
+	serve(serverAddr2)
+	proxy = proxyTo(serverAddr2)
+	defer func() {
+		_ = proxy.listener.Close()
+	}()
+
+	timeout, cancelTimeout := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelTimeout()
+	res, err = cli.RequestResponse(NewString("world", "")).Block(timeout)
+	require.NoError(t, err)
+	assert.Equal(t, "world", res.DataUTF8())
+	assert.Equal(t, int32(2), accepted.Load(), "session should be revived by acceptor")
+}
+
+func TestResume_Replay(t *testing.T) {
+	const (
+		serverAddr = "127.0.0.1:8011"
+		proxyAddr  = "127.0.0.1:8012"
+		totals     = 30
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan string, totals*2)
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Resume().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(FireAndForget(func(msg Payload) {
+					received <- msg.DataUTF8()
+				})), nil
+			}).
+			Transport("tcp://" + serverAddr).
+			Serve(ctx)
+	}()
+	<-serving
+
+	l, err := net.Listen("tcp", proxyAddr)
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+	proxy := &brokenProxy{listener: l}
+	go proxy.serve(serverAddr)
+
+	cli, err := Connect().
+		Resume(WithClientResumeBackoff(ConstantBackoff(100 * time.Millisecond))).
+		Transport("tcp://" + proxyAddr).
+		Start(context.Background())
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// Frames written before reset may be lost in flight, they are retransmitted from position of server.
+	for i := 0; i < totals; i++ {
+		cli.FireAndForget(NewString(fmt.Sprintf("%d", i), ""))
+		if i == totals/2 {
+			proxy.reset()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var all []string
+	for len(all) < totals {
+		select {
+		case it := <-received:
+			all = append(all, it)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "replay timeout", "received: %v", all)
+		}
+	}
+	for i, it := range all {
+		assert.Equal(t, fmt.Sprintf("%d", i), it, "bad element order")
+	}
+	select {
+	case it := <-received:
+		assert.Fail(t, "duplicated frame", it)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
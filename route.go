@@ -0,0 +1,227 @@
+package rsocket
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/codec"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/internal/socket"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+var errRouteTooManyMetadata = errors.New("rsocket: cannot send more metadata entries with routing metadata MIME type")
+
+// RouteSpec is used to build a request with routing metadata, it is created by Route.
+// Metadata of request is a composite metadata which contains routing, data MIME type, authentication
+// and custom entries, or only routing tags if metadata MIME type of connection is message/x.rsocket.routing.v0.
+// Default authentication of client set by ClientBuilder.RouteAuthentication is attached unless request sets its own one,
+// it is skipped if metadata MIME type of connection is message/x.rsocket.routing.v0.
+type RouteSpec interface {
+	// Data sets data of request.
+	// []byte and string are sent as is, other values are encoded by codec of data MIME type in the default codec registry.
+	Data(data interface{}) RouteSpec
+	// DataMimeType sets data MIME type of current request, it will be sent as a message/x.rsocket.mime-type.v0 entry.
+	// Data MIME type of connection is used by default.
+	DataMimeType(mime string) RouteSpec
+	// Metadata appends a custom metadata entry.
+	Metadata(mime string, metadata []byte) RouteSpec
+	// Authentication appends an authentication metadata entry.
+	Authentication(auth extension.Authentication) RouteSpec
+	// FireAndForget sends request as FireAndForget.
+	FireAndForget() error
+	// RetrieveMono sends request as RequestResponse.
+	RetrieveMono() mono.Mono
+	// RetrieveFlux sends request as RequestStream.
+	RetrieveFlux() flux.Flux
+	// Retrieve sends request as RequestResponse and decodes response into out by codec of data MIME type.
+	// out should be a pointer, response is copied as is if out is *[]byte or *string.
+	Retrieve(ctx context.Context, out interface{}) error
+	// RetrieveAll sends request as RequestStream and decodes all responses into out, which should be a pointer to slice.
+	RetrieveAll(ctx context.Context, out interface{}) error
+}
+
+type metadataEntry struct {
+	mime     string
+	metadata []byte
+}
+
+type routeSpec struct {
+	requester        RSocket
+	metadataMimeType string
+	dataMimeType     string
+	mimeTypeChanged  bool
+	tags             []string
+	data             interface{}
+	entries          []metadataEntry
+	auth             *extension.Authentication
+}
+
+// Route creates a request with routing metadata on requester, route is the first routing tag.
+// MIME types and default authentication of connection are used if requester is a Client started by ClientBuilder,
+// otherwise metadata is sent as composite metadata and data as application/binary.
+func Route(requester RSocket, route string, tags ...string) RouteSpec {
+	if c, ok := requester.(*implClient); ok {
+		spec := newRouteSpec(c, &c.setup, route, tags)
+		spec.auth = c.routeAuth
+		return spec
+	}
+	return newRouteSpec(requester, &socket.SetupInfo{
+		MetadataMimeType: []byte(extension.MessageCompositeMetadata.String()),
+		DataMimeType:     _defaultMimeType,
+	}, route, tags)
+}
+
+func newRouteSpec(requester RSocket, setup *socket.SetupInfo, route string, tags []string) *routeSpec {
+	return &routeSpec{
+		requester:        requester,
+		metadataMimeType: string(setup.MetadataMimeType),
+		dataMimeType:     string(setup.DataMimeType),
+		tags:             append([]string{route}, tags...),
+	}
+}
+
+func (p *routeSpec) Data(data interface{}) RouteSpec {
+	p.data = data
+	return p
+}
+
+func (p *routeSpec) DataMimeType(mime string) RouteSpec {
+	p.dataMimeType = mime
+	p.mimeTypeChanged = true
+	return p
+}
+
+func (p *routeSpec) Metadata(mime string, metadata []byte) RouteSpec {
+	p.entries = append(p.entries, metadataEntry{mime: mime, metadata: metadata})
+	return p
+}
+
+func (p *routeSpec) Authentication(auth extension.Authentication) RouteSpec {
+	return p.Metadata(extension.MessageAuthentication.String(), auth.Bytes())
+}
+
+func (p *routeSpec) FireAndForget() error {
+	req, err := p.build()
+	if err != nil {
+		return err
+	}
+	p.requester.FireAndForget(req)
+	return nil
+}
+
+func (p *routeSpec) RetrieveMono() mono.Mono {
+	req, err := p.build()
+	if err != nil {
+		return mono.Error(err)
+	}
+	return p.requester.RequestResponse(req)
+}
+
+func (p *routeSpec) RetrieveFlux() flux.Flux {
+	req, err := p.build()
+	if err != nil {
+		return flux.Error(err)
+	}
+	return p.requester.RequestStream(req)
+}
+
+func (p *routeSpec) Retrieve(ctx context.Context, out interface{}) error {
+	res, err := p.RetrieveMono().Block(ctx)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return nil
+	}
+	return decodeData(p.dataMimeType, res.Data(), out)
+}
+
+func (p *routeSpec) RetrieveAll(ctx context.Context, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("rsocket: cannot decode into %T, a pointer to slice is required", out)
+	}
+	slice := rv.Elem()
+	var decodeErr error
+	_, err := p.RetrieveFlux().
+		DoOnNext(func(input payload.Payload) {
+			if decodeErr != nil {
+				return
+			}
+			elem := reflect.New(slice.Type().Elem())
+			if decodeErr = decodeData(p.dataMimeType, input.Data(), elem.Interface()); decodeErr == nil {
+				slice.Set(reflect.Append(slice, elem.Elem()))
+			}
+		}).
+		BlockLast(ctx)
+	if err != nil {
+		return err
+	}
+	return decodeErr
+}
+
+func (p *routeSpec) build() (payload.Payload, error) {
+	data, err := encodeData(p.dataMimeType, p.data)
+	if err != nil {
+		return nil, err
+	}
+	routing, err := extension.EncodeRouting(p.tags[0], p.tags[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	if p.metadataMimeType == extension.MessageRouting.String() {
+		if p.mimeTypeChanged || len(p.entries) > 0 {
+			return nil, errRouteTooManyMetadata
+		}
+		return payload.New(data, routing), nil
+	}
+	builder := extension.NewCompositeMetadataBuilder().PushWellKnown(extension.MessageRouting, routing)
+	if p.mimeTypeChanged {
+		mimeType, err := extension.EncodeMimeType(p.dataMimeType)
+		if err != nil {
+			return nil, err
+		}
+		builder.PushWellKnown(extension.MessageMimeType, mimeType)
+	}
+	authenticated := false
+	for _, it := range p.entries {
+		builder.Push(it.mime, it.metadata)
+		authenticated = authenticated || it.mime == extension.MessageAuthentication.String()
+	}
+	if !authenticated && p.auth != nil {
+		builder.PushWellKnown(extension.MessageAuthentication, p.auth.Bytes())
+	}
+	metadata, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return payload.New(data, metadata), nil
+}
+
+func encodeData(mime string, data interface{}) ([]byte, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return codec.Encode(mime, data)
+}
+
+func decodeData(mime string, data []byte, out interface{}) error {
+	switch v := out.(type) {
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	return codec.Decode(mime, data, out)
+}
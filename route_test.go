@@ -0,0 +1,184 @@
+package rsocket_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/router"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrder struct {
+	ID    int    `json:"id"`
+	Item  string `json:"item"`
+	Owner string `json:"owner,omitempty"`
+}
+
+func TestRoute(t *testing.T) {
+	const addr = "127.0.0.1:7996"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := router.New().
+		Response("orders.create", func(msg Payload, vars router.Vars) mono.Mono {
+			var order testOrder
+			if err := json.Unmarshal(msg.Data(), &order); err != nil {
+				return mono.Error(err)
+			}
+			metadata, _ := msg.Metadata()
+			scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+			for scanner.Scan() {
+				mimeType, raw, err := scanner.Metadata()
+				if err != nil {
+					return mono.Error(err)
+				}
+				if mimeType == extension.MessageAuthentication.String() {
+					auth, _ := extension.ParseAuthentication(raw)
+					order.Owner = string(auth.Payload())
+				}
+			}
+			order.ID = 1
+			b, _ := json.Marshal(order)
+			return mono.Just(New(b, nil))
+		}).
+		Stream("orders.{owner}", func(msg Payload, vars router.Vars) flux.Flux {
+			var orders []Payload
+			for i := 1; i <= 3; i++ {
+				b, _ := json.Marshal(testOrder{ID: i, Owner: vars["owner"]})
+				orders = append(orders, New(b, nil))
+			}
+			return flux.Just(orders...)
+		}).
+		Response("echo", func(msg Payload, vars router.Vars) mono.Mono {
+			return mono.Just(msg)
+		})
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return r.Socket(), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		DataMimeType(extension.ApplicationJSON.String()).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	var created testOrder
+	err = Route(cli, "orders.create").
+		Data(testOrder{Item: "apple"}).
+		Authentication(extension.NewAuthentication("bearer", []byte("alice"))).
+		Retrieve(ctx, &created)
+	require.NoError(t, err)
+	assert.Equal(t, testOrder{ID: 1, Item: "apple", Owner: "alice"}, created)
+
+	var orders []testOrder
+	err = Route(cli, "orders.bob").RetrieveAll(ctx, &orders)
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	for i, it := range orders {
+		assert.Equal(t, i+1, it.ID)
+		assert.Equal(t, "bob", it.Owner)
+	}
+
+	var s string
+	err = Route(cli, "echo").DataMimeType(extension.TextPlain.String()).Data("hello").Retrieve(ctx, &s)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+
+	// non-JSON values cannot be encoded with text/plain
+	err = Route(cli, "echo").DataMimeType(extension.TextPlain.String()).Data(testOrder{}).Retrieve(ctx, &s)
+	assert.Error(t, err)
+
+	err = Route(cli, "orders.bob").RetrieveAll(ctx, &created)
+	assert.Error(t, err, "should decode into slice only")
+
+	_, err = Route(cli, "not.found").RetrieveMono().Block(ctx)
+	require.Error(t, err)
+	assert.Equal(t, ErrorCodeRejected, err.(Error).ErrorCode())
+
+	// default authentication of client
+	authCli, err := Connect().
+		DataMimeType(extension.ApplicationJSON.String()).
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		RouteAuthentication(extension.NewAuthentication("bearer", []byte("carol"))).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = authCli.Close()
+	}()
+	err = Route(authCli, "orders.create").Data(testOrder{Item: "pear"}).Retrieve(ctx, &created)
+	require.NoError(t, err)
+	assert.Equal(t, testOrder{ID: 1, Item: "pear", Owner: "carol"}, created)
+	// authentication of request overrides the default one
+	err = Route(authCli, "orders.create").
+		Data(testOrder{Item: "pear"}).
+		Authentication(extension.NewAuthentication("bearer", []byte("alice"))).
+		Retrieve(ctx, &created)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", created.Owner)
+
+	// routing metadata MIME type
+	cli2, err := Connect().
+		MetadataMimeType(extension.MessageRouting.String()).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli2.Close()
+	}()
+	err = Route(cli2, "echo").Data("world").Retrieve(ctx, &s)
+	require.NoError(t, err)
+	assert.Equal(t, "world", s)
+	_, err = Route(cli2, "echo").Metadata("text/plain", []byte("x")).RetrieveMono().Block(ctx)
+	assert.Error(t, err)
+}
+
+func TestRoute_Requester(t *testing.T) {
+	var tags []string
+	requester := NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+		metadata, _ := msg.Metadata()
+		scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+		for scanner.Scan() {
+			mimeType, m, err := scanner.Metadata()
+			if err != nil {
+				return mono.Error(err)
+			}
+			if mimeType == extension.MessageRouting.String() {
+				tags, _ = extension.ParseRoutingTags(m)
+			}
+		}
+		return mono.Just(msg)
+	}))
+	var s string
+	err := Route(requester, "echo", "v1").Data("hello").Retrieve(context.Background(), &s)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+	assert.Equal(t, []string{"echo", "v1"}, tags)
+}
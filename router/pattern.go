@@ -0,0 +1,107 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	wildcardSegment = "*"
+	wildcardTail    = "**"
+)
+
+// Vars holds path variables captured by a route pattern, eg: route "user.{id}" captures "id".
+type Vars map[string]string
+
+type segmentKind int8
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentVariable
+	segmentWildcard
+	segmentTail
+)
+
+type segment struct {
+	kind  segmentKind
+	value string
+}
+
+type pattern struct {
+	raw      string
+	segments []segment
+	literal  bool
+}
+
+// compilePattern compiles route pattern, segments of pattern are separated by dot.
+// A segment can be a literal, a variable like "{name}", a wildcard "*" which matches exactly one segment,
+// or a tail wildcard "**" which matches all remaining segments and must be the last one.
+func compilePattern(route string) (*pattern, error) {
+	if route == "" {
+		return nil, fmt.Errorf("router: empty route")
+	}
+	parts := strings.Split(route, ".")
+	p := &pattern{
+		raw:      route,
+		segments: make([]segment, 0, len(parts)),
+		literal:  true,
+	}
+	names := make(map[string]struct{})
+	for i, it := range parts {
+		switch {
+		case it == "":
+			return nil, fmt.Errorf("router: empty segment in route %s", route)
+		case it == wildcardTail:
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("router: %s must be the last segment in route %s", wildcardTail, route)
+			}
+			p.segments = append(p.segments, segment{kind: segmentTail})
+			p.literal = false
+		case it == wildcardSegment:
+			p.segments = append(p.segments, segment{kind: segmentWildcard})
+			p.literal = false
+		case strings.HasPrefix(it, "{") && strings.HasSuffix(it, "}"):
+			name := it[1 : len(it)-1]
+			if name == "" {
+				return nil, fmt.Errorf("router: empty variable name in route %s", route)
+			}
+			if _, ok := names[name]; ok {
+				return nil, fmt.Errorf("router: duplicated variable %s in route %s", name, route)
+			}
+			names[name] = struct{}{}
+			p.segments = append(p.segments, segment{kind: segmentVariable, value: name})
+			p.literal = false
+		default:
+			p.segments = append(p.segments, segment{kind: segmentLiteral, value: it})
+		}
+	}
+	return p, nil
+}
+
+// match returns captured variables and true if route matches current pattern.
+func (p *pattern) match(route string) (vars Vars, ok bool) {
+	parts := strings.Split(route, ".")
+	for i, it := range p.segments {
+		if it.kind == segmentTail {
+			return vars, i < len(parts)
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch it.kind {
+		case segmentLiteral:
+			if it.value != parts[i] {
+				return nil, false
+			}
+		case segmentVariable:
+			if vars == nil {
+				vars = make(Vars)
+			}
+			vars[it.value] = parts[i]
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return vars, true
+}
@@ -0,0 +1,290 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+)
+
+type (
+	// FireAndForgetHandler handles FireAndForget requests of a route.
+	FireAndForgetHandler = func(msg payload.Payload, vars Vars)
+	// ResponseHandler handles RequestResponse requests of a route.
+	ResponseHandler = func(msg payload.Payload, vars Vars) mono.Mono
+	// StreamHandler handles RequestStream requests of a route.
+	StreamHandler = func(msg payload.Payload, vars Vars) flux.Flux
+	// ChannelHandler handles RequestChannel requests of a route, msgs starts with the payload which carries the route.
+	ChannelHandler = func(msgs flux.Flux, vars Vars) flux.Flux
+)
+
+type interaction int8
+
+const (
+	interactionFireAndForget interaction = iota
+	interactionResponse
+	interactionStream
+	interactionChannel
+)
+
+func (i interaction) String() string {
+	switch i {
+	case interactionFireAndForget:
+		return "FireAndForget"
+	case interactionResponse:
+		return "RequestResponse"
+	case interactionStream:
+		return "RequestStream"
+	case interactionChannel:
+		return "RequestChannel"
+	default:
+		return "Unknown"
+	}
+}
+
+type routeKey struct {
+	route       string
+	interaction interaction
+}
+
+type entry struct {
+	pattern *pattern
+	handler interface{}
+}
+
+// Router dispatches requests to handlers by route in routing metadata.
+// See: https://github.com/rsocket/rsocket/blob/master/Extensions/Routing.md
+//
+// The route is the first tag of routing metadata, which is read from the message/x.rsocket.routing.v0 entry of
+// composite metadata, or from the whole metadata if metadata MIME type of connection is message/x.rsocket.routing.v0.
+// Routes without variables or wildcards are matched first, then patterns are matched in order of registration.
+//
+// A request without routing metadata will be responded with ErrorCodeInvalid,
+// a request whose route is not registered will be responded with ErrorCodeRejected.
+type Router struct {
+	locker   sync.RWMutex
+	literals map[routeKey]interface{}
+	patterns map[interaction][]entry
+	mp       func(msg payload.Payload)
+}
+
+// New creates a new Router.
+func New() *Router {
+	return &Router{
+		literals: make(map[routeKey]interface{}),
+		patterns: make(map[interaction][]entry),
+	}
+}
+
+// FireAndForget registers FireAndForget handler for route.
+func (p *Router) FireAndForget(route string, fn FireAndForgetHandler) *Router {
+	p.handle(route, interactionFireAndForget, fn, fn == nil)
+	return p
+}
+
+// Response registers RequestResponse handler for route.
+func (p *Router) Response(route string, fn ResponseHandler) *Router {
+	p.handle(route, interactionResponse, fn, fn == nil)
+	return p
+}
+
+// Stream registers RequestStream handler for route.
+func (p *Router) Stream(route string, fn StreamHandler) *Router {
+	p.handle(route, interactionStream, fn, fn == nil)
+	return p
+}
+
+// Channel registers RequestChannel handler for route.
+func (p *Router) Channel(route string, fn ChannelHandler) *Router {
+	p.handle(route, interactionChannel, fn, fn == nil)
+	return p
+}
+
+// MetadataPush registers MetadataPush handler, MetadataPush requests are not routed.
+func (p *Router) MetadataPush(fn func(msg payload.Payload)) *Router {
+	p.locker.Lock()
+	p.mp = fn
+	p.locker.Unlock()
+	return p
+}
+
+// Socket returns a RSocket which dispatches requests to current Router.
+// It can be returned by ServerAcceptor or ClientSocketAcceptor.
+func (p *Router) Socket() rsocket.RSocket {
+	return rsocket.NewAbstractSocket(
+		rsocket.FireAndForget(p.fireAndForget),
+		rsocket.MetadataPush(p.metadataPush),
+		rsocket.RequestResponse(p.requestResponse),
+		rsocket.RequestStream(p.requestStream),
+		rsocket.RequestChannel(p.requestChannel),
+	)
+}
+
+// handle registers handler, it panics if handler is nil, route is invalid or registered already.
+func (p *Router) handle(route string, i interaction, handler interface{}, isNil bool) {
+	if isNil {
+		panic(fmt.Sprintf("router: nil %s handler for route %s", i, route))
+	}
+	pt, err := compilePattern(route)
+	if err != nil {
+		panic(err)
+	}
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if pt.literal {
+		k := routeKey{route: route, interaction: i}
+		if _, ok := p.literals[k]; ok {
+			panic(fmt.Sprintf("router: %s handler for route %s exists already", i, route))
+		}
+		p.literals[k] = handler
+		return
+	}
+	for _, it := range p.patterns[i] {
+		if it.pattern.raw == route {
+			panic(fmt.Sprintf("router: %s handler for route %s exists already", i, route))
+		}
+	}
+	p.patterns[i] = append(p.patterns[i], entry{pattern: pt, handler: handler})
+}
+
+// lookup returns handler and variables of the route carried by msg.
+func (p *Router) lookup(msg payload.Payload, i interaction) (handler interface{}, vars Vars, err error) {
+	route, ok := extractRoute(msg)
+	if !ok {
+		err = errInvalid(fmt.Sprintf("missing route in %s", i))
+		return
+	}
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	if h, ok := p.literals[routeKey{route: route, interaction: i}]; ok {
+		handler = h
+		return
+	}
+	for _, it := range p.patterns[i] {
+		if vars, ok = it.pattern.match(route); ok {
+			handler = it.handler
+			return
+		}
+	}
+	err = errRejected(fmt.Sprintf("no %s handler for route %s", i, route))
+	return
+}
+
+func (p *Router) fireAndForget(msg payload.Payload) {
+	h, vars, err := p.lookup(msg, interactionFireAndForget)
+	if err != nil {
+		return
+	}
+	h.(FireAndForgetHandler)(msg, vars)
+}
+
+func (p *Router) metadataPush(msg payload.Payload) {
+	p.locker.RLock()
+	fn := p.mp
+	p.locker.RUnlock()
+	if fn != nil {
+		fn(msg)
+	}
+}
+
+func (p *Router) requestResponse(msg payload.Payload) mono.Mono {
+	h, vars, err := p.lookup(msg, interactionResponse)
+	if err != nil {
+		return mono.Error(err)
+	}
+	return h.(ResponseHandler)(msg, vars)
+}
+
+func (p *Router) requestStream(msg payload.Payload) flux.Flux {
+	h, vars, err := p.lookup(msg, interactionStream)
+	if err != nil {
+		return flux.Error(err)
+	}
+	return h.(StreamHandler)(msg, vars)
+}
+
+func (p *Router) requestChannel(msgs rx.Publisher) flux.Flux {
+	inputs, ok := msgs.(flux.Flux)
+	if !ok {
+		return flux.Error(errInvalid("unsupported channel publisher"))
+	}
+	return inputs.SwitchOnFirst(func(s flux.Signal, f flux.Flux) flux.Flux {
+		first, ok := s.Value()
+		if !ok {
+			return f
+		}
+		h, vars, err := p.lookup(first, interactionChannel)
+		if err != nil {
+			return flux.Error(err)
+		}
+		return h.(ChannelHandler)(f, vars)
+	})
+}
+
+// extractRoute returns the first routing tag in metadata.
+func extractRoute(msg payload.Payload) (route string, ok bool) {
+	metadata, ok := msg.Metadata()
+	if !ok || len(metadata) < 1 {
+		return "", false
+	}
+	raw, found := scanRouting(metadata)
+	if !found {
+		// Metadata MIME type of connection may be message/x.rsocket.routing.v0.
+		raw = metadata
+	}
+	tags, err := extension.ParseRoutingTags(raw)
+	if err != nil || len(tags) < 1 || tags[0] == "" {
+		return "", false
+	}
+	return tags[0], true
+}
+
+// scanRouting returns routing entry of composite metadata.
+func scanRouting(metadata []byte) (raw []byte, found bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			raw, found = nil, false
+		}
+	}()
+	scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+	for scanner.Scan() {
+		mimeType, data, err := scanner.Metadata()
+		if err != nil {
+			return nil, false
+		}
+		if mimeType == extension.MessageRouting.String() {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+type routeError struct {
+	code rsocket.ErrorCode
+	msg  string
+}
+
+func errInvalid(msg string) error {
+	return routeError{code: rsocket.ErrorCodeInvalid, msg: msg}
+}
+
+func errRejected(msg string) error {
+	return routeError{code: rsocket.ErrorCodeRejected, msg: msg}
+}
+
+func (e routeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.msg)
+}
+
+func (e routeError) ErrorCode() rsocket.ErrorCode {
+	return e.code
+}
+
+func (e routeError) ErrorData() []byte {
+	return []byte(e.msg)
+}
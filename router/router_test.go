@@ -0,0 +1,142 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/router"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routed(t *testing.T, route string, data string) payload.Payload {
+	tags, err := extension.EncodeRouting(route)
+	require.NoError(t, err)
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnown(extension.MessageRouting, tags).
+		Build()
+	require.NoError(t, err)
+	return payload.New([]byte(data), metadata)
+}
+
+func assertErrorCode(t *testing.T, code rsocket.ErrorCode, err error) {
+	require.Error(t, err)
+	e, ok := err.(rsocket.Error)
+	require.True(t, ok, "should be a rsocket error")
+	assert.Equal(t, code, e.ErrorCode())
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	fnf := make(chan string, 1)
+	r := router.New().
+		FireAndForget("log.{level}", func(msg payload.Payload, vars router.Vars) {
+			fnf <- vars["level"] + ":" + msg.DataUTF8()
+		}).
+		Response("user.get", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.NewString("literal", ""))
+		}).
+		Response("user.{id}", func(msg payload.Payload, vars router.Vars) mono.Mono {
+			return mono.Just(payload.NewString("user "+vars["id"], ""))
+		}).
+		Stream("prices.*", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Just(msg, msg)
+		}).
+		Stream("events.**", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Just(payload.NewString("event", ""))
+		}).
+		Channel("echo", func(msgs flux.Flux, vars router.Vars) flux.Flux {
+			return msgs.Map(func(msg payload.Payload) payload.Payload {
+				return payload.NewString(msg.DataUTF8()+"!", "")
+			})
+		})
+	sk := r.Socket()
+
+	res, err := sk.RequestResponse(routed(t, "user.get", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "literal", res.DataUTF8())
+
+	res, err = sk.RequestResponse(routed(t, "user.42", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user 42", res.DataUTF8())
+
+	// raw routing metadata without composite metadata
+	tags, _ := extension.EncodeRouting("user.7")
+	res, err = sk.RequestResponse(payload.New(nil, tags)).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user 7", res.DataUTF8())
+
+	var received []string
+	_, err = sk.RequestStream(routed(t, "prices.btc", "1")).
+		DoOnNext(func(msg payload.Payload) {
+			received = append(received, msg.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "1"}, received)
+
+	last, err := sk.RequestStream(routed(t, "events.a.b.c", "")).BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "event", last.DataUTF8())
+
+	received = nil
+	_, err = sk.RequestChannel(flux.Just(routed(t, "echo", "a"), payload.NewString("b", ""))).
+		DoOnNext(func(msg payload.Payload) {
+			received = append(received, msg.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a!", "b!"}, received)
+
+	sk.FireAndForget(routed(t, "log.warn", "disk full"))
+	assert.Equal(t, "warn:disk full", <-fnf)
+
+	// unknown routes
+	_, err = sk.RequestResponse(routed(t, "order.get.1", "")).Block(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeRejected, err)
+	_, err = sk.RequestStream(routed(t, "prices", "")).BlockLast(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeRejected, err)
+	_, err = sk.RequestStream(routed(t, "events", "")).BlockLast(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeRejected, err)
+	_, err = sk.RequestStream(routed(t, "user.get", "")).BlockLast(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeRejected, err)
+	_, err = sk.RequestChannel(flux.Just(routed(t, "nope", ""))).BlockLast(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeRejected, err)
+
+	// missing route
+	_, err = sk.RequestResponse(payload.NewString("no route", "")).Block(ctx)
+	assertErrorCode(t, rsocket.ErrorCodeInvalid, err)
+}
+
+func TestRouter_Register(t *testing.T) {
+	r := router.New()
+	fn := func(msg payload.Payload, vars router.Vars) mono.Mono {
+		return mono.Empty()
+	}
+	r.Response("a.{id}", fn)
+	assert.Panics(t, func() {
+		r.Response("a.{id}", fn)
+	}, "duplicated route")
+	assert.Panics(t, func() {
+		r.Response("a..b", fn)
+	}, "empty segment")
+	assert.Panics(t, func() {
+		r.Response("a.**.b", fn)
+	}, "tail wildcard should be the last")
+	assert.Panics(t, func() {
+		r.Response("a.{id}.{id}", fn)
+	}, "duplicated variable")
+	assert.Panics(t, func() {
+		r.Response("b", nil)
+	}, "nil handler")
+	assert.NotPanics(t, func() {
+		r.Stream("a.{id}", func(msg payload.Payload, vars router.Vars) flux.Flux {
+			return flux.Empty()
+		})
+	})
+}
@@ -107,6 +107,7 @@ func RequestStream(fn func(msg payload.Payload) flux.Flux) OptAbstractSocket {
 }
 
 // RequestChannel register request handler for RequestChannel.
+// If requester cancels the channel, the returned flux is cancelled and msgs is completed, since requester stops sending too.
 func RequestChannel(fn func(msgs rx.Publisher) flux.Flux) OptAbstractSocket {
 	return func(opts *socket.AbstractRSocket) {
 		opts.RC = fn
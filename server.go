@@ -3,29 +3,44 @@ package rsocket
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
 	"time"
 
 	"github.com/rsocket/rsocket-go/internal/common"
 	"github.com/rsocket/rsocket-go/internal/fragmentation"
 	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/instrument"
 	"github.com/rsocket/rsocket-go/internal/session"
 	"github.com/rsocket/rsocket-go/internal/socket"
 	"github.com/rsocket/rsocket-go/internal/transport"
 	"github.com/rsocket/rsocket-go/lease"
 	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/metrics"
+	"go.uber.org/atomic"
 )
 
 const (
 	serverSessionCleanInterval = 500 * time.Millisecond
 	serverSessionDuration      = 30 * time.Second
+	serverDrainCheckInterval   = 100 * time.Millisecond
 )
 
 var (
 	errUnavailableResume    = []byte("resume not supported")
+	errUnresumableTransport = []byte("resume not supported by transport")
 	errUnavailableLease     = []byte("lease not supported")
 	errDuplicatedSetupToken = []byte("duplicated setup token")
+	errServerShuttingDown   = []byte("server is shutting down")
 )
 
+var errNoTransport = errors.New("rsocket: no transport is specified")
+
+// GoAwayMetadata is the metadata of METADATA_PUSH which is sent to clients without lease when server is shutting down.
+// Clients should stop sending new requests after receiving it.
+var GoAwayMetadata = []byte("rsocket-goaway")
+
 type (
 	// OpServerResume represents resume options for RSocket server.
 	OpServerResume func(o *serverResumeOptions)
@@ -37,6 +52,26 @@ type (
 		Lease(leases lease.Leases) ServerBuilder
 		// Resume enable resume for current server.
 		Resume(opts ...OpServerResume) ServerBuilder
+		// Registry binds a connection registry, accepted connections will be registered into it.
+		Registry(registry Registry) ServerBuilder
+		// Interceptor appends interceptors which wrap requests of both sides, they will be invoked in order.
+		Interceptor(interceptors ...Interceptor) ServerBuilder
+		// Metrics enables recording metrics of connections into registry.
+		Metrics(registry metrics.Registry) ServerBuilder
+		// Prefetch limits credits which are requested from clients at a time by RequestStream and RequestChannel.
+		// Unbounded demand of subscribers will be requested in batches of prefetch,
+		// more is requested when 75% of them are received. Default zero means no limit.
+		Prefetch(prefetch int) ServerBuilder
+		// Extension registers a handler for EXT frames of custom extended type from clients.
+		// EXT frames of unregistered extended types will be skipped if they can be ignored,
+		// otherwise the connection will be closed.
+		// The sending socket passed to acceptor implements ExtensionSender.
+		Extension(extendedType uint32, handler ExtensionHandler) ServerBuilder
+		// Authenticator enables authentication of SETUP and requests by message/x.rsocket.authentication.v0 metadata.
+		// SETUP with invalid credentials will be rejected with ERROR[REJECTED_SETUP],
+		// requests with invalid credentials or without any credentials will be rejected with ERROR[REJECTED].
+		// Authenticated principal can be got by PrincipalFromContext with context of requests, see RequestResponseContext.
+		Authenticator(authenticator Authenticator) ServerBuilder
 		// Acceptor register server acceptor which is used to handle incoming RSockets.
 		Acceptor(acceptor ServerAcceptor) ServerTransportBuilder
 		// OnStart register a handler when serve success.
@@ -45,13 +80,18 @@ type (
 
 	// ServerTransportBuilder is used to build a RSocket server with custom Transport string.
 	ServerTransportBuilder interface {
-		// Transport specify transport string.
-		Transport(transport string) Start
+		// Transport specify transport strings, server listens on all of them,
+		// eg: "tcp://127.0.0.1:7878" for backend peers and "ws://127.0.0.1:8080/rsocket" for browsers.
+		// Connections of all transports share acceptor, lease, resume sessions and registry.
+		// Schemes of custom transports can be registered by RegisterTransport.
+		Transport(transports ...string) Start
 	}
 
 	// Start start a RSocket server.
 	Start interface {
 		// Serve serve RSocket server.
+		// OnStart handlers are invoked after all transports are listening.
+		// If any transport fails, the others will be closed and the first error will be returned.
 		Serve(ctx context.Context) error
 		// Serve serve RSocket server with TLS.
 		//
@@ -78,6 +118,18 @@ type (
 		//		Certificates: []tls.Certificate{cert},
 		//	}
 		ServeTLS(ctx context.Context, c *tls.Config) error
+		// ServeListener serve RSocket server on connections accepted from listener instead of transport URI,
+		// eg: a listener of socket activation. Frames are length-prefixed as TCP transport.
+		// Wrap listener by tls.NewListener to enable TLS.
+		ServeListener(ctx context.Context, listener net.Listener) error
+		// Shutdown shutdowns server gracefully.
+		// It stops accepting new connections and tells clients to stop sending new requests,
+		// by a LEASE with zero requests if lease is enabled, otherwise by a METADATA_PUSH with GoAwayMetadata.
+		// Then it waits for streams in flight until they are finished or ctx is done,
+		// finally all connections will be closed with ERROR[CONNECTION_CLOSE].
+		// It returns error of ctx if some streams were not finished in time.
+		// Serve returns once transports are closed, while connections keep draining until Shutdown returns.
+		Shutdown(ctx context.Context) error
 	}
 )
 
@@ -85,10 +137,12 @@ type (
 func Receive() ServerBuilder {
 	return &server{
 		fragment: fragmentation.MaxFragment,
-		sm:       session.NewManager(),
 		done:     make(chan struct{}),
+		shutting: atomic.NewBool(false),
+		sockets:  make(map[socket.ServerSocket]struct{}),
 		resumeOpts: &serverResumeOptions{
 			sessionDuration: serverSessionDuration,
+			store:           NewMemoryResumeStore(),
 		},
 	}
 }
@@ -96,17 +150,27 @@ func Receive() ServerBuilder {
 type serverResumeOptions struct {
 	enable          bool
 	sessionDuration time.Duration
+	store           ResumeStore
 }
 
 type server struct {
 	resumeOpts *serverResumeOptions
 	fragment   int
-	addr       string
+	addrs      []string
 	acc        ServerAcceptor
-	sm         *session.Manager
 	done       chan struct{}
 	onServe    []func()
 	leases     lease.Leases
+	registry   Registry
+	intercept  []Interceptor
+	auth       Authenticator
+	metrics    *instrument.Recorder
+	prefetch   int
+	ext        *socket.Extensions
+	shutting   *atomic.Bool
+	locker     sync.Mutex
+	sockets    map[socket.ServerSocket]struct{}
+	tps        []transport.ServerTransport
 }
 
 func (p *server) Lease(leases lease.Leases) ServerBuilder {
@@ -129,6 +193,36 @@ func (p *server) Resume(opts ...OpServerResume) ServerBuilder {
 	return p
 }
 
+func (p *server) Registry(r Registry) ServerBuilder {
+	p.registry = r
+	return p
+}
+
+func (p *server) Interceptor(interceptors ...Interceptor) ServerBuilder {
+	p.intercept = append(p.intercept, interceptors...)
+	return p
+}
+
+func (p *server) Authenticator(authenticator Authenticator) ServerBuilder {
+	p.auth = authenticator
+	return p
+}
+
+func (p *server) Metrics(registry metrics.Registry) ServerBuilder {
+	p.metrics = instrument.NewRecorder(registry, metrics.RoleServer)
+	return p
+}
+
+func (p *server) Prefetch(prefetch int) ServerBuilder {
+	p.prefetch = prefetch
+	return p
+}
+
+func (p *server) Extension(extendedType uint32, handler ExtensionHandler) ServerBuilder {
+	registerExtension(&p.ext, extendedType, handler)
+	return p
+}
+
 func (p *server) Fragment(mtu int) ServerBuilder {
 	p.fragment = mtu
 	return p
@@ -139,107 +233,186 @@ func (p *server) Acceptor(acceptor ServerAcceptor) ServerTransportBuilder {
 	return p
 }
 
-func (p *server) Transport(transport string) Start {
-	p.addr = transport
+func (p *server) Transport(transports ...string) Start {
+	p.addrs = transports
 	return p
 }
 
 func (p *server) ServeTLS(ctx context.Context, c *tls.Config) error {
-	return p.serve(ctx, c)
+	return p.serveURI(ctx, c)
 }
 
 func (p *server) Serve(ctx context.Context) error {
-	return p.serve(ctx, nil)
+	return p.serveURI(ctx, nil)
 }
 
-func (p *server) serve(ctx context.Context, tc *tls.Config) error {
-	u, err := transport.ParseURI(p.addr)
-	if err != nil {
-		return err
+func (p *server) ServeListener(ctx context.Context, listener net.Listener) error {
+	return p.serve(ctx, transport.NewTCPServerTransport(listener))
+}
+
+func (p *server) serveURI(ctx context.Context, tc *tls.Config) error {
+	if len(p.addrs) < 1 {
+		return errNoTransport
 	}
-	err = fragmentation.IsValidFragment(p.fragment)
-	if err != nil {
+	tps := make([]transport.ServerTransport, 0, len(p.addrs))
+	for _, addr := range p.addrs {
+		u, err := transport.ParseURI(addr)
+		if err == nil {
+			var t transport.ServerTransport
+			if t, err = u.MakeServerTransport(tc); err == nil {
+				tps = append(tps, t)
+				continue
+			}
+		}
+		closeServerTransports(tps)
 		return err
 	}
-	t, err := u.MakeServerTransport(tc)
+	return p.serve(ctx, tps...)
+}
+
+func (p *server) serve(ctx context.Context, tps ...transport.ServerTransport) error {
+	defer closeServerTransports(tps)
+	err := fragmentation.IsValidFragment(p.fragment)
 	if err != nil {
 		return err
 	}
+	p.locker.Lock()
+	p.tps = tps
+	p.locker.Unlock()
 
+	// Connections outlive transports during shutdown, they will be closed after draining.
+	connCtx, cancelConns := context.WithCancel(ctx)
 	defer func() {
-		_ = t.Close()
+		if !p.shutting.Load() {
+			cancelConns()
+			return
+		}
+		go func() {
+			<-p.done
+			cancelConns()
+		}()
 	}()
 
+	// Transports are stopped together once any of them exits.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	go func(ctx context.Context) {
 		_ = p.loopCleanSession(ctx)
-	}(ctx)
+	}(connCtx)
+
+	for _, t := range tps {
+		t.Accept(func(_ context.Context, tp *transport.Transport) {
+			p.accept(connCtx, tp)
+		})
+	}
 
-	t.Accept(func(ctx context.Context, tp *transport.Transport) {
-		socketChan := make(chan socket.ServerSocket, 1)
-		defer func() {
+	serveNotifier := make(chan struct{}, len(tps))
+	go func(ctx context.Context, fn []func()) {
+		for range tps {
 			select {
-			case ssk, ok := <-socketChan:
-				if !ok {
-					break
-				}
-				_, ok = ssk.Token()
-				if !ok {
-					_ = ssk.Close()
-					break
-				}
-				ssk.Pause()
-				deadline := time.Now().Add(p.resumeOpts.sessionDuration)
-				s := session.NewSession(deadline, ssk)
-				p.sm.Push(s)
-				if logger.IsDebugEnabled() {
-					logger.Debugf("store session: %s\n", s)
-				}
-			default:
+			case <-ctx.Done():
+				return
+			case <-serveNotifier:
 			}
-			close(socketChan)
-		}()
-
-		first, err := tp.ReadFirst(ctx)
-		if err != nil {
-			logger.Errorf("read first frame failed: %s\n", err)
-			_ = tp.Close()
-			return
 		}
+		for i := range fn {
+			fn[i]()
+		}
+	}(ctx, p.onServe)
+
+	errs := make(chan error, len(tps))
+	for _, t := range tps {
+		go func(t transport.ServerTransport) {
+			err := t.Listen(ctx, serveNotifier)
+			cancel()
+			errs <- err
+		}(t)
+	}
+	for range tps {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
 
-		switch frame := first.(type) {
-		case *framing.FrameResume:
-			p.doResume(frame, tp, socketChan)
-		case *framing.FrameSetup:
-			sendingSocket, err := p.doSetup(frame, tp, socketChan)
-			if err != nil {
-				_ = tp.Send(err, true)
-				_ = tp.Close()
-				return
+func (p *server) accept(ctx context.Context, tp *transport.Transport) {
+	socketChan := make(chan socket.ServerSocket, 1)
+	var setup *framing.FrameSetup
+	defer func() {
+		select {
+		case ssk, ok := <-socketChan:
+			if !ok {
+				break
+			}
+			p.disconnected(ssk)
+			_, ok = ssk.Token()
+			// Sessions are useless after shutdown.
+			if !ok || p.shutting.Load() {
+				_ = ssk.Close()
+				break
+			}
+			ssk.Pause()
+			deadline := time.Now().Add(p.resumeOpts.sessionDuration)
+			s := session.NewSession(deadline, ssk, setup)
+			if err := p.resumeOpts.store.Store(s); err != nil {
+				logger.Errorf("store session failed: %s\n", err)
+				_ = s.Close()
+			} else if logger.IsDebugEnabled() {
+				logger.Debugf("store session: %s\n", s)
 			}
-			go func(ctx context.Context, sendingSocket socket.ServerSocket) {
-				if err := sendingSocket.Start(ctx); err != nil && logger.IsDebugEnabled() {
-					logger.Debugf("sending socket exit: %w\n", err)
-				}
-			}(ctx, sendingSocket)
 		default:
-			err := framing.NewFrameError(0, common.ErrorCodeConnectionError, []byte("first frame must be setup or resume"))
+		}
+		close(socketChan)
+	}()
+
+	tp.SetMetrics(p.metrics)
+	if p.shutting.Load() {
+		_ = tp.Send(framing.NewFrameError(0, common.ErrorCodeConnectionClose, errServerShuttingDown), true)
+		_ = tp.Close()
+		return
+	}
+
+	first, err := tp.ReadFirst(ctx)
+	if err != nil {
+		logger.Errorf("read first frame failed: %s\n", err)
+		_ = tp.Close()
+		return
+	}
+
+	switch frame := first.(type) {
+	case *framing.FrameResume:
+		setup = p.doResume(ctx, frame, tp, socketChan)
+	case *framing.FrameSetup:
+		setup = frame
+		sendingSocket, err := p.doSetup(frame, tp, socketChan)
+		if err != nil {
 			_ = tp.Send(err, true)
 			_ = tp.Close()
 			return
 		}
-		if err := tp.Start(ctx); err != nil {
-			logger.Warnf("transport exit: %s\n", err.Error())
-		}
-	})
+		p.register(frame, sendingSocket)
+		go func(ctx context.Context, sendingSocket socket.ServerSocket) {
+			if err := sendingSocket.Start(ctx); err != nil && logger.IsDebugEnabled() {
+				logger.Debugf("sending socket exit: %v\n", err)
+			}
+		}(ctx, sendingSocket)
+	default:
+		err := framing.NewFrameError(0, common.ErrorCodeConnectionError, []byte("first frame must be setup or resume"))
+		_ = tp.Send(err, true)
+		_ = tp.Close()
+		return
+	}
+	if err := tp.Start(ctx); err != nil {
+		logger.Warnf("transport exit: %s\n", err.Error())
+	}
+}
 
-	serveNotifier := make(chan struct{})
-	go func(c <-chan struct{}, fn []func()) {
-		<-c
-		for i := range fn {
-			fn[i]()
-		}
-	}(serveNotifier, p.onServe)
-	return t.Listen(ctx, serveNotifier)
+func closeServerTransports(tps []transport.ServerTransport) {
+	for _, t := range tps {
+		_ = t.Close()
+	}
 }
 
 func (p *server) doSetup(
@@ -260,7 +433,16 @@ func (p *server) doSetup(
 		return
 	}
 
-	rawSocket := socket.NewServerDuplexRSocket(p.fragment, p.leases)
+	// 1.1 resume cannot work if frame positions are not kept by transport.
+	if isResume && !tp.Resumable() {
+		err = framing.NewFrameError(0, common.ErrorCodeUnsupportedSetup, errUnresumableTransport)
+		return
+	}
+
+	rawSocket, err := p.newSocket(frame)
+	if err != nil {
+		return
+	}
 
 	// 2. no resume
 	if !isResume {
@@ -270,21 +452,22 @@ func (p *server) doSetup(
 		} else {
 			sendingSocket.SetResponder(responder)
 			sendingSocket.SetTransport(tp)
+			p.connected(sendingSocket)
 			socketChan <- sendingSocket
 		}
 		return
 	}
 
 	token := make([]byte, len(frame.Token()))
+	copy(token, frame.Token())
 
 	// 3. resume reject because of duplicated token.
-	if _, ok := p.sm.Load(token); ok {
+	if _, ok := p.resumeOpts.store.Load(token); ok {
 		err = framing.NewFrameError(0, common.ErrorCodeRejectedSetup, errDuplicatedSetupToken)
 		return
 	}
 
 	// 4. resume success
-	copy(token, frame.Token())
 	sendingSocket = socket.NewServerResume(rawSocket, token)
 	if responder, e := p.acc(frame, sendingSocket); e != nil {
 		switch vv := e.(type) {
@@ -296,21 +479,41 @@ func (p *server) doSetup(
 	} else {
 		sendingSocket.SetResponder(responder)
 		sendingSocket.SetTransport(tp)
+		p.connected(sendingSocket)
 		socketChan <- sendingSocket
 	}
 	return
 }
 
-func (p *server) doResume(frame *framing.FrameResume, tp *transport.Transport, socketChan chan<- socket.ServerSocket) {
+func (p *server) doResume(
+	ctx context.Context,
+	frame *framing.FrameResume,
+	tp *transport.Transport,
+	socketChan chan<- socket.ServerSocket,
+) (setup *framing.FrameSetup) {
 	var sending framing.Frame
+	var resumed *session.Session
 	if !p.resumeOpts.enable {
 		sending = framing.NewFrameError(0, common.ErrorCodeRejectedResume, errUnavailableResume)
-	} else if s, ok := p.sm.Load(frame.Token()); ok {
-		sending = framing.NewResumeOK(0)
-		s.Socket().SetTransport(tp)
-		socketChan <- s.Socket()
-		if logger.IsDebugEnabled() {
-			logger.Debugf("recover session: %s\n", s)
+	} else if !tp.Resumable() {
+		sending = framing.NewFrameError(0, common.ErrorCodeRejectedResume, errUnresumableTransport)
+	} else if s, ok := p.resumeOpts.store.Remove(frame.Token()); ok {
+		if ss, position, err := p.resumeSession(ctx, s, frame); err != nil {
+			// The gap cannot be bridged any more, so session is useless.
+			sending = framing.NewFrameError(0, common.ErrorCodeRejectedResume, []byte(err.Error()))
+			if logger.IsDebugEnabled() {
+				logger.Debugf("reject session %s: %s\n", s, err)
+			}
+			if e := s.Close(); e != nil {
+				logger.Warnf("close rejected session failed: %s\n", e)
+			}
+		} else {
+			sending = framing.NewResumeOK(position)
+			resumed = ss
+			setup = ss.Setup()
+			if logger.IsDebugEnabled() {
+				logger.Debugf("recover session: %s\n", ss)
+			}
 		}
 	} else {
 		sending = framing.NewFrameError(
@@ -322,7 +525,158 @@ func (p *server) doResume(frame *framing.FrameResume, tp *transport.Transport, s
 	if err := tp.Send(sending, true); err != nil {
 		logger.Errorf("send resume response failed: %s\n", err)
 		_ = tp.Close()
+		// Keep session for next resume.
+		if resumed != nil {
+			if err := p.resumeOpts.store.Store(resumed); err != nil {
+				logger.Errorf("store session failed: %s\n", err)
+				_ = resumed.Close()
+			}
+		}
+		return
+	}
+	// RESUME_OK must be sent before retransmitted frames.
+	if resumed != nil {
+		resumed.Socket().SetTransport(tp)
+		p.connected(resumed.Socket())
+		socketChan <- resumed.Socket()
+	}
+	return
+}
+
+// resumeSession validates resume positions of a stored session.
+// A session without socket, such as one loaded from a file, will be revived by acceptor with its SETUP frame.
+func (p *server) resumeSession(
+	ctx context.Context,
+	stored ResumeSession,
+	frame *framing.FrameResume,
+) (s *session.Session, position uint64, err error) {
+	s, err = toSession(stored)
+	if err != nil {
+		return
+	}
+	if s.Socket() == nil {
+		rawSocket, fe := p.newSocket(s.Setup())
+		if fe != nil {
+			err = fe
+			return
+		}
+		sk := socket.NewServerResume(rawSocket, s.Token())
+		sk.Restore(s.Snapshot())
+		responder, e := p.acc(s.Setup(), sk)
+		if e != nil {
+			_ = sk.Close()
+			err = e
+			return
+		}
+		sk.SetResponder(responder)
+		p.register(s.Setup(), sk)
+		go func(ctx context.Context, sk socket.ServerSocket) {
+			if err := sk.Start(ctx); err != nil && logger.IsDebugEnabled() {
+				logger.Debugf("sending socket exit: %v\n", err)
+			}
+		}(ctx, sk)
+		s = session.NewSession(s.Deadline(), sk, s.Setup())
+	}
+	position, err = s.Socket().Resume(frame.LastReceivedServerPosition(), frame.FirstAvailableClientPosition())
+	if err != nil && s != stored {
+		_ = s.Close()
+	}
+	return
+}
+
+func (p *server) newSocket(setup *framing.FrameSetup) (*socket.DuplexRSocket, *framing.FrameError) {
+	sk := socket.NewServerDuplexRSocket(p.fragment, p.leases)
+	sk.SetSetup(setup)
+	sk.SetMetrics(p.metrics)
+	sk.SetPrefetch(p.prefetch)
+	sk.SetExtensions(p.ext)
+	sk.Intercept(p.intercept...)
+	if p.auth != nil {
+		c, err := newConnectionAuthenticator(p.auth, setup)
+		if err != nil {
+			return nil, err
+		}
+		sk.Authenticate(c.authenticate)
 	}
+	return sk, nil
+}
+
+func (p *server) register(setup *framing.FrameSetup, sendingSocket socket.ServerSocket) {
+	if p.registry != nil {
+		p.registry.Register(setup, sendingSocket)
+	}
+}
+
+func (p *server) Shutdown(ctx context.Context) (err error) {
+	if !p.shutting.CAS(false, true) {
+		return
+	}
+	// Stop accepting new connections before draining.
+	p.locker.Lock()
+	tps := p.tps
+	p.locker.Unlock()
+	closeServerTransports(tps)
+
+	ttl := serverSessionDuration
+	if deadline, ok := ctx.Deadline(); ok {
+		ttl = time.Until(deadline)
+	}
+	for _, it := range p.connectedSockets() {
+		it.GoAway(ttl, GoAwayMetadata)
+	}
+
+	tk := time.NewTicker(serverDrainCheckInterval)
+L:
+	for p.inFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break L
+		case <-tk.C:
+		}
+	}
+	tk.Stop()
+
+	for _, it := range p.connectedSockets() {
+		if e := it.CloseWithError(common.ErrorCodeConnectionClose, errServerShuttingDown); e != nil {
+			logger.Warnf("close socket failed: %s\n", e)
+		}
+	}
+	close(p.done)
+	return
+}
+
+// connected tracks a socket until its transport is lost.
+func (p *server) connected(sk socket.ServerSocket) {
+	p.locker.Lock()
+	p.sockets[sk] = struct{}{}
+	p.locker.Unlock()
+	// Socket may be connected after shutdown begins.
+	if p.shutting.Load() {
+		sk.GoAway(0, GoAwayMetadata)
+	}
+}
+
+func (p *server) disconnected(sk socket.ServerSocket) {
+	p.locker.Lock()
+	delete(p.sockets, sk)
+	p.locker.Unlock()
+}
+
+func (p *server) connectedSockets() (sockets []socket.ServerSocket) {
+	p.locker.Lock()
+	for it := range p.sockets {
+		sockets = append(sockets, it)
+	}
+	p.locker.Unlock()
+	return
+}
+
+func (p *server) inFlight() (n int) {
+	for _, it := range p.connectedSockets() {
+		n += it.InFlight()
+	}
+	return
 }
 
 func (p *server) loopCleanSession(ctx context.Context) (err error) {
@@ -347,38 +701,21 @@ L:
 }
 
 func (p *server) destroySessions() {
-	for p.sm.Len() > 0 {
-		nextSession := p.sm.Pop()
-		if err := nextSession.Close(); err != nil {
-			logger.Warnf("kill session failed: %s\n", err)
-		} else if logger.IsDebugEnabled() {
-			logger.Debugf("kill session success: %s\n", nextSession)
-		}
+	if err := p.resumeOpts.store.Close(); err != nil {
+		logger.Warnf("kill session failed: %s\n", err)
+	} else if logger.IsDebugEnabled() {
+		logger.Debugf("kill sessions success\n")
 	}
 }
 
 func (p *server) doCleanSession() {
-	deads := make(chan *session.Session)
-	go func(deads chan *session.Session) {
-		for it := range deads {
-			if err := it.Close(); err != nil {
-				logger.Warnf("close dead session failed: %s\n", err)
-			} else if logger.IsDebugEnabled() {
-				logger.Debugf("close dead session success: %s\n", it)
-			}
-		}
-	}(deads)
-	var cur *session.Session
-	for p.sm.Len() > 0 {
-		cur = p.sm.Pop()
-		// Push back if session is still alive.
-		if !cur.IsDead() {
-			p.sm.Push(cur)
-			break
+	for _, it := range p.resumeOpts.store.Expire(time.Now()) {
+		if err := it.Close(); err != nil {
+			logger.Warnf("close dead session failed: %s\n", err)
+		} else if logger.IsDebugEnabled() {
+			logger.Debugf("close dead session success: %s\n", it)
 		}
-		deads <- cur
 	}
-	close(deads)
 }
 
 // WithServerResumeSessionDuration sets resume session duration for RSocket server.
@@ -0,0 +1,177 @@
+package rsocket_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	const addr = "127.0.0.1:7991"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	start := Receive().
+		OnStart(func() {
+			close(serving)
+		}).
+		Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+			return NewAbstractSocket(
+				RequestResponse(func(msg Payload) mono.Mono {
+					return mono.Just(msg)
+				}),
+				RequestStream(func(msg Payload) flux.Flux {
+					return flux.Create(func(ctx context.Context, s flux.Sink) {
+						for i := 0; i < 5; i++ {
+							time.Sleep(100 * time.Millisecond)
+							s.Next(NewString(fmt.Sprintf("%s_%d", msg.DataUTF8(), i), ""))
+						}
+						s.Complete()
+					})
+				}),
+			), nil
+		}).
+		Transport("tcp://" + addr)
+	served := make(chan error, 1)
+	go func() {
+		served <- start.Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	goaway := make(chan struct{}, 1)
+	closed := make(chan error, 1)
+	cli, err := Connect().
+		OnClose(func(err error) {
+			closed <- err
+		}).
+		Acceptor(func(socket RSocket) RSocket {
+			return NewAbstractSocket(MetadataPush(func(msg Payload) {
+				if m, _ := msg.Metadata(); bytes.Equal(m, GoAwayMetadata) {
+					goaway <- struct{}{}
+				}
+			}))
+		}).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	var received []string
+	completed := make(chan struct{})
+	cli.RequestStream(NewString("hello", "")).
+		DoFinally(func(s rx.SignalType) {
+			close(completed)
+		}).
+		Subscribe(ctx, rx.OnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+		}))
+	time.Sleep(150 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		timeout, cancelTimeout := context.WithTimeout(ctx, 3*time.Second)
+		defer cancelTimeout()
+		shutdown <- start.Shutdown(timeout)
+	}()
+
+	select {
+	case <-goaway:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "goaway should be received")
+	}
+	_, err = cli.RequestResponse(NewString("world", "")).Block(ctx)
+	assert.Error(t, err, "new request should be rejected after goaway")
+	_, err = Connect().Transport("tcp://" + addr).Start(ctx)
+	assert.Error(t, err, "new connection should be refused during draining")
+
+	// Stream in flight should be finished during draining.
+	<-completed
+	assert.Equal(t, []string{"hello_0", "hello_1", "hello_2", "hello_3", "hello_4"}, received)
+
+	require.NoError(t, <-shutdown)
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "client should be closed after shutdown")
+	}
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "serve should return after shutdown")
+	}
+
+	_, err = Connect().Transport("tcp://" + addr).Start(ctx)
+	assert.Error(t, err, "new connection should fail after shutdown")
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	const addr = "127.0.0.1:7992"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	start := Receive().
+		OnStart(func() {
+			close(serving)
+		}).
+		Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+			return NewAbstractSocket(
+				RequestStream(func(msg Payload) flux.Flux {
+					return flux.Create(func(ctx context.Context, s flux.Sink) {
+						<-ctx.Done()
+					})
+				}),
+			), nil
+		}).
+		Transport("tcp://" + addr)
+	go func() {
+		_ = start.Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().Transport("tcp://" + addr).Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	streamErr := make(chan error, 1)
+	cli.RequestStream(NewString("hello", "")).
+		Subscribe(ctx, rx.OnError(func(e error) {
+			streamErr <- e
+		}))
+	time.Sleep(100 * time.Millisecond)
+
+	timeout, cancelTimeout := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancelTimeout()
+	assert.Equal(t, context.DeadlineExceeded, start.Shutdown(timeout))
+
+	select {
+	case err := <-streamErr:
+		assert.Error(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "unfinished stream should be failed after shutdown")
+	}
+}
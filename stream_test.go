@@ -0,0 +1,131 @@
+package rsocket_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestStream_Credits(t *testing.T) {
+	const addr = "127.0.0.1:8002"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+
+	// connect returns a client and the raw connection of server side.
+	connect := func(prefetch int) (Client, *rawConn) {
+		cli, err := Connect().
+			Prefetch(prefetch).
+			Transport("tcp://" + addr).
+			Start(ctx)
+		require.NoError(t, err)
+		c, err := l.Accept()
+		require.NoError(t, err)
+		conn := &rawConn{Conn: c, decoder: transport.NewLengthBasedFrameDecoder(c)}
+		require.IsType(t, &framing.FrameSetup{}, conn.read(t))
+		return cli, conn
+	}
+
+	next := func(conn *rawConn, sid uint32, data string) {
+		conn.write(t, framing.NewFramePayload(sid, []byte(data), nil, framing.FlagNext))
+	}
+
+	t.Run("Prefetch", func(t *testing.T) {
+		cli, conn := connect(4)
+		defer func() {
+			_ = cli.Close()
+		}()
+		received := make(chan string, 16)
+		go func() {
+			_, _ = cli.RequestStream(NewString("hello", "")).
+				DoOnNext(func(input Payload) {
+					received <- input.DataUTF8()
+				}).
+				BlockLast(ctx)
+		}()
+
+		// unbounded demand of subscriber is requested in batches.
+		f := conn.read(t)
+		require.IsType(t, &framing.FrameRequestStream{}, f)
+		sid := f.Header().StreamID()
+		assert.Equal(t, uint32(4), f.(*framing.FrameRequestStream).InitialRequestN())
+
+		for i := 0; i < 3; i++ {
+			next(conn, sid, fmt.Sprintf("%d", i))
+		}
+		f = conn.read(t)
+		require.IsType(t, &framing.FrameRequestN{}, f)
+		assert.Equal(t, uint32(3), f.(*framing.FrameRequestN).N())
+
+		conn.write(t, framing.NewFramePayload(sid, []byte("3"), nil, framing.FlagNext|framing.FlagComplete))
+		for i := 0; i < 4; i++ {
+			select {
+			case it := <-received:
+				assert.Equal(t, fmt.Sprintf("%d", i), it)
+			case <-time.After(3 * time.Second):
+				require.Fail(t, "receive timeout")
+			}
+		}
+	})
+
+	t.Run("ExceedCredits", func(t *testing.T) {
+		cli, conn := connect(0)
+		defer func() {
+			_ = cli.Close()
+		}()
+		received := make(chan string, 16)
+		done := make(chan error, 1)
+		cli.RequestStream(NewString("hello", "")).Subscribe(
+			ctx,
+			rx.OnSubscribe(func(s rx.Subscription) {
+				s.Request(1)
+			}),
+			rx.OnNext(func(input Payload) {
+				received <- input.DataUTF8()
+			}),
+			rx.OnError(func(e error) {
+				done <- e
+			}),
+		)
+
+		f := conn.read(t)
+		require.IsType(t, &framing.FrameRequestStream{}, f)
+		sid := f.Header().StreamID()
+		assert.Equal(t, uint32(1), f.(*framing.FrameRequestStream).InitialRequestN())
+
+		next(conn, sid, "0")
+		next(conn, sid, "1")
+
+		select {
+		case err := <-done:
+			assertErrorCode(t, ErrorCodeInvalid, err)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "stream should be terminated")
+		}
+		// responder is stopped by CANCEL.
+		f = conn.read(t)
+		require.IsType(t, &framing.FrameCancel{}, f)
+		assert.Equal(t, sid, f.Header().StreamID())
+		close(received)
+		var all []string
+		for it := range received {
+			all = append(all, it)
+		}
+		assert.Equal(t, []string{"0"}, all)
+	})
+}
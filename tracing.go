@@ -0,0 +1,215 @@
+package rsocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rsocket/rsocket-go/extension"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/logger"
+	"github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"go.uber.org/atomic"
+)
+
+var errMalformedCompositeMetadata = errors.New("rsocket: malformed composite metadata")
+
+type (
+	// Span represents a traced request.
+	Span interface {
+		// Context returns tracing context of span, it will be propagated to peer.
+		Context() extension.ZipkinTracing
+		// Finish finishes span with error of request.
+		// Error is nil if request completes, or context.Canceled if request is cancelled.
+		Finish(err error)
+	}
+
+	// Tracer starts spans of requests, it can be used to bridge a tracing system such as Zipkin.
+	Tracer interface {
+		// StartSpan starts a span of request, name is the interaction model, such as REQUEST_RESPONSE.
+		// For requester side, parent is the tracing metadata in request if exists.
+		// For responder side, parent is the tracing metadata propagated by peer if exists.
+		StartSpan(call *Call, name string, parent *extension.ZipkinTracing) Span
+	}
+)
+
+// NewTracingInterceptor returns an Interceptor which emits spans of requests through tracer.
+// If metadata MIME type of connection is composite metadata, tracing context will be injected into
+// requests as message/x.rsocket.tracing-zipkin.v0 entry, and be extracted from incoming requests.
+// The entry of incoming request will be replaced by context of responder span, so a handler can
+// propagate the trace by forwarding the metadata to next hop.
+// Malformed composite metadata is passed through unchanged without tracing context.
+func NewTracingInterceptor(tracer Tracer) Interceptor {
+	t := tracingInterceptor{tracer: tracer}
+	return NewInterceptor(
+		InterceptFireAndForget(t.fireAndForget),
+		InterceptMetadataPush(t.metadataPush),
+		InterceptRequestResponse(t.requestResponse),
+		InterceptRequestStream(t.requestStream),
+		InterceptRequestChannel(t.requestChannel),
+	)
+}
+
+type tracingInterceptor struct {
+	tracer Tracer
+}
+
+func (p tracingInterceptor) fireAndForget(call *Call, msg payload.Payload, next func(payload.Payload)) {
+	span, msg := p.start(call, framing.FrameTypeRequestFNF, msg)
+	defer span.Finish(nil)
+	next(msg)
+}
+
+func (p tracingInterceptor) metadataPush(call *Call, msg payload.Payload, next func(payload.Payload)) {
+	span, msg := p.start(call, framing.FrameTypeMetadataPush, msg)
+	defer span.Finish(nil)
+	next(msg)
+}
+
+func (p tracingInterceptor) requestResponse(call *Call, msg payload.Payload, next func(payload.Payload) mono.Mono) mono.Mono {
+	span, msg := p.start(call, framing.FrameTypeRequestResponse, msg)
+	res := next(msg)
+	if res == nil {
+		span.Finish(nil)
+		return nil
+	}
+	var err error
+	return res.
+		DoOnError(func(e error) {
+			err = e
+		}).
+		DoFinally(func(s rx.SignalType) {
+			span.Finish(finishError(s, err))
+		})
+}
+
+func (p tracingInterceptor) requestStream(call *Call, msg payload.Payload, next func(payload.Payload) flux.Flux) flux.Flux {
+	span, msg := p.start(call, framing.FrameTypeRequestStream, msg)
+	res := next(msg)
+	if res == nil {
+		span.Finish(nil)
+		return nil
+	}
+	var err error
+	return res.
+		DoOnError(func(e error) {
+			err = e
+		}).
+		DoFinally(func(s rx.SignalType) {
+			span.Finish(finishError(s, err))
+		})
+}
+
+func (p tracingInterceptor) requestChannel(call *Call, msgs rx.Publisher, next func(rx.Publisher) flux.Flux) flux.Flux {
+	inputs, ok := msgs.(flux.Flux)
+	if !ok {
+		return next(msgs)
+	}
+	// Tracing metadata is carried by the first payload, so span starts when it arrives.
+	var locker sync.Mutex
+	var span Span
+	first := atomic.NewBool(true)
+	inputs = inputs.Map(func(msg payload.Payload) payload.Payload {
+		if !first.CAS(true, false) {
+			return msg
+		}
+		locker.Lock()
+		span, msg = p.start(call, framing.FrameTypeRequestChannel, msg)
+		locker.Unlock()
+		return msg
+	})
+	res := next(inputs)
+	if res == nil {
+		return nil
+	}
+	var err error
+	return res.
+		DoOnError(func(e error) {
+			err = e
+		}).
+		DoFinally(func(s rx.SignalType) {
+			locker.Lock()
+			defer locker.Unlock()
+			if span != nil {
+				span.Finish(finishError(s, err))
+			}
+		})
+}
+
+// start starts a span, and replaces tracing metadata of message with context of span.
+func (p tracingInterceptor) start(call *Call, t framing.FrameType, msg payload.Payload) (Span, payload.Payload) {
+	composite := call.Setup != nil && call.Setup.MetadataMimeType() == extension.MessageCompositeMetadata.String()
+	var parent *extension.ZipkinTracing
+	metadata, ok := msg.Metadata()
+	if composite && ok {
+		parent = extractZipkin(metadata)
+	}
+	span := p.tracer.StartSpan(call, t.String(), parent)
+	if !composite {
+		return span, msg
+	}
+	injected, err := injectZipkin(metadata, span.Context())
+	if err != nil {
+		logger.Warnf("inject tracing metadata failed: %s\n", err)
+		return span, msg
+	}
+	return span, payload.New(msg.Data(), injected)
+}
+
+func finishError(s rx.SignalType, err error) error {
+	switch s {
+	case rx.SignalError:
+		return err
+	case rx.SignalCancel:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// rangeComposite iterates entries of composite metadata, it returns false if metadata is malformed.
+func rangeComposite(metadata []byte, fn func(mimeType string, metadata []byte)) (ok bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			ok = false
+		}
+	}()
+	scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+	for scanner.Scan() {
+		mimeType, metadata, err := scanner.Metadata()
+		if err != nil {
+			return false
+		}
+		fn(mimeType, metadata)
+	}
+	return true
+}
+
+func extractZipkin(metadata []byte) (found *extension.ZipkinTracing) {
+	rangeComposite(metadata, func(mimeType string, metadata []byte) {
+		if found != nil || mimeType != extension.MessageZipkin.String() {
+			return
+		}
+		if z, err := extension.ParseZipkinTracing(metadata); err == nil {
+			found = &z
+		}
+	})
+	return
+}
+
+// injectZipkin replaces tracing entry of composite metadata, it fails if metadata is malformed.
+func injectZipkin(metadata []byte, z extension.ZipkinTracing) ([]byte, error) {
+	builder := extension.NewCompositeMetadataBuilder()
+	ok := rangeComposite(metadata, func(mimeType string, metadata []byte) {
+		if mimeType != extension.MessageZipkin.String() {
+			builder.Push(mimeType, metadata)
+		}
+	})
+	if !ok {
+		return nil, errMalformedCompositeMetadata
+	}
+	return builder.PushWellKnown(extension.MessageZipkin, z.Bytes()).Build()
+}
@@ -0,0 +1,151 @@
+package rsocket_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/extension"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type fakeSpan struct {
+	tracer   *fakeTracer
+	name     string
+	ctx      extension.ZipkinTracing
+	finished *atomic.Bool
+}
+
+func (p *fakeSpan) Context() extension.ZipkinTracing {
+	return p.ctx
+}
+
+func (p *fakeSpan) Finish(err error) {
+	if p.finished.CAS(false, true) {
+		p.tracer.finished <- p
+	}
+}
+
+type fakeTracer struct {
+	ids      *atomic.Uint64
+	finished chan *fakeSpan
+}
+
+func (p *fakeTracer) StartSpan(call *Call, name string, parent *extension.ZipkinTracing) Span {
+	span := &fakeSpan{
+		tracer:   p,
+		name:     name,
+		finished: atomic.NewBool(false),
+	}
+	span.ctx.SpanID = p.ids.Inc()
+	span.ctx.Sampling = extension.ZipkinSamplingSampled
+	if parent != nil {
+		span.ctx.TraceID = parent.TraceID
+		span.ctx.ParentID = parent.SpanID
+	} else {
+		span.ctx.TraceID = span.ctx.SpanID << 32
+	}
+	return span
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	const addr = "127.0.0.1:7995"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverTracer := &fakeTracer{ids: atomic.NewUint64(100), finished: make(chan *fakeSpan, 8)}
+	clientTracer := &fakeTracer{ids: atomic.NewUint64(0), finished: make(chan *fakeSpan, 8)}
+
+	var locker sync.Mutex
+	var propagated *extension.ZipkinTracing
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Interceptor(NewTracingInterceptor(serverTracer)).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponse(func(msg Payload) mono.Mono {
+						metadata, _ := msg.Metadata()
+						scanner := extension.NewCompositeMetadataBytes(metadata).Scanner()
+						for scanner.Scan() {
+							mimeType, raw, err := scanner.Metadata()
+							if err != nil || mimeType != extension.MessageZipkin.String() {
+								continue
+							}
+							z, err := extension.ParseZipkinTracing(raw)
+							if err == nil {
+								locker.Lock()
+								propagated = &z
+								locker.Unlock()
+							}
+						}
+						return mono.Just(msg)
+					}),
+				), nil
+			}).
+			Transport("tcp://" + addr).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		MetadataMimeType(extension.MessageCompositeMetadata.String()).
+		Interceptor(NewTracingInterceptor(clientTracer)).
+		Transport("tcp://" + addr).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	metadata, err := extension.NewCompositeMetadataBuilder().
+		PushWellKnownString(extension.TextPlain, "hello").
+		Build()
+	require.NoError(t, err)
+	res, err := cli.RequestResponse(New([]byte("ping"), metadata)).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", res.DataUTF8())
+
+	var clientSpan, serverSpan *fakeSpan
+	for _, it := range []struct {
+		ch  chan *fakeSpan
+		dst **fakeSpan
+	}{{clientTracer.finished, &clientSpan}, {serverTracer.finished, &serverSpan}} {
+		select {
+		case span := <-it.ch:
+			*it.dst = span
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "span should be finished")
+		}
+	}
+	assert.Equal(t, "REQUEST_RESPONSE", clientSpan.name)
+	assert.Equal(t, "REQUEST_RESPONSE", serverSpan.name)
+	assert.Equal(t, clientSpan.ctx.TraceID, serverSpan.ctx.TraceID)
+	assert.Equal(t, clientSpan.ctx.SpanID, serverSpan.ctx.ParentID)
+	assert.Zero(t, clientSpan.ctx.ParentID)
+
+	locker.Lock()
+	require.NotNil(t, propagated, "handler should see tracing metadata")
+	assert.Equal(t, serverSpan.ctx, *propagated)
+	locker.Unlock()
+
+	// malformed metadata is passed through as is
+	malformed := append(metadata, 0x01)
+	res, err = cli.RequestResponse(New([]byte("ping"), malformed)).Block(ctx)
+	require.NoError(t, err)
+	m, _ := res.Metadata()
+	assert.Equal(t, []byte(malformed), m)
+}
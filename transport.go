@@ -0,0 +1,156 @@
+package rsocket
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+)
+
+type (
+	// Frame is a frame of RSocket protocol.
+	// Transports only carry frames, Bytes and WriteTo can be used to encode them, DecodeFrame can be used to decode them.
+	Frame interface {
+		io.WriterTo
+		// StreamID returns stream ID of frame, it is zero for frames of connection.
+		StreamID() uint32
+		// Len returns length of encoded frame.
+		Len() int
+		// Bytes returns encoded frame.
+		Bytes() []byte
+	}
+
+	// TransportConn is a connection of custom transport which carries RSocket frames.
+	TransportConn interface {
+		io.Closer
+		// SetDeadline sets deadline of reading frames.
+		// Read should return an error after this deadline, then the connection will be closed.
+		SetDeadline(deadline time.Time) error
+		// RemoteAddr returns the remote network address.
+		RemoteAddr() net.Addr
+		// Read reads next frame, it should return io.EOF after the connection is closed by peer.
+		Read() (Frame, error)
+		// Write writes a frame, the frame may be buffered until Flush.
+		Write(frame Frame) error
+		// Flush flushes buffered frames.
+		Flush() error
+	}
+
+	// ServerTransport is a custom transport of server which accepts connections from clients.
+	ServerTransport interface {
+		io.Closer
+		// Accept registers the handler of incoming connections, it will be called before Listen.
+		Accept(acceptor func(ctx context.Context, conn TransportConn))
+		// Listen starts accepting connections, it should notify notifier when it is ready.
+		// It blocks until current transport is closed or ctx is done.
+		Listen(ctx context.Context, notifier chan<- struct{}) error
+	}
+
+	// ClientTransportFactory dials a connection of custom transport for uri.
+	// tc is TLS config passed by ClientStarter.StartTLS, it is nil if TLS is not required.
+	ClientTransportFactory = func(ctx context.Context, uri *url.URL, tc *tls.Config) (TransportConn, error)
+
+	// ServerTransportFactory creates a server transport of custom transport for uri.
+	// tc is TLS config passed by Start.ServeTLS, it is nil if TLS is not required.
+	ServerTransportFactory = func(uri *url.URL, tc *tls.Config) (ServerTransport, error)
+)
+
+// RegisterTransport registers factories of custom transport for URI scheme,
+// then the scheme can be used by ClientTransportBuilder.Transport and ServerTransportBuilder.Transport.
+// Factories of an existing scheme will be replaced, including builtin schemes, a nil factory means the side is unsupported.
+func RegisterTransport(scheme string, client ClientTransportFactory, server ServerTransportFactory) {
+	var cf transport.ClientTransportFactory
+	if client != nil {
+		cf = func(ctx context.Context, uri *transport.URI, tc *tls.Config, _ map[string][]string) (*transport.Transport, error) {
+			conn, err := client(ctx, (*url.URL)(uri), tc)
+			if err != nil {
+				return nil, err
+			}
+			return transport.NewTransport(rawConn{conn}), nil
+		}
+	}
+	var sf transport.ServerTransportFactory
+	if server != nil {
+		sf = func(uri *transport.URI, tc *tls.Config) (transport.ServerTransport, error) {
+			tp, err := server((*url.URL)(uri), tc)
+			if err != nil {
+				return nil, err
+			}
+			return serverTransport{tp}, nil
+		}
+	}
+	transport.Register(scheme, cf, sf)
+}
+
+// DecodeFrame decodes a frame from bytes, it is the reverse of Frame.Bytes.
+func DecodeFrame(raw []byte) (Frame, error) {
+	f, err := decodeFrame(raw)
+	if err != nil {
+		return nil, err
+	}
+	return frame{f}, nil
+}
+
+func decodeFrame(raw []byte) (framing.Frame, error) {
+	if len(raw) < framing.HeaderLen {
+		return nil, common.ErrInvalidFrame
+	}
+	bf := common.NewByteBuff()
+	if _, err := bf.Write(raw[framing.HeaderLen:]); err != nil {
+		return nil, err
+	}
+	f, err := framing.NewFromBase(framing.NewBaseFrame(framing.ParseFrameHeader(raw), bf))
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// serverTransport adapts a custom ServerTransport.
+type serverTransport struct {
+	ServerTransport
+}
+
+func (p serverTransport) Accept(acceptor transport.ServerTransportAcceptor) {
+	p.ServerTransport.Accept(func(ctx context.Context, conn TransportConn) {
+		acceptor(ctx, transport.NewTransport(rawConn{conn}))
+	})
+}
+
+// frame exposes an internal frame as Frame.
+type frame struct {
+	framing.Frame
+}
+
+func (p frame) StreamID() uint32 {
+	return p.Header().StreamID()
+}
+
+// rawConn adapts a custom TransportConn to carry internal frames.
+type rawConn struct {
+	TransportConn
+}
+
+func (p rawConn) Read() (framing.Frame, error) {
+	f, err := p.TransportConn.Read()
+	if err != nil {
+		return nil, err
+	}
+	if it, ok := f.(frame); ok {
+		return it.Frame, nil
+	}
+	return decodeFrame(f.Bytes())
+}
+
+func (p rawConn) Write(f framing.Frame) error {
+	return p.TransportConn.Write(frame{f})
+}
@@ -0,0 +1,401 @@
+package rsocket_test
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/rsocket/rsocket-go"
+	"github.com/rsocket/rsocket-go/internal/common"
+	"github.com/rsocket/rsocket-go/internal/framing"
+	"github.com/rsocket/rsocket-go/internal/transport"
+	. "github.com/rsocket/rsocket-go/payload"
+	"github.com/rsocket/rsocket-go/rx"
+	"github.com/rsocket/rsocket-go/rx/flux"
+	"github.com/rsocket/rsocket-go/rx/mono"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type chanAddr string
+
+func (p chanAddr) Network() string {
+	return "chan"
+}
+
+func (p chanAddr) String() string {
+	return string(p)
+}
+
+// bytesFrame is a Frame of custom transport which only keeps encoded bytes.
+type bytesFrame []byte
+
+func (p bytesFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(p)
+	return int64(n), err
+}
+
+func (p bytesFrame) StreamID() uint32 {
+	return binary.BigEndian.Uint32(p) & 0x7FFFFFFF
+}
+
+func (p bytesFrame) Len() int {
+	return len(p)
+}
+
+func (p bytesFrame) Bytes() []byte {
+	return p
+}
+
+// chanConn is a custom transport connection which passes encoded frames by channels.
+// It reads frames as bytesFrame if raw is true, otherwise decodes them by DecodeFrame.
+type chanConn struct {
+	addr   chanAddr
+	in     <-chan []byte
+	out    chan<- []byte
+	closed chan struct{}
+	once   *sync.Once
+	raw    bool
+}
+
+func (p *chanConn) Close() error {
+	p.once.Do(func() {
+		close(p.closed)
+	})
+	return nil
+}
+
+func (p *chanConn) SetDeadline(deadline time.Time) error {
+	return nil
+}
+
+func (p *chanConn) RemoteAddr() net.Addr {
+	return p.addr
+}
+
+func (p *chanConn) Read() (Frame, error) {
+	select {
+	case raw := <-p.in:
+		if p.raw {
+			return bytesFrame(raw), nil
+		}
+		return DecodeFrame(raw)
+	case <-p.closed:
+		return nil, io.EOF
+	}
+}
+
+func (p *chanConn) Write(frame Frame) error {
+	b := frame.Bytes()
+	if sid := bytesFrame(b).StreamID(); sid != frame.StreamID() {
+		return fmt.Errorf("stream ID mismatch: %d != %d", sid, frame.StreamID())
+	}
+	select {
+	case p.out <- b:
+		return nil
+	case <-p.closed:
+		return errors.New("closed")
+	}
+}
+
+func (p *chanConn) Flush() error {
+	return nil
+}
+
+type chanServerTransport struct {
+	acceptor func(ctx context.Context, conn TransportConn)
+	conns    chan TransportConn
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (p *chanServerTransport) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+func (p *chanServerTransport) Accept(acceptor func(ctx context.Context, conn TransportConn)) {
+	p.acceptor = acceptor
+}
+
+func (p *chanServerTransport) Listen(ctx context.Context, notifier chan<- struct{}) error {
+	notifier <- struct{}{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.done:
+			return nil
+		case c := <-p.conns:
+			go p.acceptor(ctx, c)
+		}
+	}
+}
+
+func TestRegisterTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := &chanServerTransport{
+		conns: make(chan TransportConn),
+		done:  make(chan struct{}),
+	}
+	var dialed string
+	RegisterTransport("chan", func(ctx context.Context, uri *url.URL, tc *tls.Config) (TransportConn, error) {
+		dialed = uri.Host
+		c2s, s2c := make(chan []byte, 16), make(chan []byte, 16)
+		closed, once := make(chan struct{}), &sync.Once{}
+		st.conns <- &chanConn{addr: "client", in: c2s, out: s2c, closed: closed, once: once}
+		return &chanConn{addr: "server", in: s2c, out: c2s, closed: closed, once: once, raw: true}, nil
+	}, func(uri *url.URL, tc *tls.Config) (ServerTransport, error) {
+		return st, nil
+	})
+	defer RegisterTransport("chan", nil, nil)
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+					return mono.Just(NewString("hello "+msg.DataUTF8(), ""))
+				})), nil
+			}).
+			Transport("chan://foo").
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().Transport("chan://foo").Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+	assert.Equal(t, "foo", dialed)
+	res, err := cli.RequestResponse(NewString("world", "")).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", res.DataUTF8())
+
+	RegisterTransport("chan", nil, nil)
+	_, err = Connect().Transport("chan://foo").Start(ctx)
+	assert.Error(t, err, "scheme should be unregistered")
+}
+
+// testTransport sends requests of all interaction models over transport of uri.
+func testTransport(t *testing.T, uri string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Fragment(128).
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(
+					RequestResponse(func(msg Payload) mono.Mono {
+						return mono.Just(msg)
+					}),
+					RequestStream(func(msg Payload) flux.Flux {
+						return flux.Create(func(ctx context.Context, s flux.Sink) {
+							for i := 0; i < 3; i++ {
+								s.Next(NewString(fmt.Sprintf("%s%d", msg.DataUTF8(), i), ""))
+							}
+							s.Complete()
+						})
+					}),
+					RequestChannel(func(msgs rx.Publisher) flux.Flux {
+						return msgs.(flux.Flux)
+					}),
+				), nil
+			}).
+			Transport(uri).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	cli, err := Connect().
+		Fragment(128).
+		Transport(uri).
+		Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	// large payloads are fragmented
+	large := make([]byte, 1024)
+	for i := range large {
+		large[i] = byte('a' + i%26)
+	}
+	res, err := cli.RequestResponse(New(large, []byte("metadata"))).Block(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, large, res.Data())
+
+	var received []string
+	_, err = cli.RequestStream(NewString("hello", "")).
+		DoOnNext(func(input Payload) {
+			received = append(received, input.DataUTF8())
+		}).
+		BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello0", "hello1", "hello2"}, received)
+
+	last, err := cli.RequestChannel(flux.Just(NewString("a", ""), NewString("b", ""))).BlockLast(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", last.DataUTF8())
+}
+
+func TestUDPTransport(t *testing.T) {
+	testTransport(t, "udp://127.0.0.1:8004")
+}
+
+func TestUDPTransport_Resume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const uri = "udp://127.0.0.1:8014"
+	serving := make(chan struct{})
+	go func() {
+		_ = Receive().
+			Resume().
+			OnStart(func() {
+				close(serving)
+			}).
+			Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+				return NewAbstractSocket(), nil
+			}).
+			Transport(uri).
+			Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	// frame positions are not kept by udp transport, so resume is refused
+	_, err := Connect().
+		Resume().
+		Transport(uri).
+		Start(ctx)
+	assert.Error(t, err)
+
+	// server refuses both SETUP with resume token and RESUME
+	for _, it := range []struct {
+		frame framing.Frame
+		code  common.ErrorCode
+	}{
+		{framing.NewFrameSetup(common.DefaultVersion, 10*time.Second, 60*time.Second, []byte("token"), []byte("text/plain"), []byte("text/plain"), nil, nil, false), common.ErrorCodeUnsupportedSetup},
+		{framing.NewFrameResume(common.DefaultVersion, []byte("token"), 0, 0), common.ErrorCodeRejectedResume},
+	} {
+		u, err := transport.ParseURI(uri)
+		require.NoError(t, err)
+		tp, err := u.MakeClientTransport(ctx, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, tp.Send(it.frame, true))
+		require.NoError(t, tp.Connection().SetDeadline(time.Now().Add(3*time.Second)))
+		f, err := tp.Connection().Read()
+		require.NoError(t, err)
+		require.IsType(t, &framing.FrameError{}, f)
+		assert.Equal(t, it.code, f.(*framing.FrameError).ErrorCode())
+		_ = tp.Close()
+	}
+}
+
+func TestMultipleTransports(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	uris := []string{"tcp://127.0.0.1:8007", "ws://127.0.0.1:8008/rsocket", "mem://multiple"}
+	var accepted atomic.Int32
+	serving := make(chan struct{})
+	start := Receive().
+		OnStart(func() {
+			close(serving)
+		}).
+		Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+			accepted.Inc()
+			return NewAbstractSocket(RequestResponse(func(msg Payload) mono.Mono {
+				return mono.Just(msg)
+			})), nil
+		}).
+		Transport(uris...)
+	served := make(chan error, 1)
+	go func() {
+		served <- start.Serve(ctx)
+	}()
+	select {
+	case <-serving:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server start timeout")
+	}
+
+	for _, uri := range uris {
+		cli, err := Connect().Transport(uri).Start(ctx)
+		require.NoError(t, err, uri)
+		res, err := cli.RequestResponse(NewString(uri, "")).Block(ctx)
+		require.NoError(t, err, uri)
+		assert.Equal(t, uri, res.DataUTF8())
+		_ = cli.Close()
+	}
+	assert.Equal(t, int32(len(uris)), accepted.Load(), "acceptor should be shared")
+
+	// All transports are closed on shutdown.
+	require.NoError(t, start.Shutdown(ctx))
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "server should exit after shutdown")
+	}
+	for _, uri := range uris {
+		_, err := Connect().Transport(uri).Start(ctx)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestMultipleTransports_Failure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:8009")
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+
+	// Listening on an address in use fails, the other transport should be closed too.
+	err = Receive().
+		Acceptor(func(setup SetupPayload, sendingSocket CloseableRSocket) (RSocket, error) {
+			return NewAbstractSocket(), nil
+		}).
+		Transport("tcp://127.0.0.1:8010", "tcp://127.0.0.1:8009").
+		Serve(ctx)
+	assert.Error(t, err)
+	_, err = Connect().Transport("tcp://127.0.0.1:8010").Start(ctx)
+	assert.Error(t, err)
+}